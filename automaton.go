@@ -0,0 +1,295 @@
+package deeply
+
+import (
+	"errors"
+	"fmt"
+	"regexp/syntax"
+	"slices"
+	"unicode"
+)
+
+// automatonLimit is the maximum number of state pairs explored by a product
+// of two automata before giving up.
+const automatonLimit = 1 << 14
+
+var errUnsupportedRegex = errors.New("unsupported regular expression")
+
+// automaton is a deterministic automaton recognizing the strings in which a
+// regular expression finds a match, like regexp.MatchString. Its states are
+// the sets of the instructions of the compiled program, built on the fly.
+//
+// Only the ^ and $ assertions of the text are supported: line and word
+// boundaries depend on the neighboring runes, which the states don't track.
+type automaton struct {
+	prog *syntax.Prog
+}
+
+// autoState is a state of an automaton.
+type autoState struct {
+	pcs      []uint32 // Sorted instructions waiting for a rune or the end of the text.
+	begin    bool     // Whether no rune has been read yet.
+	accepted bool     // Whether a match has been found, so that any continuation matches.
+}
+
+// key identifies the state.
+func (s autoState) key() string {
+	return fmt.Sprint(s.pcs, s.begin, s.accepted)
+}
+
+// compileAutomaton compiles the regular expression with the flags used by regexp.Compile.
+func compileAutomaton(expr string) (*automaton, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inst := range prog.Inst {
+		if inst.Op == syntax.InstEmptyWidth && syntax.EmptyOp(inst.Arg)&^(syntax.EmptyBeginText|syntax.EmptyEndText) != 0 {
+			return nil, fmt.Errorf("%w: %s", errUnsupportedRegex, expr)
+		}
+	}
+
+	return &automaton{prog: prog}, nil
+}
+
+// closure follows the instructions which don't consume runes from the seeds.
+// It reports whether a match is reached.
+func (a *automaton) closure(seeds []uint32, begin, end bool) ([]uint32, bool) {
+	allowed := syntax.EmptyOp(0)
+	if begin {
+		allowed |= syntax.EmptyBeginText
+	}
+
+	if end {
+		allowed |= syntax.EmptyEndText
+	}
+
+	var (
+		res     []uint32
+		matched bool
+		seen    = make(map[uint32]bool)
+		stack   = slices.Clone(seeds)
+	)
+
+	for len(stack) > 0 {
+		pc := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if seen[pc] {
+			continue
+		}
+
+		seen[pc] = true
+		inst := &a.prog.Inst[pc]
+
+		switch inst.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			stack = append(stack, inst.Out, inst.Arg)
+		case syntax.InstCapture, syntax.InstNop:
+			stack = append(stack, inst.Out)
+		case syntax.InstEmptyWidth:
+			op := syntax.EmptyOp(inst.Arg)
+
+			switch {
+			case op&^allowed == 0:
+				stack = append(stack, inst.Out)
+			case op&syntax.EmptyBeginText == 0 || begin:
+				// The end of the text may come later.
+				res = append(res, pc)
+			}
+		case syntax.InstMatch:
+			matched = true
+		case syntax.InstFail:
+		default:
+			res = append(res, pc)
+		}
+	}
+
+	slices.Sort(res)
+
+	return res, matched
+}
+
+// start returns the initial state.
+func (a *automaton) start() autoState {
+	pcs, matched := a.closure([]uint32{uint32(a.prog.Start)}, true, false) //nolint:gosec
+
+	return autoState{pcs: pcs, begin: true, accepted: matched}
+}
+
+// step returns the state after reading the rune. A new match attempt starts
+// at every position, since the expression isn't anchored to the start.
+func (a *automaton) step(s autoState, r rune) autoState {
+	if s.accepted {
+		return s
+	}
+
+	seeds := []uint32{uint32(a.prog.Start)} //nolint:gosec
+
+	for _, pc := range s.pcs {
+		inst := &a.prog.Inst[pc]
+
+		var ok bool
+
+		switch inst.Op { //nolint:exhaustive
+		case syntax.InstRuneAny:
+			ok = true
+		case syntax.InstRuneAnyNotNL:
+			ok = r != '\n'
+		case syntax.InstRune, syntax.InstRune1:
+			ok = inst.MatchRune(r)
+		}
+
+		if ok {
+			seeds = append(seeds, inst.Out)
+		}
+	}
+
+	pcs, matched := a.closure(seeds, false, false)
+
+	return autoState{pcs: pcs, accepted: matched}
+}
+
+// accepts checks if the text read so far matches.
+func (a *automaton) accepts(s autoState) bool {
+	if s.accepted {
+		return true
+	}
+
+	_, matched := a.closure(s.pcs, s.begin, true)
+
+	return matched
+}
+
+// alphabet returns a representative of every class of runes which the
+// instructions of the automata can't tell apart.
+func alphabet(automata ...*automaton) []rune {
+	bounds := []rune{0, '\n', '\n' + 1, unicode.MaxRune + 1, 0xD800, 0xE000} //nolint:mnd
+
+	for _, a := range automata {
+		for _, inst := range a.prog.Inst {
+			switch inst.Op { //nolint:exhaustive
+			case syntax.InstRune1:
+				bounds = append(bounds, inst.Rune[0], inst.Rune[0]+1)
+			case syntax.InstRune:
+				if len(inst.Rune) == 1 {
+					// A single rune may match its other cases.
+					for r := inst.Rune[0]; ; {
+						bounds = append(bounds, r, r+1)
+
+						if r = unicode.SimpleFold(r); r == inst.Rune[0] {
+							break
+						}
+					}
+
+					continue
+				}
+
+				for i := 0; i+1 < len(inst.Rune); i += 2 {
+					bounds = append(bounds, inst.Rune[i], inst.Rune[i+1]+1)
+				}
+			}
+		}
+	}
+
+	slices.Sort(bounds)
+	bounds = slices.Compact(bounds)
+
+	res := make([]rune, 0, len(bounds))
+
+	for _, r := range bounds {
+		// Surrogates are not valid runes of a string.
+		if r <= unicode.MaxRune && (r < 0xD800 || r >= 0xE000) {
+			res = append(res, r)
+		}
+	}
+
+	return res
+}
+
+// product explores the pairs of states of the automata reachable by the same
+// text until stop returns true. It reports whether stop returned true, and
+// false if the exploration was cut short by automatonLimit.
+//
+// The pairs for which skip returns true are not explored further.
+func product(x, y *automaton, stop, skip func(sx, sy autoState) bool) (bool, bool) {
+	type pair struct{ x, y autoState }
+
+	runes := alphabet(x, y)
+	queue := []pair{{x.start(), y.start()}}
+	seen := map[string]bool{queue[0].x.key() + "|" + queue[0].y.key(): true}
+
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+
+		if stop(p.x, p.y) {
+			return true, true
+		}
+
+		if skip(p.x, p.y) {
+			continue
+		}
+
+		for _, r := range runes {
+			next := pair{x.step(p.x, r), y.step(p.y, r)}
+
+			key := next.x.key() + "|" + next.y.key()
+			if seen[key] {
+				continue
+			}
+
+			if len(seen) >= automatonLimit {
+				return false, false
+			}
+
+			seen[key] = true
+			queue = append(queue, next)
+		}
+	}
+
+	return false, true
+}
+
+// regexIntersects checks if some string matches both expressions.
+// It returns true if the question can't be answered.
+func regexIntersects(x, y string) bool {
+	ax, errX := compileAutomaton(x)
+	ay, errY := compileAutomaton(y)
+
+	if errX != nil || errY != nil {
+		return true
+	}
+
+	found, complete := product(ax, ay, func(sx, sy autoState) bool {
+		return ax.accepts(sx) && ay.accepts(sy)
+	}, func(_, _ autoState) bool {
+		return false
+	})
+
+	return found || !complete
+}
+
+// regexSubsumes checks if every string matching y also matches x.
+// It returns false if the question can't be answered.
+func regexSubsumes(x, y string) bool {
+	ax, errX := compileAutomaton(x)
+	ay, errY := compileAutomaton(y)
+
+	if errX != nil || errY != nil {
+		return false
+	}
+
+	found, complete := product(ax, ay, func(sx, sy autoState) bool {
+		return ay.accepts(sy) && !ax.accepts(sx)
+	}, func(sx, _ autoState) bool {
+		return sx.accepted
+	})
+
+	return !found && complete
+}
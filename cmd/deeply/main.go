@@ -0,0 +1,328 @@
+// Command deeply matches JSON and YAML files with the functions of the
+// deeply package, to debug expectations outside the server.
+//
+// Usage:
+//
+//	deeply match [--mode=matches] [--ignore-order] [--format=plain] expect.json actual.json
+//	deeply rank [--mode=matches] [--ignore-order] candidate.yaml... actual.json
+//	deeply explain [--mode=matches] [--ignore-order] expect.json actual.json
+//	deeply lint [--mode=matches] [--ignore-order] stub.yaml...
+//
+// Files with the .yaml or .yml extension are decoded as YAML, other files as JSON.
+//
+// The exit code is 0 if the values match, 1 if they don't and 2 on errors.
+// The exit code of lint is 1 if some stubs overlap.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gripmock/deeply"
+)
+
+// Exit codes of the commands.
+const (
+	exitMatch    = 0
+	exitMismatch = 1
+	exitError    = 2
+)
+
+const usage = `Usage:
+  deeply match [flags] expect actual        check if the actual value matches the expectation
+  deeply rank [flags] candidate... actual   rank the candidates by their match with the actual value
+  deeply explain [flags] expect actual      report the paths at which the values don't match
+  deeply lint [flags] stub...               report the stubs which can match the same input
+
+Flags:
+  --mode string     matches, contains or equals (default "matches")
+  --ignore-order    ignore the order of elements in arrays
+  --format string   diff format of match: plain, ansi or markdown (default "plain")
+
+Files with the .yaml or .yml extension are decoded as YAML, other files as JSON.
+The exit code is 0 if the values match, 1 if they don't and 2 on errors.
+The exit code of lint is 1 if some stubs overlap.
+`
+
+//nolint:gochecknoglobals
+var diffFormats = map[string]deeply.DiffFormat{
+	"plain":    deeply.DiffPlain,
+	"ansi":     deeply.DiffANSI,
+	"markdown": deeply.DiffMarkdown,
+}
+
+var errUsage = errors.New("invalid arguments")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command with the arguments and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+
+		return exitError
+	}
+
+	var command func(flags *options, args []string, stdout io.Writer) (int, error)
+
+	switch args[0] {
+	case "match":
+		command = match
+	case "rank":
+		command = rank
+	case "explain":
+		command = explain
+	case "lint":
+		command = lint
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+
+		return exitMatch
+	default:
+		fmt.Fprintf(stderr, "deeply: unknown command %q\n\n%s", args[0], usage)
+
+		return exitError
+	}
+
+	opts, rest, err := parseFlags(args[0], args[1:], stderr)
+	if err != nil {
+		return exitError
+	}
+
+	code, err := command(opts, rest, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "deeply %s: %v\n", args[0], err)
+
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(stderr, "\n%s", usage)
+		}
+
+		return exitError
+	}
+
+	return code
+}
+
+// options holds the flags of the commands.
+type options struct {
+	mode   deeply.Mode
+	format deeply.DiffFormat
+}
+
+// parseFlags parses the flags of the command and returns the remaining arguments.
+func parseFlags(name string, args []string, stderr io.Writer) (*options, []string, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, usage) }
+
+	mode := fs.String("mode", "matches", "")
+	ignoreOrder := fs.Bool("ignore-order", false, "")
+	format := fs.String("format", "plain", "")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	modeName := *mode
+	if *ignoreOrder && !strings.HasSuffix(modeName, "-ignore-array-order") {
+		modeName += "-ignore-array-order"
+	}
+
+	opts := &options{}
+
+	var err error
+
+	if opts.mode, err = deeply.ParseMode(modeName); err != nil {
+		fmt.Fprintf(stderr, "deeply %s: %v\n", name, err)
+
+		return nil, nil, err
+	}
+
+	var ok bool
+
+	if opts.format, ok = diffFormats[*format]; !ok {
+		err = fmt.Errorf("unknown format %q", *format) //nolint:err113
+		fmt.Fprintf(stderr, "deeply %s: %v\n", name, err)
+
+		return nil, nil, err
+	}
+
+	return opts, fs.Args(), nil
+}
+
+// match prints the diff of the values if they don't match.
+func match(opts *options, args []string, stdout io.Writer) (int, error) {
+	expect, actual, err := readPair(args)
+	if err != nil {
+		return exitError, err
+	}
+
+	if opts.mode.Match(expect, actual) {
+		return exitMatch, nil
+	}
+
+	fmt.Fprint(stdout, deeply.FormatDiff(expect, actual, deeply.DiffOptions{Mode: opts.mode, Format: opts.format}))
+
+	return exitMismatch, nil
+}
+
+// explain prints the mismatches of the values, one per line.
+func explain(opts *options, args []string, stdout io.Writer) (int, error) {
+	expect, actual, err := readPair(args)
+	if err != nil {
+		return exitError, err
+	}
+
+	mismatches := deeply.Explain(expect, actual, opts.mode)
+	for _, m := range mismatches {
+		fmt.Fprintln(stdout, m)
+	}
+
+	if len(mismatches) > 0 {
+		return exitMismatch, nil
+	}
+
+	return exitMatch, nil
+}
+
+// lint prints the pairs of stubs which can match the same input.
+func lint(opts *options, args []string, stdout io.Writer) (int, error) {
+	stubs := make([]deeply.Stub, 0, len(args))
+
+	for _, path := range args {
+		expect, err := readFile(path)
+		if err != nil {
+			return exitError, err
+		}
+
+		stubs = append(stubs, deeply.Stub{Name: path, Expect: expect, Mode: opts.mode})
+	}
+
+	overlaps := deeply.Overlaps(stubs)
+	for _, o := range overlaps {
+		fmt.Fprintln(stdout, o)
+	}
+
+	if len(overlaps) > 0 {
+		return exitMismatch, nil
+	}
+
+	return exitMatch, nil
+}
+
+// candidate is an expectation ranked by the rank command.
+type candidate struct {
+	path    string
+	score   float64
+	matches bool
+}
+
+// rank prints the candidates sorted by their score, best first, and whether
+// they match in the mode. It succeeds if any of the candidates matches.
+func rank(opts *options, args []string, stdout io.Writer) (int, error) {
+	if len(args) < 2 { //nolint:mnd
+		return exitError, fmt.Errorf("%w: expected candidates and an actual value", errUsage)
+	}
+
+	actual, err := readFile(args[len(args)-1])
+	if err != nil {
+		return exitError, err
+	}
+
+	candidates := make([]candidate, 0, len(args)-1)
+
+	for _, path := range args[:len(args)-1] {
+		expect, err := readFile(path)
+		if err != nil {
+			return exitError, err
+		}
+
+		candidates = append(candidates, candidate{
+			path:    path,
+			score:   deeply.RankMatch(expect, actual),
+			matches: opts.mode.Match(expect, actual),
+		})
+	}
+
+	// Matching candidates go first, like the server picks them.
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		if a.matches != b.matches {
+			if a.matches {
+				return -1
+			}
+
+			return 1
+		}
+
+		switch {
+		case a.score > b.score:
+			return -1
+		case a.score < b.score:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	code := exitMismatch
+
+	for _, c := range candidates {
+		status := "mismatch"
+		if c.matches {
+			status, code = "match", exitMatch
+		}
+
+		fmt.Fprintf(stdout, "%.4f\t%s\t%s\n", c.score, status, c.path)
+	}
+
+	return code, nil
+}
+
+// readPair reads the expected and the actual values from the arguments.
+func readPair(args []string) (any, any, error) {
+	if len(args) != 2 { //nolint:mnd
+		return nil, nil, fmt.Errorf("%w: expected 2 files, got %d", errUsage, len(args))
+	}
+
+	expect, err := readFile(args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	actual, err := readFile(args[1])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return expect, actual, nil
+}
+
+// readFile decodes a JSON or YAML file by its extension.
+func readFile(path string) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	decode := deeply.DecodeJSON
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decode = deeply.DecodeYAML
+	}
+
+	value, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return value, nil
+}
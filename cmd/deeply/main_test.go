@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// files writes the files to a temporary directory and returns their paths.
+func files(t *testing.T, contents map[string]string) map[string]string {
+	t.Helper()
+
+	dir := t.TempDir()
+	paths := make(map[string]string, len(contents))
+
+	for name, content := range contents {
+		paths[name] = filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(paths[name], []byte(content), 0o600))
+	}
+
+	return paths
+}
+
+func runArgs(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+
+	code := run(args, &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestMatch(t *testing.T) {
+	p := files(t, map[string]string{
+		"expect.yaml": "name: ^al\nage: 42\ntags: [b, a]\n",
+		"actual.json": `{"name": "alice", "age": 42, "tags": ["a", "b"], "extra": true}`,
+	})
+
+	code, stdout, _ := runArgs("match", "--mode=contains", "--ignore-order", p["expect.yaml"], p["actual.json"])
+	require.Equal(t, exitMismatch, code)
+	require.Contains(t, stdout, `-   "name": "^al"  // not equal`)
+
+	code, stdout, _ = runArgs("match", "--ignore-order", p["expect.yaml"], p["actual.json"])
+	require.Equal(t, exitMatch, code)
+	require.Empty(t, stdout)
+
+	code, _, _ = runArgs("match", p["expect.yaml"], p["actual.json"])
+	require.Equal(t, exitMismatch, code)
+
+	code, stdout, _ = runArgs("match", "--format=markdown", p["expect.yaml"], p["actual.json"])
+	require.Equal(t, exitMismatch, code)
+	require.Contains(t, stdout, "```diff\n")
+}
+
+func TestExplain(t *testing.T) {
+	p := files(t, map[string]string{
+		"expect.json": `{"name": "bob", "age": 42}`,
+		"actual.json": `{"name": "alice", "age": 42}`,
+	})
+
+	code, stdout, _ := runArgs("explain", "--mode=equals", p["expect.json"], p["actual.json"])
+	require.Equal(t, exitMismatch, code)
+	require.Equal(t, "$.name: not equal: expected \"bob\", got \"alice\"\n", stdout)
+
+	code, stdout, _ = runArgs("explain", p["expect.json"], p["expect.json"])
+	require.Equal(t, exitMatch, code)
+	require.Empty(t, stdout)
+}
+
+func TestRank(t *testing.T) {
+	p := files(t, map[string]string{
+		"a.yaml":       "name: bob\n",
+		"b.yaml":       "name: alice\nage: 42\n",
+		"c.yaml":       "name: alice\n",
+		"request.json": `{"name": "alice", "age": 40}`,
+	})
+
+	code, stdout, _ := runArgs("rank", "--mode=contains", p["a.yaml"], p["b.yaml"], p["c.yaml"], p["request.json"])
+	require.Equal(t, exitMatch, code)
+
+	lines := bytes.Split(bytes.TrimSpace([]byte(stdout)), []byte("\n"))
+	require.Len(t, lines, 3)
+	require.Contains(t, string(lines[0]), "\tmatch\t"+p["c.yaml"])
+	require.Contains(t, string(lines[1]), "\tmismatch\t"+p["b.yaml"])
+	require.Contains(t, string(lines[2]), "\tmismatch\t"+p["a.yaml"])
+
+	code, _, _ = runArgs("rank", p["a.yaml"], p["request.json"])
+	require.Equal(t, exitMismatch, code)
+}
+
+func TestLint(t *testing.T) {
+	p := files(t, map[string]string{
+		"a.yaml": "name: ^al\n",
+		"b.yaml": "name: ^alice$\nage: 42\n",
+		"c.yaml": "name: ^bob$\n",
+	})
+
+	code, stdout, _ := runArgs("lint", p["a.yaml"], p["b.yaml"], p["c.yaml"])
+	require.Equal(t, exitMismatch, code)
+	require.Equal(t, p["b.yaml"]+" is shadowed by "+p["a.yaml"]+"\n", stdout)
+
+	code, stdout, _ = runArgs("lint", "--mode=contains", p["a.yaml"], p["b.yaml"], p["c.yaml"])
+	require.Equal(t, exitMatch, code)
+	require.Empty(t, stdout)
+}
+
+func TestErrors(t *testing.T) {
+	p := files(t, map[string]string{
+		"broken.json": `{`,
+		"ok.json":     `{}`,
+	})
+
+	for _, args := range [][]string{
+		{},
+		{"unknown"},
+		{"match", p["ok.json"]},
+		{"match", "--mode=similar", p["ok.json"], p["ok.json"]},
+		{"match", "--format=html", p["ok.json"], p["ok.json"]},
+		{"match", "--unknown", p["ok.json"], p["ok.json"]},
+		{"match", p["broken.json"], p["ok.json"]},
+		{"explain", p["ok.json"], filepath.Join(t.TempDir(), "missing.json")},
+		{"rank", p["ok.json"]},
+	} {
+		code, _, stderr := runArgs(args...)
+		require.Equal(t, exitError, code, args)
+		require.NotEmpty(t, stderr, args)
+	}
+
+	code, stdout, _ := runArgs("help")
+	require.Equal(t, exitMatch, code)
+	require.Contains(t, stdout, "Usage:")
+}
@@ -0,0 +1,123 @@
+package deeply
+
+import (
+	"reflect"
+
+	gocmp "github.com/google/go-cmp/cmp"
+)
+
+// CmpMatches returns a go-cmp option comparing values like Matches.
+// The first argument of cmp.Equal and cmp.Diff is the expectation.
+func CmpMatches() gocmp.Option {
+	return CmpMode(ModeMatches)
+}
+
+// CmpMatchesIgnoreArrayOrder returns a go-cmp option comparing values like MatchesIgnoreArrayOrder.
+func CmpMatchesIgnoreArrayOrder() gocmp.Option {
+	return CmpMode(ModeMatchesIgnoreArrayOrder)
+}
+
+// CmpContains returns a go-cmp option comparing values like Contains.
+func CmpContains() gocmp.Option {
+	return CmpMode(ModeContains)
+}
+
+// CmpContainsIgnoreArrayOrder returns a go-cmp option comparing values like ContainsIgnoreArrayOrder.
+func CmpContainsIgnoreArrayOrder() gocmp.Option {
+	return CmpMode(ModeContainsIgnoreArrayOrder)
+}
+
+// CmpIgnoreArrayOrder returns a go-cmp option comparing values like EqualsIgnoreArrayOrder.
+func CmpIgnoreArrayOrder() gocmp.Option {
+	return CmpMode(ModeEqualsIgnoreArrayOrder)
+}
+
+// CmpMode returns a go-cmp option comparing values in the mode, so that
+// cmp.Equal agrees with the mode and cmp.Diff reports the differences the
+// mode sees. The first argument of cmp.Equal and cmp.Diff is the expectation.
+//
+// The option lets go-cmp descend into the values the mode compares
+// recursively: maps of the same type and, for Matches and Equals, slices
+// of the same type and length. The other values are compared once in the
+// mode, ignored if they match and reported as a whole otherwise, so that
+// the cost stays linear in the size of the values. Keys missing from the
+// expected maps are ignored in modes allowing partial maps.
+//
+// The values are compared within the document of the second argument,
+// so that the operators reading the root of the document, such as $expr,
+// find it at any depth.
+func CmpMode(mode Mode) gocmp.Option {
+	// leaf returns the values of the last step of the path if the mode
+	// compares them as a whole.
+	leaf := func(p gocmp.Path) (any, any, bool) {
+		x, y, ok := pathValues(p)
+
+		return x, y, ok && !descends(mode, x, y)
+	}
+
+	return gocmp.Options{
+		gocmp.FilterPath(func(p gocmp.Path) bool {
+			x, y, ok := leaf(p)
+
+			return ok && mode.matchIn(pathRoot(p), x, y)
+		}, gocmp.Ignore()),
+		gocmp.FilterPath(func(p gocmp.Path) bool {
+			x, y, ok := leaf(p)
+
+			return ok && !mode.matchIn(pathRoot(p), x, y)
+		}, gocmp.Comparer(func(_, _ any) bool { return false })),
+		gocmp.FilterPath(func(p gocmp.Path) bool {
+			mi, ok := p.Last().(gocmp.MapIndex)
+			if !ok || !mode.partialMaps() {
+				return false
+			}
+
+			x, y := mi.Values()
+
+			return !x.IsValid() && y.IsValid()
+		}, gocmp.Ignore()),
+	}
+}
+
+// pathValues returns the values of the last step of the path.
+// It returns false if either of the values is missing or can't be accessed.
+func pathValues(p gocmp.Path) (any, any, bool) {
+	x, y := p.Last().Values()
+	if !x.IsValid() || !y.IsValid() || !x.CanInterface() || !y.CanInterface() {
+		return nil, nil, false
+	}
+
+	return x.Interface(), y.Interface(), true
+}
+
+// pathRoot returns the actual value of the first step of the path, the
+// root of the compared document.
+func pathRoot(p gocmp.Path) any {
+	_, y := p.Index(0).Values()
+	if !y.IsValid() || !y.CanInterface() {
+		return nil
+	}
+
+	return y.Interface()
+}
+
+// descends checks if the mode compares the values recursively, so that
+// go-cmp should descend into them to report the differences.
+func descends(mode Mode, expect, actual any) bool {
+	if _, ok := asOperatorNode(expect); ok || hasTypeComparator(expect, actual) {
+		return false
+	}
+
+	if expect == nil || actual == nil || reflect.TypeOf(expect) != reflect.TypeOf(actual) {
+		return false
+	}
+
+	switch reflect.TypeOf(expect).Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Slice:
+		return mode.orderedSlices() && reflect.ValueOf(expect).Len() == reflect.ValueOf(actual).Len()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,193 @@
+package deeply_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+func TestCmp_Contains(t *testing.T) {
+	expect := map[string]any{
+		"name": "bob",
+		"address": map[string]any{
+			"city": "Berlin",
+		},
+	}
+
+	actual := map[string]any{
+		"name": "bob",
+		"age":  42,
+		"address": map[string]any{
+			"city": "Berlin",
+			"zip":  "10115",
+		},
+	}
+
+	require.True(t, cmp.Equal(expect, actual, deeply.CmpContains()))
+	require.False(t, cmp.Equal(expect, actual))
+	require.False(t, cmp.Equal(actual, expect, deeply.CmpContains()))
+
+	actual["address"].(map[string]any)["city"] = "Munich"
+
+	require.False(t, cmp.Equal(expect, actual, deeply.CmpContains()))
+
+	diff := cmp.Diff(expect, actual, deeply.CmpContains())
+
+	require.Contains(t, diff, `"city": string("Berlin")`)
+	require.Contains(t, diff, `"city": string("Munich")`)
+	require.NotContains(t, diff, "zip")
+	require.NotContains(t, diff, "age")
+}
+
+func TestCmp_ContainsSlices(t *testing.T) {
+	expect := map[string]any{"items": []any{map[string]any{"id": 1}}}
+	actual := map[string]any{"items": []any{map[string]any{"id": 1, "qty": 2}}}
+
+	// Contains compares slices with reflect.DeepEqual, so must the option.
+	require.Equal(t, deeply.Contains(expect, actual), cmp.Equal(expect, actual, deeply.CmpContains()))
+	require.False(t, cmp.Equal(expect, actual, deeply.CmpContains()))
+
+	require.True(t, cmp.Equal(
+		[]any{"b", "a"},
+		[]any{"a", "b", "c"},
+		deeply.CmpContainsIgnoreArrayOrder(),
+	))
+	require.True(t, cmp.Equal(
+		map[string]any{"tags": []any{"b", "a"}},
+		map[string]any{"tags": []any{"a", "b"}, "id": 1},
+		deeply.CmpContainsIgnoreArrayOrder(),
+	))
+}
+
+func TestCmp_Matches(t *testing.T) {
+	expect := map[string]any{
+		"id":     "^[0-9]+$",
+		"cities": []any{"Jakarta", ".*grad$"},
+		"when":   map[string]any{"$after": "2024-01-01"},
+	}
+
+	actual := map[string]any{
+		"id":     "42",
+		"cities": []any{"Jakarta", "Stalingrad"},
+		"when":   "2024-05-01T00:00:00Z",
+		"extra":  true,
+	}
+
+	require.True(t, cmp.Equal(expect, actual, deeply.CmpMatches()))
+
+	actual["cities"] = []any{"Jakarta", "Paris"}
+
+	diff := cmp.Diff(expect, actual, deeply.CmpMatches())
+
+	require.Contains(t, diff, `string(".*grad$")`)
+	require.Contains(t, diff, `string("Paris")`)
+	require.NotContains(t, diff, "Jakarta")
+
+	require.True(t, cmp.Equal([]any{"^b", "^a"}, []any{"ab", "ba"}, deeply.CmpMatchesIgnoreArrayOrder()))
+	require.False(t, cmp.Equal([]any{"^b", "^a"}, []any{"ab", "ba"}, deeply.CmpMatches()))
+}
+
+func TestCmp_IgnoreArrayOrder(t *testing.T) {
+	require.True(t, cmp.Equal([]int{1, 2, 3}, []int{3, 1, 2}, deeply.CmpIgnoreArrayOrder()))
+	require.False(t, cmp.Equal([]int{1, 2, 3}, []int{3, 1, 2, 4}, deeply.CmpIgnoreArrayOrder()))
+	require.False(t, cmp.Equal(
+		map[string]any{"a": []any{1, 2}},
+		map[string]any{"a": []any{2, 1}, "b": 1},
+		deeply.CmpIgnoreArrayOrder(),
+	), "extra keys are not ignored")
+}
+
+func TestCmp_Agreement(t *testing.T) {
+	values := []any{
+		nil,
+		"a",
+		"^a",
+		1,
+		[]any{"a", "b"},
+		[]any{"b", "a"},
+		[]any{"a", "b", "c"},
+		map[string]any{"a": "a"},
+		map[string]any{"a": "^a", "b": []any{1, 2}},
+		map[string]any{"a": "abc", "b": []any{2, 1}},
+		map[string]any{"a": "abc", "b": []any{1, 2}, "c": nil},
+		map[string]any{"a": map[string]any{"$format": "uuid"}},
+		map[string]any{},
+		[]any{map[string]any{"a": "abc", "b": "x"}, "a"},
+		[]any{map[string]any{"a": "^a"}, "a"},
+		map[string]any{"$size": 2.0},
+	}
+
+	modes := []deeply.Mode{
+		deeply.ModeMatches,
+		deeply.ModeMatchesIgnoreArrayOrder,
+		deeply.ModeContains,
+		deeply.ModeContainsIgnoreArrayOrder,
+		deeply.ModeEquals,
+		deeply.ModeEqualsIgnoreArrayOrder,
+	}
+
+	for _, mode := range modes {
+		for _, x := range values {
+			for _, y := range values {
+				require.Equal(t, mode.Match(x, y), cmp.Equal(x, y, deeply.CmpMode(mode)), "%s %v %v", mode, x, y)
+			}
+		}
+	}
+}
+
+func TestCmp_Root(t *testing.T) {
+	expect := map[string]any{
+		"items": map[string]any{"$expr": "size(@) <= $.limit"},
+		"other": map[string]any{"limit": 1.0},
+	}
+
+	actual := map[string]any{
+		"limit": 2.0,
+		"items": []any{1.0, 2.0},
+		"other": map[string]any{"limit": 1.0, "name": "x"},
+	}
+
+	require.True(t, cmp.Equal(expect, actual, deeply.CmpContains()), "$ is the actual document")
+
+	// The sub-values go-cmp descends into are compared within the document too.
+	actual["other"].(map[string]any)["limit"] = 3.0
+
+	diff := cmp.Diff(expect, actual, deeply.CmpContains())
+
+	require.Contains(t, diff, `"limit": float64(3)`)
+	require.NotContains(t, diff, `"items"`)
+
+	actual["limit"] = 1.0
+
+	require.Contains(t, cmp.Diff(expect, actual, deeply.CmpContains()), `"items"`)
+}
+
+func TestCmp_Cost(t *testing.T) {
+	calls := 0
+
+	defer deeply.RegisterTypeComparator(reflect.TypeFor[Status](), deeply.Comparator{
+		Match: func(expect, actual any) bool {
+			calls++
+
+			return expect == actual
+		},
+	})()
+
+	// Each value is compared in the mode once, not once per level above it.
+	var expect, actual any = Status("a"), Status("b")
+	for range 50 {
+		expect = map[string]any{"k": expect, "l": []any{1.0}}
+		actual = map[string]any{"k": actual, "l": []any{1.0}}
+	}
+
+	for _, mode := range []deeply.Mode{deeply.ModeMatches, deeply.ModeContains, deeply.ModeEquals} {
+		calls = 0
+
+		require.False(t, cmp.Equal(expect, actual, deeply.CmpMode(mode)))
+		require.Less(t, calls, 10, "%v", mode)
+	}
+}
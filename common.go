@@ -1,7 +1,9 @@
 package deeply
 
 import (
+	"maps"
 	"reflect"
+	"slices"
 )
 
 // cmp is a function type used to compare two values.
@@ -12,11 +14,11 @@ type cmp func(expect, actual any) bool
 //   - The expected and actual values are both nil.
 //   - The expected and actual values are both slices and have the same length.
 //   - The expected and actual values are deeply equal using the provided compare function.
-func slicesDeepEquals(expect, actual reflect.Value, compare cmp) bool {
+func slicesDeepEquals(expect, actual []any, compare cmp) bool {
 	// Iterate over the values of the expected slice.
-	for i := range expect.Len() {
+	for i := range expect {
 		// Compare the values of the expected and actual slices.
-		if !compare(expect.Index(i).Interface(), actual.Index(i).Interface()) {
+		if !compare(expect[i], actual[i]) {
 			return false
 		}
 	}
@@ -25,29 +27,176 @@ func slicesDeepEquals(expect, actual reflect.Value, compare cmp) bool {
 	return true
 }
 
-// slicesDeepEqualContains checks if the expected slice contains all the values of the actual slice.
-// It returns true if any of the following conditions are met:
-//   - The expected and actual values are both nil.
-//   - The expected and actual values are both slices and have the same length.
-//   - The expected and actual values are deeply equal using the provided compare function.
-func slicesDeepEqualContains(expect, actual reflect.Value, compare cmp) bool {
-	marks := make([]bool, actual.Len()) // Create a map to keep track of the keys that have been marked as matched.
-	res := 0                            // Initialize the total number of matched values.
+// slicesDeepEqualContains checks if every value of the expected slice has a
+// distinct matching value in the actual slice, regardless of their order.
+// The values are paired by a maximum matching, so that an actual value
+// matching several expected values is not claimed by the wrong one.
+func slicesDeepEqualContains(expect, actual []any, compare cmp) bool {
+	// Pair the values of the expected slice with the values of the actual slice.
+	pairs := pairElements(len(expect), len(actual), func(i, j int) bool {
+		return compare(expect[i], actual[j])
+	})
 
-	// Iterate over the values of the expected slice.
-	for i := range expect.Len() {
-		// Iterate over the values of the actual slice.
-		for j := range actual.Len() {
-			// Skip the value if it has already been marked as matched.
-			if !marks[j] && compare(expect.Index(i).Interface(), actual.Index(j).Interface()) {
-				marks[j] = true // Mark the value as matched.
-				res++           // Increment the total number of matched values.
+	// Return true if all values have been paired.
+	return !slices.Contains(pairs, -1)
+}
+
+// pairElements finds a maximum matching between the elements of two slices of
+// the given lengths, where edge tells which pairs of elements match.
+// It returns the index of the right element paired with each left element,
+// or -1 for the left elements left without a pair.
+//
+// The elements are paired greedily first, which pairs all of them in the
+// usual cases at the cost of one edge per left element for equal slices.
+// Augmenting paths then look for a pair for the left elements left over.
+func pairElements(left, right int, edge func(i, j int) bool) []int {
+	pairs := make([]int, left)
+	owners := make([]int, right)
+
+	for j := range owners {
+		owners[j] = -1
+	}
+
+	unpaired := 0
+
+	for i := range pairs {
+		pairs[i] = -1
+
+		for j := range right {
+			if owners[j] < 0 && edge(i, j) {
+				pairs[i], owners[j] = j, i
+
+				break
+			}
+		}
+
+		if pairs[i] < 0 {
+			unpaired++
+		}
+	}
+
+	// The matching is maximum if every element of a slice is paired.
+	if unpaired == 0 || !slices.Contains(owners, -1) {
+		return pairs
+	}
+
+	augmentPairs(pairs, owners, edge)
+
+	return pairs
+}
+
+// augmentPairs extends the pairs of the elements by augmenting paths from
+// the left elements without a pair, owners holding the left element paired
+// with each right element.
+func augmentPairs(pairs, owners []int, edge func(i, j int) bool) {
+	right := len(owners)
+
+	// Cache the results of edge, augmenting paths may ask for a pair several
+	// times. The searches visit few pairs in the usual cases, hence the map.
+	memo := make(map[int]bool)
+	matches := func(i, j int) bool {
+		res, ok := memo[i*right+j]
+		if !ok {
+			res = edge(i, j)
+			memo[i*right+j] = res
+		}
+
+		return res
+	}
+
+	// The right elements visited by the current search are marked with its epoch.
+	seen := make([]int, right)
+	epoch := 0
+
+	var augment func(i int) bool
+
+	augment = func(i int) bool {
+		for j := range right {
+			if seen[j] == epoch || !matches(i, j) {
+				continue
+			}
+
+			seen[j] = epoch
+
+			if owners[j] < 0 || augment(owners[j]) {
+				pairs[i], owners[j] = j, i
+
+				return true
 			}
 		}
+
+		return false
+	}
+
+	for i := range pairs {
+		if pairs[i] < 0 {
+			epoch++
+			augment(i)
+		}
+	}
+}
+
+// matching returns the number of elements paired by pairElements.
+func matching(left, right int, edge func(i, j int) bool) int {
+	return left - countMissing(pairElements(left, right, edge))
+}
+
+// countMissing counts the elements left without a pair.
+func countMissing(pairs []int) int {
+	res := 0
+
+	for _, j := range pairs {
+		if j < 0 {
+			res++
+		}
+	}
+
+	return res
+}
+
+// mapStringDeepEquals is mapDeepEquals for the maps of decoded JSON objects,
+// without reflection.
+func mapStringDeepEquals(expect, actual map[string]any, compare cmp) bool {
+	// Iterate over the keys of the expected map.
+	for k, v := range expect {
+		// Check if the actual value has a corresponding key and if the values are deeply equal.
+		if value, ok := actual[k]; !ok || !compare(v, value) {
+			return false
+		}
 	}
 
-	// Return true if the total number of matched values is equal to the length of the expected slice.
-	return res == expect.Len()
+	// Return true if all values are deeply equal.
+	return true
+}
+
+// deepEqual is reflect.DeepEqual with fast paths for the values of decoded JSON.
+func deepEqual(expect, actual any) bool {
+	switch e := expect.(type) {
+	case nil:
+		return actual == nil
+	case string:
+		a, ok := actual.(string)
+
+		return ok && e == a
+	case float64:
+		a, ok := actual.(float64)
+
+		return ok && e == a
+	case bool:
+		a, ok := actual.(bool)
+
+		return ok && e == a
+	case []any:
+		a, ok := actual.([]any)
+
+		return ok && (e == nil) == (a == nil) && slices.EqualFunc(e, a, deepEqual)
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+
+		return ok && (e == nil) == (a == nil) && maps.EqualFunc(e, a, deepEqual)
+	default:
+		return reflect.DeepEqual(expect, actual)
+	}
 }
 
 // mapDeepEquals checks if the expected and actual values are deeply equal as maps.
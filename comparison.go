@@ -0,0 +1,155 @@
+package deeply
+
+import (
+	"log"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// comparison checks the result of comparing an actual value with an operand,
+// -1, 0 or 1 if the actual value is lower, equal or higher.
+type comparison func(res int) bool
+
+// comparisons holds the comparison operators keyed by their name.
+//
+//nolint:gochecknoglobals
+var comparisons = map[string]comparison{
+	"$gt":  func(res int) bool { return res > 0 },
+	"$gte": func(res int) bool { return res >= 0 },
+	"$lt":  func(res int) bool { return res < 0 },
+	"$lte": func(res int) bool { return res <= 0 },
+}
+
+// comparisonOperator creates an operator comparing the actual value with its operand.
+// Numbers are compared by value and strings lexicographically.
+func comparisonOperator(name string) operator {
+	return operator{
+		match: func(node map[string]any, actual any, _ cmp) bool {
+			res, ok := compareOrdered(actual, node[name])
+
+			return ok && comparisons[name](res)
+		},
+		rank: func(node map[string]any, actual any, _ ranker) float64 {
+			res, ok := compareOrdered(actual, node[name])
+			if !ok {
+				return 0
+			}
+
+			if comparisons[name](res) {
+				return 1
+			}
+
+			// Score the actual value by its closeness to the bound.
+			a, okA := toNumber(actual)
+			b, okB := toNumber(node[name])
+
+			if okA && okB {
+				return numberCloseness(a, b) / 2 //nolint:mnd
+			}
+
+			return 0
+		},
+	}
+}
+
+// matchEq checks if the actual value equals $eq as a JSON value.
+func matchEq(node map[string]any, actual any, _ cmp) bool {
+	return jsonEqual(node["$eq"], actual)
+}
+
+// rankEq scores the actual value by its equality to $eq.
+func rankEq(node map[string]any, actual any, _ ranker) float64 {
+	return matchScore(jsonEqual(node["$eq"], actual))
+}
+
+// matchNe checks if the actual value differs from $ne as a JSON value.
+func matchNe(node map[string]any, actual any, _ cmp) bool {
+	return !jsonEqual(node["$ne"], actual)
+}
+
+// rankNe scores the actual value by its inequality to $ne.
+func rankNe(node map[string]any, actual any, _ ranker) float64 {
+	return matchScore(!jsonEqual(node["$ne"], actual))
+}
+
+// compareOrdered compares two numbers or two strings.
+// It returns false if the values are not comparable.
+func compareOrdered(actual, operand any) (int, bool) {
+	if x, ok := toNumber(operand); ok {
+		y, ok := toNumber(actual)
+		if !ok || math.IsNaN(x) || math.IsNaN(y) {
+			return 0, false
+		}
+
+		switch {
+		case y < x:
+			return -1, true
+		case y > x:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	x, okX := operand.(string)
+	y, okY := actual.(string)
+
+	if !okX || !okY {
+		log.Printf("Error on comparing %v with %v: not comparable\n", actual, operand)
+
+		return 0, false
+	}
+
+	return strings.Compare(y, x), true
+}
+
+// isNumber checks if the value is a number.
+func isNumber(value any) bool {
+	t := jsonType(value)
+
+	return t == "integer" || t == "number"
+}
+
+// toNumber converts a number or a numeric string to a float64. Numeric strings
+// are accepted because protobuf encodes 64-bit integers to JSON as strings.
+func toNumber(value any) (float64, bool) {
+	if s, ok := value.(string); ok {
+		f, err := strconv.ParseFloat(s, 64)
+
+		return f, err == nil
+	}
+
+	if !isNumber(value) {
+		return 0, false
+	}
+
+	return toFloat(value), true
+}
+
+// toFloat converts a value of any numeric kind to a float64.
+func toFloat(value any) float64 {
+	v := reflect.ValueOf(value)
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	default:
+		return 0
+	}
+}
+
+// numberCloseness calculates the match score of two numbers by their relative
+// difference. It returns 1 for equal numbers and 0 for numbers of opposite signs.
+func numberCloseness(a, b float64) float64 {
+	if a == b {
+		return 1
+	}
+
+	return max(0, 1-math.Abs(a-b)/max(math.Abs(a), math.Abs(b)))
+}
@@ -0,0 +1,52 @@
+package deeply_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+func TestComparison_Numbers(t *testing.T) {
+	require.True(t, deeply.Matches(map[string]any{"$gt": 1}, 2))
+	require.False(t, deeply.Matches(map[string]any{"$gt": 1}, 1))
+	require.True(t, deeply.Matches(map[string]any{"$gte": 1}, 1.0))
+	require.True(t, deeply.Matches(map[string]any{"$lt": 1.5}, int64(1)))
+	require.False(t, deeply.Matches(map[string]any{"$lt": 1.5}, uint8(2)))
+	require.True(t, deeply.Matches(map[string]any{"$lte": 2}, float32(2)))
+	require.True(t, deeply.Matches(map[string]any{"$gt": 1, "$lt": 3}, 2))
+	require.False(t, deeply.Matches(map[string]any{"$gt": 1, "$lt": 3}, 3))
+
+	require.True(t, deeply.Matches(map[string]any{"$gt": 9}, "10"), "numeric strings are compared as numbers")
+	require.False(t, deeply.Matches(map[string]any{"$gt": 9}, "ten"))
+	require.False(t, deeply.Matches(map[string]any{"$gt": 9}, true))
+	require.False(t, deeply.Matches(map[string]any{"$gt": 9}, math.NaN()))
+}
+
+func TestComparison_Strings(t *testing.T) {
+	require.True(t, deeply.Matches(map[string]any{"$gt": "b"}, "c"))
+	require.False(t, deeply.Matches(map[string]any{"$gt": "b"}, "a"))
+	require.True(t, deeply.Matches(map[string]any{"$lte": "2024-01-01"}, "2023-12-31"))
+	require.False(t, deeply.Matches(map[string]any{"$lte": "b"}, 1))
+}
+
+func TestComparison_Equality(t *testing.T) {
+	require.True(t, deeply.Matches(map[string]any{"$eq": 1}, 1.0))
+	require.True(t, deeply.Matches(map[string]any{"$eq": "^a$"}, "^a$"), "$eq compares literally")
+	require.False(t, deeply.Matches(map[string]any{"$eq": "^a$"}, "a"))
+
+	require.True(t, deeply.Matches(map[string]any{"$ne": 1}, 2))
+	require.False(t, deeply.Matches(map[string]any{"$ne": 1}, 1.0))
+	require.True(t, deeply.Matches(map[string]any{"status": map[string]any{"$ne": "failed"}}, map[string]any{"status": "ok"}))
+}
+
+func TestComparison_RankMatch(t *testing.T) {
+	gte := map[string]any{"$gte": 100}
+
+	require.InDelta(t, 1., deeply.RankMatch(gte, 100), 1e-9)
+	require.Equal(t, []any{150, 99, 50, 1, "x"}, ranker(gte, []any{1, "x", 50, 150, 99}))
+	require.InDelta(t, 1., deeply.RankMatch(map[string]any{"$eq": 1}, 1.0), 1e-9)
+	require.Zero(t, deeply.RankMatch(map[string]any{"$ne": 1}, 1.0))
+}
@@ -11,8 +11,24 @@ import (
 //     are contained in the actual map.
 //   - The expected and actual values are slices and the expected slice is completely
 //     contained in the actual slice.
+//
+// If the expected value is an operator node, the actual value is checked by the operator instead.
+// Values of a type with a comparator are compared by it, see RegisterTypeComparator.
 func Contains(expect, actual any) bool {
-	return mapDeepContains(expect, actual, Contains) || reflect.DeepEqual(expect, actual)
+	return documents[ModeContains].compare(actual, expect, actual)
+}
+
+// contains is Contains within the document.
+func (d *document) contains(expect, actual any) bool {
+	if node, ok := asOperatorNode(expect); ok {
+		return node.match(actual, d.compare, d.root)
+	}
+
+	if ok, found := matchByType(expect, actual); found {
+		return ok
+	}
+
+	return mapDeepContains(expect, actual, d.compare) || d.equal(expect, actual)
 }
 
 // ContainsIgnoreArrayOrder checks if the expected value is contained in the actual value.
@@ -23,14 +39,34 @@ func Contains(expect, actual any) bool {
 //   - The expected and actual values are slices and the expected slice is partially
 //     contained in the actual slice. The order of elements in the slice is not important.
 func ContainsIgnoreArrayOrder(expect, actual any) bool {
-	return mapDeepContains(expect, actual, ContainsIgnoreArrayOrder) ||
-		slicesDeepContains(expect, actual, ContainsIgnoreArrayOrder) ||
-		reflect.DeepEqual(expect, actual)
+	return documents[ModeContainsIgnoreArrayOrder].compare(actual, expect, actual)
+}
+
+// containsIgnoreArrayOrder is ContainsIgnoreArrayOrder within the document.
+func (d *document) containsIgnoreArrayOrder(expect, actual any) bool {
+	if node, ok := asOperatorNode(expect); ok {
+		return node.match(actual, d.compare, d.root)
+	}
+
+	if ok, found := matchByType(expect, actual); found {
+		return ok
+	}
+
+	return mapDeepContains(expect, actual, d.compare) ||
+		slicesDeepContains(expect, actual, d.compare) ||
+		d.equal(expect, actual)
 }
 
 // mapDeepContains checks if the expected map is contained in the actual map.
 // It returns true if all keys and values in the expected map are contained in the actual map.
 func mapDeepContains(expect, actual any, compare cmp) bool {
+	// Fast path for decoded JSON objects.
+	if left, ok := expect.(map[string]any); ok {
+		if right, ok := actual.(map[string]any); ok {
+			return len(left) <= len(right) && mapStringDeepEquals(left, right, compare)
+		}
+	}
+
 	// Check if the types are the same.
 	if reflect.TypeOf(expect) != reflect.TypeOf(actual) {
 		return false
@@ -60,6 +96,13 @@ func mapDeepContains(expect, actual any, compare cmp) bool {
 // slicesDeepContains checks if the expected slice is contained in the actual slice.
 // It returns true if the expected slice is completely contained in the actual slice.
 func slicesDeepContains(expect, actual any, compare cmp) bool {
+	// Fast path for decoded JSON arrays.
+	if a, ok := expect.([]any); ok {
+		if b, ok := actual.([]any); ok {
+			return len(a) <= len(b) && slicesDeepEqualContains(a, b, compare)
+		}
+	}
+
 	// Check if the types are the same.
 	if reflect.TypeOf(expect) != reflect.TypeOf(actual) {
 		return false
@@ -75,11 +118,11 @@ func slicesDeepContains(expect, actual any, compare cmp) bool {
 		return false
 	}
 
-	a := reflect.ValueOf(expect)
-	b := reflect.ValueOf(actual)
+	a, _ := elements(expect)
+	b, _ := elements(actual)
 
 	// Check if the length of the expected slice is less than or equal to the length of the actual slice.
-	if a.Len() > b.Len() {
+	if len(a) > len(b) {
 		return false
 	}
 
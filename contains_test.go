@@ -165,6 +165,9 @@ func TestContains_Slices_OrderIgnore(t *testing.T) {
 
 	require.False(t, deeply.ContainsIgnoreArrayOrder([]int{1, 2, 3}, []int{1, 2}))
 	require.False(t, deeply.ContainsIgnoreArrayOrder([]any{1, 2, 3}, []any{1, 2}))
+
+	// Every actual element matches one expected element only.
+	require.False(t, deeply.ContainsIgnoreArrayOrder([]string{"a", "b"}, []string{"a", "a"}))
 }
 
 func TestContains_Boundary(t *testing.T) {
@@ -0,0 +1,226 @@
+package deeply
+
+import (
+	"maps"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Counterexample is a value which almost matches an expectation, found by Counterexamples.
+type Counterexample struct {
+	// Path is the location of the violated constraint, e.g. $.items[0].name.
+	Path string
+	// Value is the whole value, which matches everywhere but at the path.
+	Value any
+	// Reason describes the violation, e.g. "missing key" or "reordered".
+	Reason string
+}
+
+// Reasons of the counterexamples which are not mismatches of Explain.
+const (
+	reasonReordered      = "reordered"
+	reasonMissingElement = "missing element"
+)
+
+// Counterexamples returns values which almost match the expectation in the
+// mode, e.g. for negative tests. It generates a matching value with Generate
+// and breaks one constraint of it at a time:
+//   - every expected key is dropped, and a key is added to the maps of Equals;
+//   - every leaf and operator node gets a close value which doesn't satisfy it,
+//     such as a string changed so that it doesn't match the regular expression;
+//   - slices compared by index are reordered and elements are dropped from the others.
+//
+// A counterexample is kept only if Explain reports mismatches at or below
+// its path and nowhere else, so every counterexample breaks exactly one constraint.
+func Counterexamples(expect any, mode Mode, opts ...GenerateOption) ([]Counterexample, error) {
+	g := &generator{rng: rand.New(rand.NewPCG(0, 0)), mode: mode} //nolint:gosec
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	base, err := g.generate(expect)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &counterexamples{generator: g, root: expect}
+	c.walk("$", expect, base, func(value any) any { return value })
+
+	return c.res, nil
+}
+
+// counterexamples collects the counterexamples of an expectation.
+type counterexamples struct {
+	*generator
+
+	root any
+	res  []Counterexample
+}
+
+// add adds the value to the counterexamples if it breaks the expectation only
+// at or below the path. The reason defaults to the reason of the mismatch.
+func (c *counterexamples) add(path string, value any, reason string) bool {
+	mismatches := Explain(c.root, value, c.mode)
+	if len(mismatches) == 0 {
+		return false
+	}
+
+	for _, m := range mismatches {
+		if m.Path != path && !strings.HasPrefix(m.Path, path+".") && !strings.HasPrefix(m.Path, path+"[") {
+			return false
+		}
+	}
+
+	if reason == "" {
+		reason = mismatches[0].Reason
+	}
+
+	c.res = append(c.res, Counterexample{Path: path, Value: value, Reason: reason})
+
+	return true
+}
+
+// walk adds the counterexamples of the expectation at the path, where actual
+// is the matching value and rebuild returns the whole value with the value at
+// the path replaced.
+func (c *counterexamples) walk(path string, expect, actual any, rebuild func(any) any) {
+	switch e := expect.(type) {
+	case map[string]any:
+		if isOperatorNode(e) {
+			break
+		}
+
+		a, ok := actual.(map[string]any)
+		if !ok {
+			break
+		}
+
+		c.walkMap(path, e, a, rebuild)
+
+		return
+	case []any:
+		a, ok := actual.([]any)
+		if !ok || !c.mode.orderedSlices() && !c.mode.ignoreArrayOrder() || len(a) != len(e) {
+			break
+		}
+
+		c.walkSlice(path, e, a, rebuild)
+
+		return
+	}
+
+	for _, candidate := range c.perturb(expect, actual) {
+		if !c.mode.Match(expect, candidate) && c.add(path, rebuild(candidate), "") {
+			return
+		}
+	}
+}
+
+// walkMap drops every expected key, adds a key if the mode compares whole
+// maps and walks the values.
+func (c *counterexamples) walkMap(path string, expect, actual map[string]any, rebuild func(any) any) {
+	keys := slices.Sorted(maps.Keys(expect))
+
+	for _, key := range keys {
+		m := maps.Clone(actual)
+		delete(m, key)
+		c.add(childPath(path, key), rebuild(m), reasonMissingKey)
+	}
+
+	if !c.mode.partialMaps() {
+		m := maps.Clone(actual)
+		m[c.word()] = c.word()
+		c.add(path, rebuild(m), reasonUnexpectedKey)
+	}
+
+	for _, key := range keys {
+		c.walk(childPath(path, key), expect[key], actual[key], func(value any) any {
+			m := maps.Clone(actual)
+			m[key] = value
+
+			return rebuild(m)
+		})
+	}
+}
+
+// walkSlice reorders a slice compared by index or drops an element from a
+// slice compared regardless of the order, and walks the elements.
+func (c *counterexamples) walkSlice(path string, expect, actual []any, rebuild func(any) any) {
+	if c.mode.orderedSlices() {
+		for i := 0; i+1 < len(actual); i++ {
+			s := slices.Clone(actual)
+			s[i], s[i+1] = s[i+1], s[i]
+
+			if c.add(path, rebuild(s), reasonReordered) {
+				break
+			}
+		}
+	} else if len(actual) > 0 {
+		c.add(path, rebuild(actual[:len(actual)-1:len(actual)-1]), reasonMissingElement)
+	}
+
+	for i := range expect {
+		c.walk(childPath(path, i), expect[i], actual[i], func(value any) any {
+			s := slices.Clone(actual)
+			s[i] = value
+
+			return rebuild(s)
+		})
+	}
+}
+
+// perturb returns values close to the matching value, closest first: the
+// operands of an operator node and their neighbors, small changes keeping
+// the type of the value, then values of other types.
+func (c *counterexamples) perturb(expect, actual any) []any {
+	var res []any
+
+	if node, ok := asOperatorNode(expect); ok {
+		for _, name := range node.names {
+			operand := node.args[name]
+			res = append(res, operand)
+
+			if n, ok := toNumber(operand); ok {
+				res = append(res, n-1, n+1)
+			}
+		}
+	}
+
+	switch v := actual.(type) {
+	case string:
+		res = append(res, v+"!", "!"+v, strings.ToUpper(v), strings.ToLower(v))
+		if v != "" {
+			res = append(res, v[1:], v[:len(v)-1])
+		}
+
+		res = append(res, "", c.word())
+	case float64:
+		res = append(res, v+1, v-1, -v, v*10, 0.0) //nolint:mnd
+	case bool:
+		res = append(res, !v)
+	case []any:
+		s := slices.Clone(v)
+		slices.Reverse(s)
+		res = append(res, s)
+
+		if len(v) > 0 {
+			res = append(res, v[:len(v)-1:len(v)-1], append(slices.Clone(v), v[0]))
+		}
+
+		res = append(res, []any{})
+	case map[string]any:
+		res = append(res, map[string]any{})
+	}
+
+	// Values of other types, unless the value is already one of them.
+	for _, other := range []any{nil, "", 0.0, false, []any{}, map[string]any{}} {
+		if reflect.TypeOf(other) != reflect.TypeOf(actual) {
+			res = append(res, other)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,100 @@
+package deeply_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+func TestCounterexamples(t *testing.T) {
+	expect := map[string]any{
+		"name": "^al",
+		"age":  map[string]any{"$gte": 18.0},
+		"tags": []any{"^a", "^b"},
+	}
+
+	res, err := deeply.Counterexamples(expect, deeply.ModeMatches)
+	require.NoError(t, err)
+
+	reasons := make(map[string]string, len(res))
+	for _, c := range res {
+		reasons[c.Path] += c.Reason + ";"
+
+		require.False(t, deeply.Matches(expect, c.Value), c.Path)
+
+		for _, m := range deeply.Explain(expect, c.Value, deeply.ModeMatches) {
+			require.Contains(t, m.Path, c.Path)
+		}
+	}
+
+	require.Equal(t, map[string]string{
+		"$.name":    "missing key;does not match the pattern;",
+		"$.age":     "missing key;does not satisfy $gte;",
+		"$.tags":    "missing key;reordered;",
+		"$.tags[0]": "does not match the pattern;",
+		"$.tags[1]": "does not match the pattern;",
+	}, reasons)
+
+	for _, c := range res {
+		if c.Path == "$.age" && c.Reason != "missing key" {
+			require.InDelta(t, 17.0, c.Value.(map[string]any)["age"], 0)
+		}
+	}
+}
+
+func TestCounterexamples_Modes(t *testing.T) {
+	expect := map[string]any{"id": 1.0, "tags": []any{"a", "b"}}
+
+	res, err := deeply.Counterexamples(expect, deeply.ModeEquals)
+	require.NoError(t, err)
+
+	var reasons []string
+	for _, c := range res {
+		reasons = append(reasons, c.Path+": "+c.Reason)
+	}
+
+	require.Equal(t, []string{
+		"$.id: missing key",
+		"$.tags: missing key",
+		"$: unexpected key",
+		"$.id: not equal",
+		"$.tags: reordered",
+		"$.tags[0]: not equal",
+		"$.tags[1]: not equal",
+	}, reasons)
+
+	res, err = deeply.Counterexamples(expect, deeply.ModeContainsIgnoreArrayOrder)
+	require.NoError(t, err)
+
+	reasons = reasons[:0]
+	for _, c := range res {
+		reasons = append(reasons, c.Path+": "+c.Reason)
+	}
+
+	require.Equal(t, []string{
+		"$.id: missing key",
+		"$.tags: missing key",
+		"$.id: not equal",
+		"$.tags: missing element",
+		"$.tags[0]: no matching element",
+		"$.tags[1]: no matching element",
+	}, reasons)
+}
+
+func TestCounterexamples_Property(t *testing.T) {
+	for _, expect := range generateExpectations {
+		for _, mode := range generateModes {
+			res, err := deeply.Counterexamples(expect, mode, deeply.WithSeed(7))
+			require.NoError(t, err, "%v %v", expect, mode)
+
+			for _, c := range res {
+				require.False(t, mode.Match(expect, c.Value), "%v %v %s: %#v", expect, mode, c.Path, c.Value)
+			}
+		}
+	}
+
+	_, err := deeply.Counterexamples(map[string]any{"$gt": 1.0, "$lt": 1.0}, deeply.ModeMatches)
+	require.ErrorIs(t, err, deeply.ErrGenerate)
+}
@@ -0,0 +1,182 @@
+package deeply
+
+import (
+	"log"
+	"maps"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Comparator compares the values of a domain type, such as amounts of money
+// in minor units, phone numbers in E.164 or case-insensitive enums, for which
+// the deep comparison is too strict.
+//
+// Match reports whether the actual value equals the expected one and Rank
+// scores how close it is, between 0 and 1. Either may be nil: a comparator
+// without Rank scores 1 if the values match and 0 otherwise, a comparator
+// without Match matches the values scoring 1.
+type Comparator struct {
+	Match func(expect, actual any) bool
+	Rank  func(expect, actual any) float64
+}
+
+// match checks if the actual value matches the expected value.
+func (c Comparator) match(expect, actual any) bool {
+	switch {
+	case c.Match != nil:
+		return c.Match(expect, actual)
+	case c.Rank != nil:
+		return c.Rank(expect, actual) == 1
+	default:
+		return deepEqual(expect, actual)
+	}
+}
+
+// rank scores the actual value against the expected value.
+func (c Comparator) rank(expect, actual any) float64 {
+	if c.Rank != nil {
+		return clampScore(c.Rank(expect, actual))
+	}
+
+	return matchScore(c.match(expect, actual))
+}
+
+// The comparators are read on every comparison and rarely registered, so
+// the registries are replaced as a whole under the lock and read without it.
+//
+//nolint:gochecknoglobals
+var (
+	comparatorsMu    sync.Mutex
+	namedComparators atomic.Pointer[map[string]Comparator]
+	typeComparators  atomic.Pointer[map[reflect.Type]Comparator]
+)
+
+// RegisterComparator registers the comparator under the name used by the
+// "$custom" modifier, e.g. {"$custom": "phone", "$value": "+1 (555) 010-0000"},
+// and returns a function restoring the previous comparator of the name.
+func RegisterComparator(name string, c Comparator) (restore func()) {
+	return register(&namedComparators, name, c)
+}
+
+// RegisterTypeComparator registers the comparator of the values of the type
+// and returns a function restoring the previous comparator of the type.
+//
+// Matches, Contains, Equals, their variants and RankMatch compare the values
+// with the comparator of the type of the expected value, or else of the
+// actual value, instead of comparing them deeply.
+func RegisterTypeComparator(t reflect.Type, c Comparator) (restore func()) {
+	return register(&typeComparators, t, c)
+}
+
+// register sets the comparator of the key in the registry and returns a
+// function restoring the previous one.
+func register[K comparable](registry *atomic.Pointer[map[K]Comparator], key K, c Comparator) func() {
+	prev, ok := update(registry, key, &c)
+
+	return func() {
+		if ok {
+			update(registry, key, &prev)
+		} else {
+			update(registry, key, nil)
+		}
+	}
+}
+
+// update replaces the registry by a copy with the comparator of the key set,
+// or deleted if it is nil. It returns the previous comparator of the key.
+func update[K comparable](registry *atomic.Pointer[map[K]Comparator], key K, c *Comparator) (Comparator, bool) {
+	comparatorsMu.Lock()
+	defer comparatorsMu.Unlock()
+
+	res := make(map[K]Comparator)
+	if current := registry.Load(); current != nil {
+		maps.Copy(res, *current)
+	}
+
+	prev, ok := res[key]
+
+	if c != nil {
+		res[key] = *c
+	} else {
+		delete(res, key)
+	}
+
+	registry.Store(&res)
+
+	return prev, ok
+}
+
+// typeComparator returns the comparator registered for the type of the
+// expected value, or else of the actual value.
+func typeComparator(expect, actual any) (Comparator, bool) {
+	registry := typeComparators.Load()
+	if registry == nil || len(*registry) == 0 {
+		return Comparator{}, false
+	}
+
+	if c, ok := (*registry)[reflect.TypeOf(expect)]; ok {
+		return c, true
+	}
+
+	c, ok := (*registry)[reflect.TypeOf(actual)]
+
+	return c, ok
+}
+
+// matchByType checks if the actual value matches the expected value with the
+// comparator of their type. It returns false as second value if there is none.
+func matchByType(expect, actual any) (bool, bool) {
+	c, ok := typeComparator(expect, actual)
+	if !ok {
+		return false, false
+	}
+
+	return c.match(expect, actual), true
+}
+
+// rankByType scores the actual value against the expected value with the
+// comparator of their type. It returns false as second value if there is none.
+func rankByType(expect, actual any) (float64, bool) {
+	c, ok := typeComparator(expect, actual)
+	if !ok {
+		return 0, false
+	}
+
+	return c.rank(expect, actual), true
+}
+
+// customComparator returns the comparator named by the "$custom" modifier of
+// the node. It returns false if the node has no such modifier.
+// An unknown name is logged and compares nothing as equal.
+func customComparator(node map[string]any) (Comparator, bool) {
+	value, ok := node["$custom"]
+	if !ok {
+		return Comparator{}, false
+	}
+
+	name, ok := value.(string)
+	if !ok {
+		log.Printf("Error on parsing $custom operand %v: not a string\n", value)
+
+		return Comparator{Match: func(_, _ any) bool { return false }}, true
+	}
+
+	if registry := namedComparators.Load(); registry != nil {
+		if c, ok := (*registry)[name]; ok {
+			return c, true
+		}
+	}
+
+	log.Printf("Error on parsing $custom operand %s: unknown comparator\n", name)
+
+	return Comparator{Match: func(_, _ any) bool { return false }}, true
+}
+
+// hasTypeComparator checks if a comparator is registered for the type of the
+// expected value or of the actual value.
+func hasTypeComparator(expect, actual any) bool {
+	_, ok := typeComparator(expect, actual)
+
+	return ok
+}
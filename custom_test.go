@@ -0,0 +1,163 @@
+package deeply_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+// Money is an amount in minor units.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// Status is a case-insensitive enum.
+type Status string
+
+// digits returns the digits of a phone number.
+func digits(value any) string {
+	s, _ := value.(string)
+
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+
+		return -1
+	}, s)
+}
+
+func TestComparator_Named(t *testing.T) {
+	defer deeply.RegisterComparator("phone", deeply.Comparator{
+		Match: func(expect, actual any) bool { return digits(expect) == digits(actual) },
+	})()
+
+	expect := map[string]any{"phone": map[string]any{"$custom": "phone", "$value": "+1 (555) 010-0000"}}
+
+	for _, match := range []func(expect, actual any) bool{
+		deeply.Matches, deeply.MatchesIgnoreArrayOrder,
+		deeply.Contains, deeply.ContainsIgnoreArrayOrder,
+		deeply.Equals, deeply.EqualsIgnoreArrayOrder,
+	} {
+		require.True(t, match(expect, map[string]any{"phone": "+15550100000"}))
+		require.False(t, match(expect, map[string]any{"phone": "+15550100001"}))
+	}
+
+	// Comparators without Rank score 1 or 0.
+	require.InDelta(t, 1., deeply.RankMatch(expect["phone"], "+15550100000"), 1e-9)
+	require.Zero(t, deeply.RankMatch(expect["phone"], "+15550100001"))
+
+	// The modifiers of $value still apply.
+	r := deeply.NewRanker()
+	weighted := map[string]any{"phone": map[string]any{"$custom": "phone", "$value": "555-0100000", "$weight": 0}, "a": 1.0}
+	require.InDelta(t,
+		r.RankMatch(weighted, map[string]any{"phone": "5550100000", "a": 1.0}),
+		r.RankMatch(weighted, map[string]any{"phone": "nope", "a": 1.0}), 1e-9)
+}
+
+func TestComparator_UnknownName(t *testing.T) {
+	expect := map[string]any{"$custom": "unknown", "$value": "a"}
+
+	require.False(t, deeply.Matches(expect, "a"))
+	require.Zero(t, deeply.RankMatch(expect, "a"))
+	require.False(t, deeply.Matches(map[string]any{"$custom": 1, "$value": "a"}, "a"))
+}
+
+func TestComparator_Type(t *testing.T) {
+	defer deeply.RegisterTypeComparator(reflect.TypeFor[Status](), deeply.Comparator{
+		Match: func(expect, actual any) bool {
+			e, _ := expect.(Status)
+			a, _ := actual.(Status)
+
+			return strings.EqualFold(string(e), string(a))
+		},
+	})()
+
+	require.True(t, deeply.Equals(Status("ACTIVE"), Status("active")))
+	require.True(t, deeply.Matches([]any{Status("ACTIVE")}, []any{Status("Active")}))
+	require.True(t, deeply.Contains(map[string]any{"s": Status("ACTIVE")}, map[string]any{"s": Status("active"), "b": 1}))
+	require.False(t, deeply.Equals(Status("ACTIVE"), Status("inactive")))
+	require.Empty(t, deeply.Explain(map[string]any{"s": Status("ACTIVE")}, map[string]any{"s": Status("active")}, deeply.ModeEquals))
+	require.Len(t, deeply.Explain(map[string]any{"s": Status("ACTIVE")}, map[string]any{"s": Status("gone")}, deeply.ModeEquals), 1)
+}
+
+func TestComparator_TypeRank(t *testing.T) {
+	defer deeply.RegisterTypeComparator(reflect.TypeFor[Money](), deeply.Comparator{
+		Rank: func(expect, actual any) float64 {
+			e, _ := expect.(Money)
+			a, _ := actual.(Money)
+
+			if e.Currency != a.Currency {
+				return 0
+			}
+
+			return 1 / (1 + float64(max(e.Amount-a.Amount, a.Amount-e.Amount)))
+		},
+	})()
+
+	price := Money{Amount: 1999, Currency: "USD"}
+
+	// Comparators without Match match the values scoring 1.
+	require.True(t, deeply.Equals(price, Money{Amount: 1999, Currency: "USD"}))
+	require.False(t, deeply.Equals(price, Money{Amount: 1998, Currency: "USD"}))
+
+	require.InDelta(t, .5, deeply.RankMatch(price, Money{Amount: 1998, Currency: "USD"}), 1e-9)
+	require.Zero(t, deeply.RankMatch(price, Money{Amount: 1999, Currency: "EUR"}))
+
+	r := deeply.NewRanker()
+	best, _ := r.BestMatch(Money{Amount: 1000, Currency: "USD"}, []any{price, Money{Amount: 1001, Currency: "USD"}, "1000"})
+	require.Equal(t, 1, best)
+}
+
+func TestComparator_Restore(t *testing.T) {
+	restore := deeply.RegisterTypeComparator(reflect.TypeFor[Status](), deeply.Comparator{
+		Match: func(_, _ any) bool { return true },
+	})
+
+	restoreInner := deeply.RegisterTypeComparator(reflect.TypeFor[Status](), deeply.Comparator{
+		Match: func(_, _ any) bool { return false },
+	})
+
+	require.False(t, deeply.Equals(Status("a"), Status("a")))
+
+	restoreInner()
+	require.True(t, deeply.Equals(Status("a"), Status("b")))
+
+	restore()
+	require.False(t, deeply.Equals(Status("a"), Status("b")))
+	require.True(t, deeply.Equals(Status("a"), Status("a")))
+}
+
+func TestComparator_Arrays(t *testing.T) {
+	defer deeply.RegisterComparator("ci", deeply.Comparator{
+		Match: func(expect, actual any) bool {
+			e, _ := expect.(string)
+			a, _ := actual.(string)
+
+			return strings.EqualFold(e, a)
+		},
+	})()
+
+	defer deeply.RegisterTypeComparator(reflect.TypeFor[Status](), deeply.Comparator{
+		Match: func(expect, actual any) bool {
+			e, _ := expect.(Status)
+			a, _ := actual.(Status)
+
+			return strings.EqualFold(string(e), string(a))
+		},
+	})()
+
+	for _, equals := range []func(expect, actual any) bool{deeply.Equals, deeply.EqualsIgnoreArrayOrder} {
+		require.True(t, equals([]any{Status("A")}, []any{Status("a")}))
+		require.True(t, equals(map[string]any{"x": []any{Status("A")}}, map[string]any{"x": []any{Status("a")}}))
+		require.True(t, equals([]any{map[string]any{"$custom": "ci", "$value": "A"}}, []any{"a"}))
+		require.False(t, equals([]any{map[string]any{"$custom": "ci", "$value": "A"}}, []any{"b"}))
+		require.False(t, equals([]any{Status("A")}, []any{Status("a"), Status("a")}))
+	}
+}
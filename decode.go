@@ -0,0 +1,395 @@
+package deeply
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// errJSONTrailingData is returned by DecodeJSON for data after the document.
+var errJSONTrailingData = errors.New("invalid character after top-level value")
+
+// DecodeJSON decodes a JSON document and normalizes it, see Normalize.
+// Integers too large for a float64 are kept exact.
+func DecodeJSON(data []byte) (any, error) {
+	var value any
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errJSONTrailingData
+	}
+
+	return Normalize(value), nil
+}
+
+// yamlMaxValues is the number of values a YAML document may expand to, its
+// aliases included, so that nested aliases can't exhaust the memory.
+const yamlMaxValues = 1 << 20
+
+// Errors of the expansion of YAML aliases.
+var (
+	errYAMLAliasCycle = errors.New("alias refers to itself")
+	errYAMLNullKey    = errors.New("null key")
+	errYAMLTooLarge   = fmt.Errorf("document expands to more than %d values", yamlMaxValues)
+)
+
+// DecodeYAML decodes a YAML document and normalizes it, see Normalize.
+// Timestamps are kept as written, like quoted strings in JSON. Aliases
+// referring to themselves, documents expanding to more than a million
+// values and null keys, which JSON objects can't have, are rejected.
+func DecodeYAML(data []byte) (any, error) {
+	var node yaml.Node
+
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+
+	d := yamlDecoder{expanding: make(map[*yaml.Node]bool)}
+
+	value, err := d.value(&node)
+	if err != nil {
+		return nil, err
+	}
+
+	return Normalize(value), nil
+}
+
+// yamlDecoder converts YAML nodes to values, expanding the aliases.
+type yamlDecoder struct {
+	expanding map[*yaml.Node]bool // The anchored nodes being expanded.
+	count     int                 // The number of values converted.
+}
+
+// value converts a YAML node to a value. Unlike yaml.v3, it doesn't turn
+// timestamps into time.Time, so that they compare like the strings in JSON.
+func (d *yamlDecoder) value(node *yaml.Node) (any, error) {
+	if d.count++; d.count > yamlMaxValues {
+		return nil, errYAMLTooLarge
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil //nolint:nilnil
+		}
+
+		return d.value(node.Content[0])
+	case yaml.AliasNode:
+		var res any
+
+		err := d.expand(node, func(target *yaml.Node) error {
+			var err error
+
+			res, err = d.value(target)
+
+			return err
+		})
+
+		return res, err
+	case yaml.SequenceNode:
+		res := make([]any, 0, len(node.Content))
+
+		for _, item := range node.Content {
+			value, err := d.value(item)
+			if err != nil {
+				return nil, err
+			}
+
+			res = append(res, value)
+		}
+
+		return res, nil
+	case yaml.MappingNode:
+		return d.mapping(node)
+	default:
+		if node.ShortTag() == "!!timestamp" {
+			return node.Value, nil
+		}
+
+		var value any
+
+		err := node.Decode(&value)
+
+		return value, err
+	}
+}
+
+// expand calls fn with the node the alias refers to. It fails if the alias
+// is met again while the node is being expanded.
+func (d *yamlDecoder) expand(alias *yaml.Node, fn func(target *yaml.Node) error) error {
+	target := alias.Alias
+	if d.expanding[target] {
+		return fmt.Errorf("line %d: %w", alias.Line, errYAMLAliasCycle)
+	}
+
+	d.expanding[target] = true
+	defer delete(d.expanding, target)
+
+	return fn(target)
+}
+
+// mapping converts a YAML mapping node to a map, resolving merge keys.
+// The keys of the mapping override the merged ones.
+func (d *yamlDecoder) mapping(node *yaml.Node) (map[string]any, error) {
+	res := make(map[string]any, len(node.Content)/2) //nolint:mnd
+	merged := make(map[string]any)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		if key.ShortTag() == "!!merge" {
+			if err := d.merge(value, merged, true); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		k, err := d.value(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if k == nil {
+			return nil, fmt.Errorf("line %d: %w", key.Line, errYAMLNullKey)
+		}
+
+		v, err := d.value(value)
+		if err != nil {
+			return nil, err
+		}
+
+		res[fmt.Sprint(k)] = v
+	}
+
+	for k, v := range merged {
+		if _, ok := res[k]; !ok {
+			res[k] = v
+		}
+	}
+
+	return res, nil
+}
+
+// merge adds the keys of a merged mapping, or of a sequence of mappings if
+// sequences are allowed, to the map. The first mapping of a sequence takes
+// precedence.
+func (d *yamlDecoder) merge(node *yaml.Node, res map[string]any, sequences bool) error {
+	switch {
+	case node.Kind == yaml.AliasNode:
+		return d.expand(node, func(target *yaml.Node) error {
+			return d.merge(target, res, sequences)
+		})
+	case node.Kind == yaml.SequenceNode && sequences:
+		for _, n := range node.Content {
+			if err := d.merge(n, res, false); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case node.Kind != yaml.MappingNode:
+		return fmt.Errorf("line %d: merged value is not a mapping", node.Line) //nolint:err113
+	}
+
+	m, err := d.mapping(node)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range m {
+		if _, ok := res[k]; !ok {
+			res[k] = v
+		}
+	}
+
+	return nil
+}
+
+// Normalize converts a decoded value to the types encoding/json decodes to,
+// so that values behave identically no matter which decoder produced them:
+//   - numbers, including json.Number, become float64, except the integers
+//     beyond 2^53 which become int64, or uint64 above math.MaxInt64, to stay exact;
+//   - maps become map[string]any, their keys formatted with fmt.Sprint;
+//   - slices and arrays, except []byte, become []any;
+//   - times become RFC 3339 strings;
+//   - pointers are dereferenced and nil pointers become nil.
+//
+// Strings and booleans of named types become string and bool, other
+// values are returned as is.
+func Normalize(value any) any {
+	switch v := value.(type) {
+	case nil, string, bool, float64, []byte:
+		return v
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return normalizeInt(n)
+		}
+
+		if n, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return normalizeUint(n)
+		}
+
+		f, err := v.Float64()
+		if err != nil {
+			log.Printf("Error on normalizing number %s: %v\n", v, err)
+
+			return v.String()
+		}
+
+		return f
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case map[string]any:
+		res := make(map[string]any, len(v))
+		for k, item := range v {
+			res[k] = Normalize(item)
+		}
+
+		return res
+	case []any:
+		res := make([]any, len(v))
+		for i, item := range v {
+			res[i] = Normalize(item)
+		}
+
+		return res
+	}
+
+	return normalizeValue(reflect.ValueOf(value))
+}
+
+// normalizeValue normalizes the values of the kinds Normalize doesn't switch on.
+func normalizeValue(v reflect.Value) any {
+	switch v.Kind() { //nolint:exhaustive
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return normalizeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return normalizeUint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+
+		return Normalize(v.Elem().Interface())
+	case reflect.Map:
+		res := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			res[fmt.Sprint(iter.Key().Interface())] = Normalize(iter.Value().Interface())
+		}
+
+		return res
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+
+		res := make([]any, v.Len())
+		for i := range v.Len() {
+			res[i] = Normalize(v.Index(i).Interface())
+		}
+
+		return res
+	default:
+		return v.Interface()
+	}
+}
+
+// matchDecoded decodes the expected and actual documents and compares them.
+// It returns false if either of the documents can't be decoded.
+func matchDecoded(decode func([]byte) (any, error), expect, actual []byte, compare cmp) bool {
+	left, err := decode(expect)
+	if err != nil {
+		log.Printf("Error on decoding expected value: %v\n", err)
+
+		return false
+	}
+
+	right, err := decode(actual)
+	if err != nil {
+		log.Printf("Error on decoding actual value: %v\n", err)
+
+		return false
+	}
+
+	return compare(left, right)
+}
+
+// MatchesJSON checks if the actual JSON document matches the expected one, see Matches.
+// Both documents are decoded by DecodeJSON; invalid documents don't match.
+func MatchesJSON(expect, actual []byte) bool {
+	return matchDecoded(DecodeJSON, expect, actual, Matches)
+}
+
+// ContainsJSON checks if the actual JSON document contains the expected one, see Contains.
+func ContainsJSON(expect, actual []byte) bool {
+	return matchDecoded(DecodeJSON, expect, actual, Contains)
+}
+
+// EqualsJSON checks if the JSON documents are equal, see Equals.
+func EqualsJSON(expect, actual []byte) bool {
+	return matchDecoded(DecodeJSON, expect, actual, Equals)
+}
+
+// MatchesYAML checks if the actual YAML document matches the expected one, see Matches.
+// Both documents are decoded by DecodeYAML; invalid documents don't match.
+func MatchesYAML(expect, actual []byte) bool {
+	return matchDecoded(DecodeYAML, expect, actual, Matches)
+}
+
+// ContainsYAML checks if the actual YAML document contains the expected one, see Contains.
+func ContainsYAML(expect, actual []byte) bool {
+	return matchDecoded(DecodeYAML, expect, actual, Contains)
+}
+
+// EqualsYAML checks if the YAML documents are equal, see Equals.
+func EqualsYAML(expect, actual []byte) bool {
+	return matchDecoded(DecodeYAML, expect, actual, Equals)
+}
+
+// maxExactInt is the largest integer such that float64 holds it and all the
+// integers below it exactly.
+const maxExactInt = 1 << 53
+
+// normalizeInt converts an integer to a float64 if it holds it exactly.
+func normalizeInt(n int64) any {
+	if n >= -maxExactInt && n <= maxExactInt {
+		return float64(n)
+	}
+
+	return n
+}
+
+// normalizeUint converts an unsigned integer to a float64 if it holds it
+// exactly, or else to an int64 if it fits.
+func normalizeUint(n uint64) any {
+	switch {
+	case n <= maxExactInt:
+		return float64(n)
+	case n <= math.MaxInt64:
+		return int64(n)
+	default:
+		return n
+	}
+}
@@ -0,0 +1,178 @@
+package deeply_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+func TestNormalize(t *testing.T) {
+	type name string
+
+	ptr := 5
+
+	require.Equal(t, map[string]any{
+		"int":     float64(1),
+		"uint":    float64(2),
+		"float32": float64(1.5),
+		"number":  float64(3),
+		"named":   "bob",
+		"nil":     nil,
+		"ptr":     float64(5),
+		"nilPtr":  nil,
+		"time":    "2024-01-02T10:00:00Z",
+		"keys":    map[string]any{"1": "a", "true": "b"},
+		"strings": []any{"a", "b"},
+		"array":   []any{float64(1), float64(2)},
+		"nested":  []any{map[string]any{"a": float64(1)}},
+	}, deeply.Normalize(map[string]any{
+		"int":     1,
+		"uint":    uint8(2),
+		"float32": float32(1.5),
+		"number":  json.Number("3"),
+		"named":   name("bob"),
+		"nil":     nil,
+		"ptr":     &ptr,
+		"nilPtr":  (*int)(nil),
+		"time":    time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+		"keys":    map[any]any{1: "a", true: "b"},
+		"strings": []string{"a", "b"},
+		"array":   [2]int64{1, 2},
+		"nested":  []map[string]int{{"a": 1}},
+	}))
+
+	require.Nil(t, deeply.Normalize([]string(nil)))
+}
+
+func TestNormalize_LargeIntegers(t *testing.T) {
+	// The integers a float64 can't hold exactly keep their value.
+	require.Equal(t, float64(1<<53), deeply.Normalize(int64(1<<53)))
+	require.Equal(t, int64(1<<53+1), deeply.Normalize(int64(1<<53+1)))
+	require.Equal(t, int64(-1<<53-1), deeply.Normalize(int64(-1<<53-1)))
+	require.Equal(t, int64(math.MaxInt64), deeply.Normalize(uint64(math.MaxInt64)))
+	require.Equal(t, uint64(math.MaxUint64), deeply.Normalize(uint64(math.MaxUint64)))
+	require.Equal(t, int64(9007199254740993), deeply.Normalize(json.Number("9007199254740993")))
+	require.Equal(t, uint64(math.MaxUint64), deeply.Normalize(json.Number("18446744073709551615")))
+	require.Equal(t, 1.5, deeply.Normalize(json.Number("1.5")))
+
+	value, err := deeply.DecodeJSON([]byte(`{"id": 9007199254740993}`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"id": int64(9007199254740993)}, value)
+
+	require.False(t, deeply.EqualsJSON([]byte(`{"id": 9007199254740993}`), []byte(`{"id": 9007199254740992}`)))
+	require.True(t, deeply.MatchesYAML([]byte("id: 9007199254740993"), []byte(`{"id": 9007199254740993}`)))
+}
+
+func TestDecodeYAML(t *testing.T) {
+	value, err := deeply.DecodeYAML([]byte(`
+base: &base
+  id: 1
+  role: user
+user:
+  <<: *base
+  role: admin
+  born: 2024-01-02
+  hex: 0x10
+  1: one
+`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"base": map[string]any{"id": float64(1), "role": "user"},
+		"user": map[string]any{
+			"id":   float64(1),
+			"role": "admin",
+			"born": "2024-01-02",
+			"hex":  float64(16),
+			"1":    "one",
+		},
+	}, value)
+
+	value, err = deeply.DecodeYAML(nil)
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	_, err = deeply.DecodeYAML([]byte("a: [b"))
+	require.Error(t, err)
+
+	_, err = deeply.DecodeYAML([]byte("a:\n  <<: 1\n"))
+	require.Error(t, err)
+
+	// JSON objects have no null keys, so they aren't turned into a string.
+	for _, doc := range []string{"~: 1", "a:\n  null: 1\n", "? \n: 1\n"} {
+		_, err = deeply.DecodeYAML([]byte(doc))
+		require.ErrorContains(t, err, "null key", doc)
+	}
+}
+
+func TestDecodeYAML_Aliases(t *testing.T) {
+	// A shared anchor is expanded at each of its aliases.
+	value, err := deeply.DecodeYAML([]byte("a: &x [1]\nb: [*x, *x]\n"))
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"a": []any{float64(1)}, "b": []any{[]any{float64(1)}, []any{float64(1)}}}, value)
+
+	for _, doc := range []string{
+		"a: &x\n  b: *x\n",
+		"a: &x [*x]\n",
+		"a: &x\n  <<: *x\n",
+		"a: &x\n  <<: [*x]\n",
+	} {
+		_, err := deeply.DecodeYAML([]byte(doc))
+		require.ErrorContains(t, err, "alias refers to itself", doc)
+		require.False(t, deeply.MatchesYAML([]byte(doc), []byte(doc)), doc)
+	}
+
+	// Each level doubles the size of the document: a billion laughs.
+	var laughs strings.Builder
+
+	laughs.WriteString("l0: &l0 [lol, lol]\n")
+
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(&laughs, "l%d: &l%d [*l%d, *l%d]\n", i, i, i-1, i-1)
+	}
+
+	_, err = deeply.DecodeYAML([]byte(laughs.String()))
+	require.ErrorContains(t, err, "document expands to more than")
+}
+
+func TestDecodeJSON(t *testing.T) {
+	value, err := deeply.DecodeJSON([]byte(`{"a": [1, "b", null, true]}`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"a": []any{float64(1), "b", nil, true}}, value)
+
+	_, err = deeply.DecodeJSON([]byte(`{`))
+	require.Error(t, err)
+
+	_, err = deeply.DecodeJSON([]byte(`{} {}`))
+	require.Error(t, err)
+}
+
+func TestMatchesJSON(t *testing.T) {
+	require.True(t, deeply.MatchesJSON([]byte(`{"name": "^al", "age": 42}`), []byte(`{"name": "alice", "age": 42.0}`)))
+	require.False(t, deeply.MatchesJSON([]byte(`{"name": "^al"}`), []byte(`{"name": "bob"}`)))
+	require.False(t, deeply.MatchesJSON([]byte(`{`), []byte(`{}`)))
+	require.False(t, deeply.MatchesJSON([]byte(`{}`), []byte(`{`)))
+
+	require.True(t, deeply.ContainsJSON([]byte(`{"age": 42}`), []byte(`{"name": "alice", "age": 42}`)))
+	require.True(t, deeply.EqualsJSON([]byte(`[1, 2]`), []byte(`[1.0, 2e0]`)))
+	require.False(t, deeply.EqualsJSON([]byte(`{"age": 42}`), []byte(`{"name": "alice", "age": 42}`)))
+}
+
+func TestMatchesYAML(t *testing.T) {
+	expect := []byte("name: ^al\nage: 42\nborn: 2000-01-02\n")
+
+	require.True(t, deeply.MatchesYAML(expect, []byte(`{"name": "alice", "age": 42.0, "born": "2000-01-02"}`)))
+	require.True(t, deeply.MatchesYAML(expect, []byte("name: alice\nage: 42\nborn: 2000-01-02\n")))
+	require.False(t, deeply.MatchesYAML(expect, []byte("name: bob\n")))
+	require.False(t, deeply.MatchesYAML([]byte("a: [b"), expect))
+
+	require.True(t, deeply.ContainsYAML([]byte("age: 42\n"), []byte("name: alice\nage: 42.0\n")))
+	require.True(t, deeply.EqualsYAML([]byte("ids: [1, 2]\n"), []byte(`{"ids": [1, 2]}`)))
+	require.False(t, deeply.EqualsYAML([]byte("ids: [1, 2]\n"), []byte("ids: [2, 1]\n")))
+}
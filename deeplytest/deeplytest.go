@@ -0,0 +1,160 @@
+// Package deeplytest provides test helpers built on the matching functions of
+// the deeply package. Unlike assert.True(t, deeply.Contains(expect, actual)),
+// they report the paths at which the values differ on failure.
+package deeplytest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gripmock/deeply"
+)
+
+// Report describes why the actual value doesn't match the expected value in
+// the mode, listing a mismatch per line. It returns an empty string if the
+// values match.
+func Report(expect, actual any, mode deeply.Mode) string {
+	mismatches := deeply.Explain(expect, actual, mode)
+	if len(mismatches) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "actual value doesn't match the expectation (%s):", mode)
+
+	for _, mismatch := range mismatches {
+		sb.WriteString("\n\t")
+		sb.WriteString(mismatch.String())
+	}
+
+	return sb.String()
+}
+
+// Assert checks that the actual value matches the expected value in the mode.
+// It reports the mismatches and marks the test as failed otherwise.
+// The optional message and arguments are formatted with fmt.Sprint or
+// fmt.Sprintf if the first of them is a format string.
+func Assert(t testing.TB, mode deeply.Mode, expect, actual any, msgAndArgs ...any) bool {
+	t.Helper()
+
+	report := Report(expect, actual, mode)
+	if report == "" {
+		return true
+	}
+
+	t.Errorf("%s%s", message(msgAndArgs), report)
+
+	return false
+}
+
+// Require checks that the actual value matches the expected value in the mode.
+// It reports the mismatches and stops the test otherwise.
+func Require(t testing.TB, mode deeply.Mode, expect, actual any, msgAndArgs ...any) {
+	t.Helper()
+
+	if !Assert(t, mode, expect, actual, msgAndArgs...) {
+		t.FailNow()
+	}
+}
+
+// AssertMatches checks that the actual value matches the expectation like deeply.Matches.
+func AssertMatches(t testing.TB, expect, actual any, msgAndArgs ...any) bool {
+	t.Helper()
+
+	return Assert(t, deeply.ModeMatches, expect, actual, msgAndArgs...)
+}
+
+// AssertMatchesIgnoreArrayOrder checks that the actual value matches the
+// expectation like deeply.MatchesIgnoreArrayOrder.
+func AssertMatchesIgnoreArrayOrder(t testing.TB, expect, actual any, msgAndArgs ...any) bool {
+	t.Helper()
+
+	return Assert(t, deeply.ModeMatchesIgnoreArrayOrder, expect, actual, msgAndArgs...)
+}
+
+// AssertContains checks that the actual value contains the expectation like deeply.Contains.
+func AssertContains(t testing.TB, expect, actual any, msgAndArgs ...any) bool {
+	t.Helper()
+
+	return Assert(t, deeply.ModeContains, expect, actual, msgAndArgs...)
+}
+
+// AssertContainsIgnoreArrayOrder checks that the actual value contains the
+// expectation like deeply.ContainsIgnoreArrayOrder.
+func AssertContainsIgnoreArrayOrder(t testing.TB, expect, actual any, msgAndArgs ...any) bool {
+	t.Helper()
+
+	return Assert(t, deeply.ModeContainsIgnoreArrayOrder, expect, actual, msgAndArgs...)
+}
+
+// AssertEquals checks that the actual value equals the expectation like deeply.Equals.
+func AssertEquals(t testing.TB, expect, actual any, msgAndArgs ...any) bool {
+	t.Helper()
+
+	return Assert(t, deeply.ModeEquals, expect, actual, msgAndArgs...)
+}
+
+// AssertEqualsIgnoreArrayOrder checks that the actual value equals the
+// expectation like deeply.EqualsIgnoreArrayOrder.
+func AssertEqualsIgnoreArrayOrder(t testing.TB, expect, actual any, msgAndArgs ...any) bool {
+	t.Helper()
+
+	return Assert(t, deeply.ModeEqualsIgnoreArrayOrder, expect, actual, msgAndArgs...)
+}
+
+// RequireMatches is like AssertMatches but stops the test on failure.
+func RequireMatches(t testing.TB, expect, actual any, msgAndArgs ...any) {
+	t.Helper()
+
+	Require(t, deeply.ModeMatches, expect, actual, msgAndArgs...)
+}
+
+// RequireMatchesIgnoreArrayOrder is like AssertMatchesIgnoreArrayOrder but stops the test on failure.
+func RequireMatchesIgnoreArrayOrder(t testing.TB, expect, actual any, msgAndArgs ...any) {
+	t.Helper()
+
+	Require(t, deeply.ModeMatchesIgnoreArrayOrder, expect, actual, msgAndArgs...)
+}
+
+// RequireContains is like AssertContains but stops the test on failure.
+func RequireContains(t testing.TB, expect, actual any, msgAndArgs ...any) {
+	t.Helper()
+
+	Require(t, deeply.ModeContains, expect, actual, msgAndArgs...)
+}
+
+// RequireContainsIgnoreArrayOrder is like AssertContainsIgnoreArrayOrder but stops the test on failure.
+func RequireContainsIgnoreArrayOrder(t testing.TB, expect, actual any, msgAndArgs ...any) {
+	t.Helper()
+
+	Require(t, deeply.ModeContainsIgnoreArrayOrder, expect, actual, msgAndArgs...)
+}
+
+// RequireEquals is like AssertEquals but stops the test on failure.
+func RequireEquals(t testing.TB, expect, actual any, msgAndArgs ...any) {
+	t.Helper()
+
+	Require(t, deeply.ModeEquals, expect, actual, msgAndArgs...)
+}
+
+// RequireEqualsIgnoreArrayOrder is like AssertEqualsIgnoreArrayOrder but stops the test on failure.
+func RequireEqualsIgnoreArrayOrder(t testing.TB, expect, actual any, msgAndArgs ...any) {
+	t.Helper()
+
+	Require(t, deeply.ModeEqualsIgnoreArrayOrder, expect, actual, msgAndArgs...)
+}
+
+// message formats the optional message of an assertion, followed by a new line.
+func message(msgAndArgs []any) string {
+	if len(msgAndArgs) == 0 {
+		return ""
+	}
+
+	if format, ok := msgAndArgs[0].(string); ok && len(msgAndArgs) > 1 {
+		return fmt.Sprintf(format, msgAndArgs[1:]...) + "\n"
+	}
+
+	return fmt.Sprint(msgAndArgs...) + "\n"
+}
@@ -0,0 +1,82 @@
+package deeplytest_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+	"github.com/gripmock/deeply/deeplytest"
+)
+
+// recorder records the failures of a test instead of failing it.
+type recorder struct {
+	testing.TB
+
+	errors []string
+	failed bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) FailNow() {
+	r.failed = true
+}
+
+func TestAssert_Success(t *testing.T) {
+	r := &recorder{TB: t}
+
+	expect := map[string]any{"name": "^b", "tags": []any{"b", "a"}}
+	actual := map[string]any{"name": "bob", "tags": []any{"a", "b"}, "id": 1}
+
+	require.True(t, deeplytest.AssertMatchesIgnoreArrayOrder(r, expect, actual))
+	require.True(t, deeplytest.AssertContainsIgnoreArrayOrder(r, map[string]any{"tags": []any{"b", "a"}}, actual))
+	require.True(t, deeplytest.AssertContains(r, map[string]any{"id": 1}, actual))
+	require.True(t, deeplytest.AssertMatches(r, map[string]any{"id": 1}, actual))
+	require.True(t, deeplytest.AssertEquals(r, actual, actual))
+	require.True(t, deeplytest.AssertEqualsIgnoreArrayOrder(r, map[string]any{"tags": []any{"b", "a"}}, map[string]any{"tags": []any{"a", "b"}}))
+
+	deeplytest.RequireMatches(r, expect["name"], "bob")
+	deeplytest.RequireMatchesIgnoreArrayOrder(r, expect, actual)
+	deeplytest.RequireContains(r, expect["tags"], expect["tags"])
+	deeplytest.RequireContainsIgnoreArrayOrder(r, expect["tags"], actual["tags"])
+	deeplytest.RequireEquals(r, 1, 1)
+	deeplytest.RequireEqualsIgnoreArrayOrder(r, expect["tags"], actual["tags"])
+
+	require.Empty(t, r.errors)
+	require.False(t, r.failed)
+}
+
+func TestAssert_Failure(t *testing.T) {
+	r := &recorder{TB: t}
+
+	expect := map[string]any{"user": map[string]any{"name": "bob", "role": "admin"}}
+	actual := map[string]any{"user": map[string]any{"name": "alice"}}
+
+	require.False(t, deeplytest.AssertContains(r, expect, actual, "user %d", 7))
+	require.Equal(t, []string{
+		"user 7\n" +
+			"actual value doesn't match the expectation (contains):\n" +
+			"\t$.user.name: not equal: expected \"bob\", got \"alice\"\n" +
+			"\t$.user.role: missing key: expected \"admin\"",
+	}, r.errors)
+	require.False(t, r.failed)
+
+	deeplytest.RequireEquals(r, 1, 2, "numbers")
+
+	require.Len(t, r.errors, 2)
+	require.Equal(t, "numbers\nactual value doesn't match the expectation (equals):\n\t$: not equal: expected 1, got 2", r.errors[1])
+	require.True(t, r.failed)
+}
+
+func TestReport(t *testing.T) {
+	require.Empty(t, deeplytest.Report("^a", "abc", deeply.ModeMatches))
+	require.Equal(t,
+		"actual value doesn't match the expectation (matches):\n\t$: does not match the pattern: expected \"^a\", got \"bcd\"",
+		deeplytest.Report("^a", "bcd", deeply.ModeMatches))
+}
@@ -0,0 +1,49 @@
+package deeplytest
+
+import (
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+
+	"github.com/gripmock/deeply"
+)
+
+// matcher is a Gomega matcher checking the actual value in a mode.
+type matcher struct {
+	mode   deeply.Mode
+	expect any
+}
+
+// MatchDeeply succeeds if the actual value matches the expectation like deeply.Matches.
+func MatchDeeply(expect any) types.GomegaMatcher {
+	return MatchMode(deeply.ModeMatches, expect)
+}
+
+// ContainDeeply succeeds if the actual value contains the expectation like deeply.Contains.
+func ContainDeeply(expect any) types.GomegaMatcher {
+	return MatchMode(deeply.ModeContains, expect)
+}
+
+// EqualDeeply succeeds if the actual value equals the expectation like deeply.Equals.
+func EqualDeeply(expect any) types.GomegaMatcher {
+	return MatchMode(deeply.ModeEquals, expect)
+}
+
+// MatchMode succeeds if the actual value matches the expectation in the mode.
+func MatchMode(mode deeply.Mode, expect any) types.GomegaMatcher {
+	return &matcher{mode: mode, expect: expect}
+}
+
+// Match checks if the actual value matches the expectation.
+func (m *matcher) Match(actual any) (bool, error) {
+	return m.mode.Match(m.expect, actual), nil
+}
+
+// FailureMessage reports the mismatches between the actual value and the expectation.
+func (m *matcher) FailureMessage(actual any) string {
+	return format.Message(actual, "to match in mode "+m.mode.String(), m.expect) + "\n" + Report(m.expect, actual, m.mode)
+}
+
+// NegatedFailureMessage reports that the actual value unexpectedly matches the expectation.
+func (m *matcher) NegatedFailureMessage(actual any) string {
+	return format.Message(actual, "not to match in mode "+m.mode.String(), m.expect)
+}
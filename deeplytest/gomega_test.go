@@ -0,0 +1,37 @@
+package deeplytest_test
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+	"github.com/gripmock/deeply/deeplytest"
+)
+
+func TestGomega_Matchers(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	actual := map[string]any{"name": "bob", "tags": []any{"a", "b"}}
+
+	g.Expect(actual).To(deeplytest.MatchDeeply(map[string]any{"name": "^b"}))
+	g.Expect(actual).To(deeplytest.ContainDeeply(map[string]any{"tags": []any{"a", "b"}}))
+	g.Expect(actual).NotTo(deeplytest.ContainDeeply(map[string]any{"tags": []any{"b", "a"}}))
+	g.Expect(actual).To(deeplytest.MatchMode(deeply.ModeContainsIgnoreArrayOrder, map[string]any{"tags": []any{"b", "a"}}))
+	g.Expect(actual).To(deeplytest.EqualDeeply(actual))
+	g.Expect(actual).NotTo(deeplytest.EqualDeeply(map[string]any{"name": "bob"}))
+}
+
+func TestGomega_FailureMessage(t *testing.T) {
+	matcher := deeplytest.ContainDeeply(map[string]any{"name": "bob"})
+	actual := map[string]any{"name": "alice"}
+
+	ok, err := matcher.Match(actual)
+
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Contains(t, matcher.FailureMessage(actual), "to match in mode contains")
+	require.Contains(t, matcher.FailureMessage(actual), "\t$.name: not equal: expected \"bob\", got \"alice\"")
+	require.Contains(t, matcher.NegatedFailureMessage(actual), "not to match in mode contains")
+}
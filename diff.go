@@ -0,0 +1,262 @@
+package deeply
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DiffFormat is the output format of FormatDiff.
+type DiffFormat int
+
+const (
+	// DiffPlain formats the diff as plain text.
+	DiffPlain DiffFormat = iota
+	// DiffANSI formats the diff as text colored with ANSI escape codes.
+	DiffANSI
+	// DiffMarkdown formats the diff as a Markdown diff code block.
+	DiffMarkdown
+)
+
+// DiffOptions configures FormatDiff.
+type DiffOptions struct {
+	// Mode is the mode whose view of the values is rendered.
+	Mode Mode
+	// Format is the output format.
+	Format DiffFormat
+}
+
+// ANSI escape codes used by DiffANSI.
+const (
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiDim   = "\x1b[2m"
+	ansiReset = "\x1b[0m"
+)
+
+// diffLine is a line of a diff.
+type diffLine struct {
+	op      byte   // ' ' for context lines, '-' for expected values and '+' for actual values.
+	depth   int    // Indentation level.
+	text    string // Content of the line.
+	comment string // Reason of the mismatch, if any.
+	dim     bool   // Whether the line summarizes values without mismatches.
+}
+
+// differ renders the diff of the values from the mismatches found by Explain.
+type differ struct {
+	mode       Mode
+	mismatches map[string]Mismatch
+	lines      []diffLine
+}
+
+// FormatDiff renders a unified diff of the expected and the actual values as
+// seen by the mode of the options. It returns an empty string if the values match.
+//
+// The diff is built from the mismatches found by Explain. Expected values
+// which differ are prefixed with "-" and annotated with the reason, actual
+// values are prefixed with "+". Sub-trees without mismatches are collapsed
+// and keys ignored by the mode are counted.
+func FormatDiff(expect, actual any, opts DiffOptions) string {
+	mismatches := Explain(expect, actual, opts.Mode)
+	if len(mismatches) == 0 {
+		return ""
+	}
+
+	d := &differ{mode: opts.Mode, mismatches: make(map[string]Mismatch, len(mismatches))}
+
+	for _, m := range mismatches {
+		d.mismatches[m.Path] = m
+	}
+
+	d.render("$", "", expect, actual, 0)
+
+	var sb strings.Builder
+
+	if opts.Format == DiffMarkdown {
+		sb.WriteString("```diff\n")
+	}
+
+	for _, line := range d.lines {
+		sb.WriteString(line.format(opts.Format))
+		sb.WriteByte('\n')
+	}
+
+	if opts.Format == DiffMarkdown {
+		sb.WriteString("```\n")
+	}
+
+	return sb.String()
+}
+
+// format formats the line in the output format.
+func (l diffLine) format(f DiffFormat) string {
+	text := string(l.op) + " " + strings.Repeat("  ", l.depth) + l.text
+	if l.comment != "" {
+		text += "  // " + l.comment
+	}
+
+	if f != DiffANSI {
+		return text
+	}
+
+	switch l.op {
+	case '-':
+		return ansiRed + text + ansiReset
+	case '+':
+		return ansiGreen + text + ansiReset
+	default:
+		if l.dim {
+			return ansiDim + text + ansiReset
+		}
+
+		return text
+	}
+}
+
+// line appends a line to the diff.
+func (d *differ) line(op byte, depth int, text, comment string) {
+	d.lines = append(d.lines, diffLine{op: op, depth: depth, text: text, comment: comment})
+}
+
+// render renders the values at the path, labeled with their key in the parent map.
+func (d *differ) render(path, label string, expect, actual any, depth int) {
+	if m, ok := d.mismatches[path]; ok {
+		switch m.Reason {
+		case reasonMissingKey, reasonNoElement:
+			d.line('-', depth, label+formatJSON(m.Expect), m.Reason)
+		case reasonUnexpectedKey:
+			d.line('+', depth, label+formatJSON(m.Actual), m.Reason)
+		default:
+			d.line('-', depth, label+formatJSON(m.Expect), m.Reason)
+			d.line('+', depth, label+formatJSON(m.Actual), "")
+		}
+
+		return
+	}
+
+	if !d.hasMismatchesUnder(path) {
+		d.lines = append(d.lines, diffLine{op: ' ', depth: depth, text: label + collapse(expect), dim: true})
+
+		return
+	}
+
+	switch reflect.TypeOf(expect).Kind() { //nolint:exhaustive
+	case reflect.Map:
+		d.renderMap(path, label, expect, actual, depth)
+	case reflect.Slice:
+		d.line(' ', depth, label+"[", "")
+
+		left, right := reflect.ValueOf(expect), reflect.ValueOf(actual)
+
+		for i := range left.Len() {
+			var item any
+
+			// The elements are paired by their index unless the order is ignored.
+			if i < right.Len() && !d.mode.ignoreArrayOrder() {
+				item = right.Index(i).Interface()
+			}
+
+			d.render(childPath(path, i), "", left.Index(i).Interface(), item, depth+1)
+		}
+
+		d.line(' ', depth, "]", "")
+	}
+}
+
+// renderMap renders the keys of the expected map and the unexpected keys of the actual map.
+func (d *differ) renderMap(path, label string, expect, actual any, depth int) {
+	left, right := reflect.ValueOf(expect), reflect.ValueOf(actual)
+
+	d.line(' ', depth, label+"{", "")
+
+	for _, key := range sortedKeys(left) {
+		var value any
+		if v := right.MapIndex(key); v.IsValid() {
+			value = v.Interface()
+		}
+
+		d.render(childPath(path, key.Interface()), formatKey(key.Interface()), left.MapIndex(key).Interface(), value, depth+1)
+	}
+
+	ignored := 0
+
+	for _, key := range sortedKeys(right) {
+		if left.MapIndex(key).IsValid() {
+			continue
+		}
+
+		if m, ok := d.mismatches[childPath(path, key.Interface())]; ok {
+			d.line('+', depth+1, formatKey(key.Interface())+formatJSON(m.Actual), m.Reason)
+		} else {
+			ignored++
+		}
+	}
+
+	if ignored > 0 {
+		d.lines = append(d.lines, diffLine{
+			op:    ' ',
+			depth: depth + 1,
+			text:  fmt.Sprintf("… %d ignored %s", ignored, plural(ignored, "key")),
+			dim:   true,
+		})
+	}
+
+	d.line(' ', depth, "}", "")
+}
+
+// hasMismatchesUnder checks if there are mismatches below the path.
+func (d *differ) hasMismatchesUnder(path string) bool {
+	for p := range d.mismatches {
+		if len(p) > len(path) && strings.HasPrefix(p, path) && (p[len(path)] == '.' || p[len(path)] == '[') {
+			return true
+		}
+	}
+
+	return false
+}
+
+// collapse formats a value without mismatches: maps and slices are replaced
+// with the number of their entries.
+func collapse(value any) string {
+	v := reflect.ValueOf(value)
+
+	switch {
+	case v.Kind() == reflect.Map && v.Len() > 0:
+		return fmt.Sprintf("{… %d %s}", v.Len(), plural(v.Len(), "key"))
+	case v.Kind() == reflect.Slice && v.Len() > 0:
+		return fmt.Sprintf("[… %d %s]", v.Len(), plural(v.Len(), "item"))
+	default:
+		return formatJSON(value)
+	}
+}
+
+// formatKey formats a map key as a label.
+func formatKey(key any) string {
+	return formatJSON(fmt.Sprint(key)) + ": "
+}
+
+// formatJSON formats a value as compact JSON, falling back to the Go syntax
+// for values which can't be encoded.
+func formatJSON(value any) string {
+	var sb strings.Builder
+
+	encoder := json.NewEncoder(&sb)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprintf("%#v", value)
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// plural returns the noun in the plural form if the count is not one.
+func plural(count int, noun string) string {
+	if count == 1 {
+		return noun
+	}
+
+	return noun + "s"
+}
@@ -0,0 +1,110 @@
+package deeply_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+func TestFormatDiff_Plain(t *testing.T) {
+	expect := map[string]any{
+		"name": "^bob$",
+		"address": map[string]any{
+			"city": "Berlin",
+			"zip":  "10115",
+		},
+		"tags":  []any{"a", "b"},
+		"roles": []any{"admin", "dev"},
+	}
+
+	actual := map[string]any{
+		"name": "alice",
+		"address": map[string]any{
+			"city":    "Munich",
+			"country": "DE",
+		},
+		"tags":  []any{"a", "b"},
+		"roles": []any{"admin", "ops"},
+		"age":   42,
+	}
+
+	require.Equal(t, `  {
+    "address": {
+-     "city": "Berlin"  // does not match the pattern
++     "city": "Munich"
+-     "zip": "10115"  // missing key
+      … 1 ignored key
+    }
+-   "name": "^bob$"  // does not match the pattern
++   "name": "alice"
+    "roles": [
+      "admin"
+-     "dev"  // does not match the pattern
++     "ops"
+    ]
+    "tags": [… 2 items]
+    … 1 ignored key
+  }
+`, deeply.FormatDiff(expect, actual, deeply.DiffOptions{Mode: deeply.ModeMatches}))
+
+	require.Empty(t, deeply.FormatDiff(expect, expect, deeply.DiffOptions{}))
+}
+
+func TestFormatDiff_Modes(t *testing.T) {
+	expect := map[string]any{"tags": []any{"b", "c"}, "id": 1}
+	actual := map[string]any{"tags": []any{"a", "b"}, "id": 1, "extra": true}
+
+	require.Equal(t, `  {
+    "id": 1
+-   "tags": ["b","c"]  // not equal
++   "tags": ["a","b"]
+    … 1 ignored key
+  }
+`, deeply.FormatDiff(expect, actual, deeply.DiffOptions{Mode: deeply.ModeContains}))
+
+	require.Equal(t, `  {
+    "id": 1
+    "tags": [
+      "b"
+-     "c"  // no matching element
+    ]
++   "extra": true  // unexpected key
+  }
+`, deeply.FormatDiff(expect, actual, deeply.DiffOptions{Mode: deeply.ModeEqualsIgnoreArrayOrder}))
+
+	require.Equal(t, `- 1  // different type
++ "1"
+`, deeply.FormatDiff(1, "1", deeply.DiffOptions{Mode: deeply.ModeEquals}))
+}
+
+func TestFormatDiff_Formats(t *testing.T) {
+	expect := map[string]any{"a": 1, "b": map[string]any{"c": 1}}
+	actual := map[string]any{"a": 2, "b": map[string]any{"c": 1}}
+
+	require.Equal(t, "  {\n"+
+		"\x1b[31m-   \"a\": 1  // not equal\x1b[0m\n"+
+		"\x1b[32m+   \"a\": 2\x1b[0m\n"+
+		"\x1b[2m    \"b\": {… 1 key}\x1b[0m\n"+
+		"  }\n", deeply.FormatDiff(expect, actual, deeply.DiffOptions{Mode: deeply.ModeEquals, Format: deeply.DiffANSI}))
+
+	require.Equal(t, "```diff\n"+
+		"  {\n"+
+		"-   \"a\": 1  // not equal\n"+
+		"+   \"a\": 2\n"+
+		"    \"b\": {… 1 key}\n"+
+		"  }\n"+
+		"```\n", deeply.FormatDiff(expect, actual, deeply.DiffOptions{Mode: deeply.ModeEquals, Format: deeply.DiffMarkdown}))
+}
+
+func TestFormatDiff_Operators(t *testing.T) {
+	expect := map[string]any{"version": map[string]any{"$format": "semver:>=2"}}
+	actual := map[string]any{"version": "1.0.0"}
+
+	require.Equal(t, `  {
+-   "version": {"$format":"semver:>=2"}  // does not satisfy $format
++   "version": "1.0.0"
+  }
+`, deeply.FormatDiff(expect, actual, deeply.DiffOptions{Mode: deeply.ModeMatches}))
+}
@@ -0,0 +1,85 @@
+package deeply
+
+import (
+	"sync"
+)
+
+// document is the actual document compared by a call of Matches, Contains,
+// Equals or one of their variants. Its values are compared recursively by the
+// comparison bound to it, so that the operators reading the root of the
+// document, such as $expr, find it at any depth.
+type document struct {
+	root      any       // The actual value of the call.
+	tolerance tolerance // Differences under which numbers are equal, see Matcher.
+	compare   cmp       // The comparison of the mode bound to the document.
+}
+
+// documentPool recycles the documents of a mode, so that the comparisons
+// cost no allocation.
+type documentPool struct {
+	pool sync.Pool
+}
+
+// newDocumentPool returns a pool of documents with the comparison returned by bind.
+func newDocumentPool(bind func(d *document) cmp) *documentPool {
+	p := &documentPool{}
+	p.pool.New = func() any {
+		d := &document{}
+		d.compare = bind(d)
+
+		return d
+	}
+
+	return p
+}
+
+// compare compares the expected and actual values within the document of the root.
+func (p *documentPool) compare(root, expect, actual any) bool {
+	return p.compareWithin(tolerance{}, root, expect, actual)
+}
+
+// compareWithin compares the expected and actual values within the document
+// of the root, numbers being equal within the tolerance.
+func (p *documentPool) compareWithin(tol tolerance, root, expect, actual any) bool {
+	d, _ := p.pool.Get().(*document)
+	d.root, d.tolerance = root, tol
+
+	defer func() {
+		d.root, d.tolerance = nil, tolerance{}
+		p.pool.Put(d)
+	}()
+
+	return d.compare(expect, actual)
+}
+
+// equal checks if the values are deeply equal, numbers being equal within
+// the tolerance of the document.
+func (d *document) equal(expect, actual any) bool {
+	if d.tolerance == (tolerance{}) {
+		return deepEqual(expect, actual)
+	}
+
+	return d.tolerance.deepEqual(expect, actual)
+}
+
+// documents holds the pools of documents keyed by their mode.
+//
+//nolint:gochecknoglobals
+var documents = [...]*documentPool{
+	ModeMatches:                  newDocumentPool(func(d *document) cmp { return d.matches }),
+	ModeMatchesIgnoreArrayOrder:  newDocumentPool(func(d *document) cmp { return d.matchesIgnoreArrayOrder }),
+	ModeContains:                 newDocumentPool(func(d *document) cmp { return d.contains }),
+	ModeContainsIgnoreArrayOrder: newDocumentPool(func(d *document) cmp { return d.containsIgnoreArrayOrder }),
+	ModeEquals:                   newDocumentPool(func(d *document) cmp { return d.equals }),
+	ModeEqualsIgnoreArrayOrder:   newDocumentPool(func(d *document) cmp { return d.equalsIgnoreArrayOrder }),
+}
+
+// documentsOf returns the pool of documents of the mode, of ModeMatches for
+// the invalid modes.
+func documentsOf(m Mode) *documentPool {
+	if m < 0 || int(m) >= len(documents) {
+		return documents[ModeMatches]
+	}
+
+	return documents[m]
+}
@@ -9,17 +9,48 @@ import (
 //   - The expected and actual values are both maps and have the same number of keys.
 //   - The expected and actual values are both slices and have the same length.
 //   - The expected and actual values are deeply equal using reflect.DeepEqual.
+//
+// If the expected value is an operator node, the actual value is checked by the operator instead.
+// Values of a type with a comparator are compared by it, see RegisterTypeComparator.
 func Equals(expect, actual any) bool {
-	return mapDeepEqual(expect, actual, Equals) || reflect.DeepEqual(expect, actual)
+	return documents[ModeEquals].compare(actual, expect, actual)
+}
+
+// equals is Equals within the document.
+func (d *document) equals(expect, actual any) bool {
+	if node, ok := asOperatorNode(expect); ok {
+		return node.match(actual, d.compare, d.root)
+	}
+
+	if ok, found := matchByType(expect, actual); found {
+		return ok
+	}
+
+	return mapDeepEqual(expect, actual, d.compare) ||
+		slicesDeepMatches(expect, actual, d.compare) ||
+		d.equal(expect, actual)
 }
 
 // EqualsIgnoreArrayOrder checks if the expected and actual values are deeply equal
 // ignoring the order of arrays. It behaves similarly to Equals except that it
 // uses slicesDeepEqualContains instead of slicesDeepEqual to compare slices.
 func EqualsIgnoreArrayOrder(expect, actual any) bool {
-	return mapDeepEqual(expect, actual, EqualsIgnoreArrayOrder) ||
-		slicesDeepEqual(expect, actual, EqualsIgnoreArrayOrder) ||
-		reflect.DeepEqual(expect, actual)
+	return documents[ModeEqualsIgnoreArrayOrder].compare(actual, expect, actual)
+}
+
+// equalsIgnoreArrayOrder is EqualsIgnoreArrayOrder within the document.
+func (d *document) equalsIgnoreArrayOrder(expect, actual any) bool {
+	if node, ok := asOperatorNode(expect); ok {
+		return node.match(actual, d.compare, d.root)
+	}
+
+	if ok, found := matchByType(expect, actual); found {
+		return ok
+	}
+
+	return mapDeepEqual(expect, actual, d.compare) ||
+		slicesDeepEqual(expect, actual, d.compare) ||
+		d.equal(expect, actual)
 }
 
 // mapDeepEqual checks if the expected and actual values are deeply equal as maps.
@@ -28,6 +59,13 @@ func EqualsIgnoreArrayOrder(expect, actual any) bool {
 //   - The expected and actual values are both maps and have the same number of keys.
 //   - The expected and actual values are deeply equal using the provided compare function.
 func mapDeepEqual(expect, actual any, compare cmp) bool {
+	// Fast path for decoded JSON objects.
+	if left, ok := expect.(map[string]any); ok {
+		if right, ok := actual.(map[string]any); ok {
+			return len(left) == len(right) && mapStringDeepEquals(left, right, compare)
+		}
+	}
+
 	// Check if the types of the expected and actual values are equal.
 	if reflect.TypeOf(expect) != reflect.TypeOf(actual) {
 		return false
@@ -61,6 +99,13 @@ func mapDeepEqual(expect, actual any, compare cmp) bool {
 //   - The expected and actual values are both slices and have the same length.
 //   - The expected and actual values are deeply equal using the provided compare function.
 func slicesDeepEqual(expect, actual any, compare cmp) bool {
+	// Fast path for decoded JSON arrays.
+	if a, ok := expect.([]any); ok {
+		if b, ok := actual.([]any); ok {
+			return len(a) == len(b) && slicesDeepEqualContains(a, b, compare)
+		}
+	}
+
 	// Check if the types of the expected and actual values are equal.
 	if reflect.TypeOf(expect) != reflect.TypeOf(actual) {
 		return false
@@ -76,11 +121,11 @@ func slicesDeepEqual(expect, actual any, compare cmp) bool {
 		return false
 	}
 
-	a := reflect.ValueOf(expect)
-	b := reflect.ValueOf(actual)
+	a, _ := elements(expect)
+	b, _ := elements(actual)
 
 	// If the lengths of the slices are not equal, return false.
-	if a.Len() != b.Len() {
+	if len(a) != len(b) {
 		return false
 	}
 
@@ -115,6 +115,9 @@ func TestEquals_Slices_OrderIgnore(t *testing.T) {
 
 	require.False(t, deeply.EqualsIgnoreArrayOrder([]int{1, 2, 3}, []int{1, 2}))
 	require.False(t, deeply.EqualsIgnoreArrayOrder([]any{1, 2, 3}, []any{1, 2}))
+
+	// Every actual element matches one expected element only.
+	require.False(t, deeply.EqualsIgnoreArrayOrder([]string{"a", "b"}, []string{"a", "a"}))
 }
 
 func TestEquals_Boundary(t *testing.T) {
@@ -0,0 +1,232 @@
+package deeply
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Mismatch is a difference between an expected and an actual value found by Explain.
+type Mismatch struct {
+	// Path is the location of the values in the actual value, e.g. $.items[0].name.
+	Path string
+	// Expect is the expected value at the path, nil for unexpected keys.
+	Expect any
+	// Actual is the actual value at the path, nil for missing keys.
+	Actual any
+	// Reason describes the difference.
+	Reason string
+}
+
+// String returns the mismatch as a single line, e.g.
+// `$.name: not equal: expected "bob", got "alice"`.
+func (m Mismatch) String() string {
+	switch m.Reason {
+	case reasonMissingKey:
+		return fmt.Sprintf("%s: %s: expected %s", m.Path, m.Reason, formatValue(m.Expect))
+	case reasonUnexpectedKey:
+		return fmt.Sprintf("%s: %s: got %s", m.Path, m.Reason, formatValue(m.Actual))
+	default:
+		return fmt.Sprintf("%s: %s: expected %s, got %s", m.Path, m.Reason, formatValue(m.Expect), formatValue(m.Actual))
+	}
+}
+
+// Reasons of the mismatches.
+const (
+	reasonMissingKey    = "missing key"
+	reasonUnexpectedKey = "unexpected key"
+	reasonLength        = "different length"
+	reasonType          = "different type"
+	reasonPattern       = "does not match the pattern"
+	reasonNotEqual      = "not equal"
+	reasonNoElement     = "no matching element"
+	reasonOperator      = "does not satisfy"
+)
+
+// Explain explains why the actual value doesn't match the expected value in
+// the mode. It returns nil if the values match.
+//
+// Explain follows the traversal of the matching functions: it descends into
+// the maps and the slices the mode compares recursively and reports the
+// deepest values which don't match, so the report shows exactly what the
+// mode saw.
+func Explain(expect, actual any, mode Mode) []Mismatch {
+	var res []Mismatch
+
+	explain("$", expect, actual, mode, actual, &res)
+
+	return res
+}
+
+// explain appends the mismatches between the expected and actual values at the path
+// of the actual document of the root.
+func explain(path string, expect, actual any, mode Mode, root any, res *[]Mismatch) {
+	if mode.matchIn(root, expect, actual) {
+		return
+	}
+
+	mismatch := Mismatch{Path: path, Expect: expect, Actual: actual}
+
+	switch {
+	case isOperatorNode(expect):
+		node, _ := asOperatorNode(expect)
+		mismatch.Reason = reasonOperator + " " + strings.Join(node.names, ", ")
+	case hasTypeComparator(expect, actual):
+		mismatch.Reason = reasonNotEqual
+	case reflect.TypeOf(expect) != reflect.TypeOf(actual) || expect == nil || actual == nil:
+		mismatch.Reason = reasonType
+	case reflect.TypeOf(expect).Kind() == reflect.Map:
+		if explainMap(path, expect, actual, mode, root, res) {
+			return
+		}
+
+		mismatch.Reason = reasonNotEqual
+	case reflect.TypeOf(expect).Kind() == reflect.Slice:
+		if explainSlice(path, expect, actual, mode, root, res) {
+			return
+		}
+
+		mismatch.Reason = reasonNotEqual
+	case mode == ModeMatches || mode == ModeMatchesIgnoreArrayOrder:
+		mismatch.Reason = reasonNotEqual
+		if _, ok := expect.(string); ok {
+			mismatch.Reason = reasonPattern
+		}
+	default:
+		mismatch.Reason = reasonNotEqual
+	}
+
+	*res = append(*res, mismatch)
+}
+
+// explainMap appends the mismatches between two maps of the same type.
+// It returns false if no mismatch was found in the keys and the values.
+func explainMap(path string, expect, actual any, mode Mode, root any, res *[]Mismatch) bool {
+	left, right := reflect.ValueOf(expect), reflect.ValueOf(actual)
+	count := len(*res)
+
+	for _, key := range sortedKeys(left) {
+		keyPath := childPath(path, key.Interface())
+
+		value := right.MapIndex(key)
+		if !value.IsValid() {
+			*res = append(*res, Mismatch{Path: keyPath, Expect: left.MapIndex(key).Interface(), Reason: reasonMissingKey})
+
+			continue
+		}
+
+		explain(keyPath, left.MapIndex(key).Interface(), value.Interface(), mode, root, res)
+	}
+
+	if !mode.partialMaps() {
+		for _, key := range sortedKeys(right) {
+			if !left.MapIndex(key).IsValid() {
+				*res = append(*res, Mismatch{
+					Path:   childPath(path, key.Interface()),
+					Actual: right.MapIndex(key).Interface(),
+					Reason: reasonUnexpectedKey,
+				})
+			}
+		}
+	}
+
+	return len(*res) > count
+}
+
+// explainSlice appends the mismatches between two slices of the same type.
+// It returns false if the mode compares the slices as a whole.
+func explainSlice(path string, expect, actual any, mode Mode, root any, res *[]Mismatch) bool {
+	a, b := reflect.ValueOf(expect), reflect.ValueOf(actual)
+
+	lengthOk := a.Len() == b.Len() || a.Len() < b.Len() && mode.partialMaps()
+
+	switch {
+	case mode.orderedSlices():
+		if a.Len() != b.Len() {
+			*res = append(*res, Mismatch{Path: path, Expect: expect, Actual: actual, Reason: reasonLength})
+
+			return true
+		}
+
+		count := len(*res)
+
+		for i := range a.Len() {
+			explain(childPath(path, i), a.Index(i).Interface(), b.Index(i).Interface(), mode, root, res)
+		}
+
+		return len(*res) > count
+	case mode.ignoreArrayOrder():
+		if !lengthOk {
+			*res = append(*res, Mismatch{Path: path, Expect: expect, Actual: actual, Reason: reasonLength})
+
+			return true
+		}
+
+		// Find the expected elements left without a pair, like slicesDeepEqualContains does.
+		count := len(*res)
+
+		pairs := pairElements(a.Len(), b.Len(), func(i, j int) bool {
+			return mode.matchIn(root, a.Index(i).Interface(), b.Index(j).Interface())
+		})
+
+		for i, j := range pairs {
+			if j < 0 {
+				*res = append(*res, Mismatch{Path: childPath(path, i), Expect: a.Index(i).Interface(), Reason: reasonNoElement})
+			}
+		}
+
+		return len(*res) > count
+	default:
+		return false
+	}
+}
+
+// isOperatorNode checks if the expected value is an operator node.
+func isOperatorNode(expect any) bool {
+	_, ok := asOperatorNode(expect)
+
+	return ok
+}
+
+// childPath returns the path of a map value or a slice element.
+// Keys which are not identifiers are quoted, e.g. $["first name"].
+func childPath(path string, key any) string {
+	switch k := key.(type) {
+	case int:
+		return fmt.Sprintf("%s[%d]", path, k)
+	case string:
+		if identifier.MatchString(k) {
+			return path + "." + k
+		}
+
+		return fmt.Sprintf("%s[%q]", path, k)
+	default:
+		return fmt.Sprintf("%s[%v]", path, k)
+	}
+}
+
+//nolint:gochecknoglobals
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// sortedKeys returns the keys of a map sorted by their string representation,
+// to make the reports deterministic.
+func sortedKeys(m reflect.Value) []reflect.Value {
+	keys := m.MapKeys()
+
+	slices.SortFunc(keys, func(a, b reflect.Value) int {
+		return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+	})
+
+	return keys
+}
+
+// formatValue formats a value for a report, quoting strings.
+func formatValue(value any) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,113 @@
+package deeply_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+func TestExplain_Maps(t *testing.T) {
+	expect := map[string]any{
+		"name": "^bob$",
+		"address": map[string]any{
+			"city": "Berlin",
+			"zip":  "10115",
+		},
+		"first name": "Bob",
+	}
+
+	actual := map[string]any{
+		"name": "alice",
+		"address": map[string]any{
+			"city": "Munich",
+		},
+		"first name": "Bob",
+		"age":        42,
+	}
+
+	require.Equal(t, []deeply.Mismatch{
+		{Path: "$.address.city", Expect: "Berlin", Actual: "Munich", Reason: "does not match the pattern"},
+		{Path: "$.address.zip", Expect: "10115", Reason: "missing key"},
+		{Path: "$.name", Expect: "^bob$", Actual: "alice", Reason: "does not match the pattern"},
+	}, deeply.Explain(expect, actual, deeply.ModeMatches))
+
+	require.Equal(t, []deeply.Mismatch{
+		{Path: "$.address.city", Expect: "Berlin", Actual: "Munich", Reason: "not equal"},
+		{Path: "$.address.zip", Expect: "10115", Reason: "missing key"},
+		{Path: "$.name", Expect: "^bob$", Actual: "alice", Reason: "not equal"},
+		{Path: "$.age", Actual: 42, Reason: "unexpected key"},
+	}, deeply.Explain(expect, actual, deeply.ModeEquals))
+
+	require.Nil(t, deeply.Explain(expect, expect, deeply.ModeEquals))
+
+	delete(expect, "name")
+	delete(expect, "address")
+
+	require.Equal(t, []deeply.Mismatch{
+		{Path: "$.address", Actual: map[string]any{"city": "Munich"}, Reason: "unexpected key"},
+		{Path: "$.age", Actual: 42, Reason: "unexpected key"},
+		{Path: "$.name", Actual: "alice", Reason: "unexpected key"},
+	}, deeply.Explain(expect, actual, deeply.ModeEquals))
+	require.Nil(t, deeply.Explain(expect, actual, deeply.ModeContains))
+}
+
+func TestExplain_Slices(t *testing.T) {
+	require.Equal(t, []deeply.Mismatch{
+		{Path: "$.items[1].id", Expect: 2, Actual: 3, Reason: "not equal"},
+	}, deeply.Explain(
+		map[string]any{"items": []any{map[string]any{"id": 1}, map[string]any{"id": 2}}},
+		map[string]any{"items": []any{map[string]any{"id": 1}, map[string]any{"id": 3}}},
+		deeply.ModeMatches,
+	))
+
+	require.Equal(t, []deeply.Mismatch{
+		{Path: "$", Expect: []any{1, 2}, Actual: []any{1, 2, 3}, Reason: "different length"},
+	}, deeply.Explain([]any{1, 2}, []any{1, 2, 3}, deeply.ModeMatches))
+
+	require.Equal(t, []deeply.Mismatch{
+		{Path: "$", Expect: []any{1, 2}, Actual: []any{1, 3}, Reason: "not equal"},
+	}, deeply.Explain([]any{1, 2}, []any{1, 3}, deeply.ModeContains), "Contains compares slices as a whole")
+
+	require.Equal(t, []deeply.Mismatch{
+		{Path: "$[1]", Expect: 4, Reason: "no matching element"},
+	}, deeply.Explain([]any{3, 4}, []any{1, 2, 3}, deeply.ModeContainsIgnoreArrayOrder))
+
+	require.Equal(t, []deeply.Mismatch{
+		{Path: "$", Expect: []any{3}, Actual: []any{1, 3}, Reason: "different length"},
+	}, deeply.Explain([]any{3}, []any{1, 3}, deeply.ModeEqualsIgnoreArrayOrder))
+
+	// The first pattern matches both elements, the pairing leaves "b" to it.
+	require.Empty(t, deeply.Explain([]any{"a|b", "a"}, []any{"a", "b"}, deeply.ModeMatchesIgnoreArrayOrder))
+	require.Equal(t, []deeply.Mismatch{
+		{Path: "$[1]", Expect: "b", Reason: "no matching element"},
+	}, deeply.Explain([]any{"a", "b"}, []any{"a", "a"}, deeply.ModeContainsIgnoreArrayOrder))
+}
+
+func TestExplain_Leaves(t *testing.T) {
+	require.Equal(t, []deeply.Mismatch{
+		{Path: "$.id", Expect: 1, Actual: "1", Reason: "different type"},
+		{Path: "$.when", Expect: map[string]any{"$after": "2024-01-01", "$before": "2024-02-01"}, Actual: "2023-01-01", Reason: "does not satisfy $after, $before"},
+	}, deeply.Explain(
+		map[string]any{"id": 1, "when": map[string]any{"$after": "2024-01-01", "$before": "2024-02-01"}},
+		map[string]any{"id": "1", "when": "2023-01-01"},
+		deeply.ModeContains,
+	))
+
+	require.Equal(t, []deeply.Mismatch{
+		{Path: "$", Expect: nil, Actual: 1, Reason: "different type"},
+	}, deeply.Explain(nil, 1, deeply.ModeEquals))
+}
+
+func TestExplain_String(t *testing.T) {
+	require.Equal(t,
+		`$.name: not equal: expected "bob", got "alice"`,
+		deeply.Mismatch{Path: "$.name", Expect: "bob", Actual: "alice", Reason: "not equal"}.String())
+	require.Equal(t,
+		`$["first name"]: missing key: expected "bob"`,
+		deeply.Explain(map[string]any{"first name": "bob"}, map[string]any{}, deeply.ModeContains)[0].String())
+	require.Equal(t,
+		`$.age: unexpected key: got 42`,
+		deeply.Explain(map[string]any{}, map[string]any{"age": 42}, deeply.ModeEquals)[0].String())
+}
@@ -0,0 +1,12 @@
+package deeply
+
+// Distance exposes distance to the tests of the package.
+var Distance = distance //nolint:gochecknoglobals
+
+// DistanceAtLeast exposes distanceAtLeast to the tests of the package.
+var DistanceAtLeast = distanceAtLeast //nolint:gochecknoglobals
+
+// CompiledExpressions returns the number of cached compiled expressions.
+func CompiledExpressions() int {
+	return compiledExprs.len()
+}
@@ -0,0 +1,754 @@
+package deeply
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// exprMaxCost is the number of evaluation steps after which $expr gives up:
+// every node of the expression evaluated costs 1, every element or character
+// visited by a function or by a comparison costs 1 more.
+const exprMaxCost = 10000
+
+// ErrExpr is returned by CheckExpressions for the expressions of $expr which
+// don't compile.
+var ErrExpr = errors.New("invalid expression")
+
+// Errors of the evaluations of expressions.
+var (
+	errExprCost       = errors.New("evaluation cost exceeded")
+	errNotBoolean     = errors.New("not a boolean")
+	errNotNumber      = errors.New("not a number")
+	errNotString      = errors.New("not a string")
+	errNotList        = errors.New("neither a string nor a list")
+	errNotComparable  = errors.New("not comparable")
+	errNoSize         = errors.New("no size")
+	errInvalidIndex   = errors.New("invalid index")
+	errDivisionByZero = errors.New("division by zero")
+)
+
+// exprNode is a node of a compiled expression.
+type exprNode interface {
+	eval(env *exprEnv) (any, error)
+}
+
+// exprEnv is the environment of an evaluation.
+type exprEnv struct {
+	current any   // The actual value checked by the expression, "@".
+	root    any   // The actual document, "$".
+	items   []any // The elements visited by all, any and count, "it" being the last one.
+	cost    int
+}
+
+// spend adds to the cost of the evaluation and fails if it exceeds exprMaxCost.
+func (env *exprEnv) spend(cost int) error {
+	env.cost += cost
+	if env.cost > exprMaxCost {
+		return errExprCost
+	}
+
+	return nil
+}
+
+// exprCacheSize is the number of compiled expressions and of regular
+// expressions of matches kept by their caches.
+const exprCacheSize = 256
+
+// compiledExpr is the result of the compilation of an expression.
+type compiledExpr struct {
+	node exprNode
+	err  error
+}
+
+// Caches of the compiled expressions and of the regular expressions of
+// matches, keyed by their source.
+//
+//nolint:gochecknoglobals
+var (
+	compiledExprs = newLRU[string, compiledExpr](exprCacheSize)
+	exprRegexps   = newLRU[string, compiledRegexp](exprCacheSize)
+)
+
+// exprOf returns the compiled expression of the source.
+func exprOf(src string) (exprNode, error) {
+	c := compiledExprs.get(src, func(src string) compiledExpr {
+		node, err := compileExpr(src)
+
+		return compiledExpr{node: node, err: err}
+	})
+
+	return c.node, c.err
+}
+
+// evalExpr checks if the expression of $expr is true for the actual value.
+// Syntax errors and evaluation errors are logged and fail the match.
+func evalExpr(node map[string]any, actual, root any) bool {
+	src, ok := node["$expr"].(string)
+	if !ok {
+		log.Printf("Error on parsing $expr operand %v: not a string\n", node["$expr"])
+
+		return false
+	}
+
+	expr, err := exprOf(src)
+	if err != nil {
+		log.Printf("Error on parsing $expr operand %s: %v\n", src, err)
+
+		return false
+	}
+
+	res, err := expr.eval(&exprEnv{current: actual, root: root})
+	if err != nil {
+		log.Printf("Error on evaluating $expr %s: %v\n", src, err)
+
+		return false
+	}
+
+	b, ok := res.(bool)
+	if !ok {
+		log.Printf("Error on evaluating $expr %s: %v: %v\n", src, errNotBoolean, res)
+
+		return false
+	}
+
+	return b
+}
+
+// CheckExpressions compiles the expressions of the $expr operators of the
+// expectation, so that their syntax errors are found before the requests
+// come. It returns the errors prefixed with the paths of the operators.
+func CheckExpressions(expect any) error {
+	var errs []error
+
+	checkExpressions("$", expect, &errs)
+
+	return errors.Join(errs...)
+}
+
+// checkExpressions appends the errors of the expressions at the path.
+func checkExpressions(path string, expect any, errs *[]error) {
+	if node, ok := expect.(map[string]any); ok && isOperatorNode(node) {
+		if value, ok := node["$expr"]; ok {
+			src, ok := value.(string)
+			if !ok {
+				*errs = append(*errs, fmt.Errorf("%s: %w: not a string", path, ErrExpr))
+			} else if _, err := exprOf(src); err != nil {
+				*errs = append(*errs, fmt.Errorf("%s: %w", path, err))
+			}
+		}
+	}
+
+	v := reflect.ValueOf(expect)
+
+	switch v.Kind() {
+	case reflect.Map:
+		for _, key := range sortedKeys(v) {
+			checkExpressions(childPath(path, key.Interface()), v.MapIndex(key).Interface(), errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			checkExpressions(childPath(path, i), v.Index(i).Interface(), errs)
+		}
+	default:
+	}
+}
+
+// literalNode is a number, a string, a boolean or null.
+type literalNode struct {
+	value any
+}
+
+func (n literalNode) eval(env *exprEnv) (any, error) {
+	return n.value, env.spend(1)
+}
+
+// currentNode is the actual value checked by the expression, "@".
+type currentNode struct{}
+
+func (currentNode) eval(env *exprEnv) (any, error) {
+	return env.current, env.spend(1)
+}
+
+// rootNode is the actual document, "$".
+type rootNode struct{}
+
+func (rootNode) eval(env *exprEnv) (any, error) {
+	return env.root, env.spend(1)
+}
+
+// nameNode is the element visited by all, any and count if the name is
+// "it", or else a field of the actual value.
+type nameNode struct {
+	name string
+}
+
+func (n nameNode) eval(env *exprEnv) (any, error) {
+	if err := env.spend(1); err != nil {
+		return nil, err
+	}
+
+	if n.name == "it" && len(env.items) > 0 {
+		return env.items[len(env.items)-1], nil
+	}
+
+	return field(env.current, n.name), nil
+}
+
+// listNode is a list of expressions.
+type listNode struct {
+	items []exprNode
+}
+
+func (n listNode) eval(env *exprEnv) (any, error) {
+	if err := env.spend(1); err != nil {
+		return nil, err
+	}
+
+	res := make([]any, len(n.items))
+
+	for i, item := range n.items {
+		value, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+
+		res[i] = value
+	}
+
+	return res, nil
+}
+
+// indexNode is a field of a map or an element of a slice. Missing fields and
+// elements are null.
+type indexNode struct {
+	target exprNode
+	index  exprNode
+}
+
+func (n indexNode) eval(env *exprEnv) (any, error) {
+	target, err := n.target.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := n.index.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, ok := index.(string); ok {
+		return field(target, key), nil
+	}
+
+	i, ok := exprNumber(index)
+	if !ok || i != math.Trunc(i) {
+		return nil, fmt.Errorf("%w: %v", errInvalidIndex, index)
+	}
+
+	items, ok := elements(target)
+	if !ok || i < 0 || int(i) >= len(items) {
+		return nil, nil
+	}
+
+	return items[int(i)], nil
+}
+
+// unaryNode is a negation, "!" for booleans and "-" for numbers.
+type unaryNode struct {
+	op      string
+	operand exprNode
+}
+
+func (n unaryNode) eval(env *exprEnv) (any, error) {
+	value, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "!" {
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%w: %v", errNotBoolean, value)
+		}
+
+		return !b, nil
+	}
+
+	x, ok := exprNumber(value)
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", errNotNumber, value)
+	}
+
+	return -x, nil
+}
+
+// binaryNode is a binary operation.
+type binaryNode struct {
+	op          string
+	left, right exprNode
+}
+
+//nolint:cyclop
+func (n binaryNode) eval(env *exprEnv) (any, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// The logical operators evaluate their right operand only if needed.
+	if n.op == "&&" || n.op == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%w: %v", errNotBoolean, left)
+		}
+
+		if l == (n.op == "||") {
+			return l, nil
+		}
+
+		right, err := n.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+
+		r, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%w: %v", errNotBoolean, right)
+		}
+
+		return r, nil
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return exprEqual(env, left, right)
+	case "!=":
+		eq, err := exprEqual(env, left, right)
+
+		return !eq, err
+	case "<", "<=", ">", ">=":
+		return compareExpr(n.op, left, right)
+	case "in":
+		return contains(env, right, left)
+	case "+":
+		if l, ok := left.(string); ok {
+			if r, ok := right.(string); ok {
+				return l + r, env.spend(len(l) + len(r))
+			}
+		}
+	}
+
+	return arithmetic(n.op, left, right)
+}
+
+// compareExpr compares two strings lexicographically, or else two numbers.
+func compareExpr(op string, left, right any) (bool, error) {
+	var res int
+
+	l, okL := left.(string)
+	r, okR := right.(string)
+
+	if okL && okR {
+		res = strings.Compare(l, r)
+	} else {
+		x, okX := exprNumber(left)
+		y, okY := exprNumber(right)
+
+		if !okX || !okY {
+			return false, fmt.Errorf("%w: %v, %v", errNotComparable, left, right)
+		}
+
+		switch {
+		case math.IsNaN(x) || math.IsNaN(y):
+			return false, nil
+		case x < y:
+			res = -1
+		case x > y:
+			res = 1
+		}
+	}
+
+	switch op {
+	case "<":
+		return res < 0, nil
+	case "<=":
+		return res <= 0, nil
+	case ">":
+		return res > 0, nil
+	default:
+		return res >= 0, nil
+	}
+}
+
+// exprNumber converts a value of a numeric kind to a float64. Unlike
+// toNumber it rejects the numeric strings, which the expressions don't
+// convert silently.
+func exprNumber(value any) (float64, bool) {
+	if !isNumber(value) {
+		return 0, false
+	}
+
+	return toFloat(value), true
+}
+
+// arithmetic calculates an arithmetic operation on numbers.
+func arithmetic(op string, left, right any) (any, error) {
+	x, okX := exprNumber(left)
+	y, okY := exprNumber(right)
+
+	if !okX || !okY {
+		return nil, fmt.Errorf("%w: %v, %v", errNotNumber, left, right)
+	}
+
+	switch op {
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "*":
+		return x * y, nil
+	}
+
+	if y == 0 {
+		return nil, errDivisionByZero
+	}
+
+	if op == "/" {
+		return x / y, nil
+	}
+
+	return math.Mod(x, y), nil
+}
+
+// callNode is a call of a function.
+type callNode struct {
+	name string
+	fn   exprFunc
+	args []exprNode
+}
+
+func (n callNode) eval(env *exprEnv) (any, error) {
+	if err := env.spend(1); err != nil {
+		return nil, err
+	}
+
+	if n.fn.macro != nil {
+		return n.fn.macro(env, n.args)
+	}
+
+	args := make([]any, len(n.args))
+
+	for i, arg := range n.args {
+		value, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+
+		args[i] = value
+	}
+
+	res, err := n.fn.call(env, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+
+	return res, nil
+}
+
+// exprFunc is a function of the expressions. Functions get the values of
+// their arguments, macros evaluate their arguments themselves.
+type exprFunc struct {
+	minArgs, maxArgs int
+	call             func(env *exprEnv, args []any) (any, error)
+	macro            func(env *exprEnv, args []exprNode) (any, error)
+}
+
+// arity describes the number of arguments of the function.
+func (f exprFunc) arity() string {
+	switch {
+	case f.minArgs == f.maxArgs && f.minArgs == 1:
+		return "1 argument"
+	case f.minArgs == f.maxArgs:
+		return fmt.Sprintf("%d arguments", f.minArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", f.minArgs, f.maxArgs)
+	}
+}
+
+// exprFuncs holds the functions of the expressions keyed by their name.
+//
+//nolint:gochecknoglobals,mnd
+var exprFuncs = map[string]exprFunc{
+	"size":       {minArgs: 1, maxArgs: 1, call: exprSize},
+	"type":       {minArgs: 1, maxArgs: 1, call: exprType},
+	"lower":      {minArgs: 1, maxArgs: 1, call: stringFunc(strings.ToLower)},
+	"upper":      {minArgs: 1, maxArgs: 1, call: stringFunc(strings.ToUpper)},
+	"abs":        {minArgs: 1, maxArgs: 1, call: exprAbs},
+	"contains":   {minArgs: 2, maxArgs: 2, call: exprContains},
+	"startsWith": {minArgs: 2, maxArgs: 2, call: stringPredicate(strings.HasPrefix)},
+	"endsWith":   {minArgs: 2, maxArgs: 2, call: stringPredicate(strings.HasSuffix)},
+	"matches":    {minArgs: 2, maxArgs: 2, call: exprMatches},
+	"all":        {minArgs: 2, maxArgs: 2, macro: quantifier(func(n, total int) bool { return n == total }, false)},
+	"any":        {minArgs: 2, maxArgs: 2, macro: quantifier(func(n, _ int) bool { return n > 0 }, true)},
+	"count":      {minArgs: 2, maxArgs: 2, macro: exprCount},
+}
+
+// exprSize returns the length of a string in characters, of a slice or of a map.
+func exprSize(env *exprEnv, args []any) (any, error) {
+	if s, ok := args[0].(string); ok {
+		return float64(utf8.RuneCountInString(s)), env.spend(len(s))
+	}
+
+	v := reflect.ValueOf(args[0])
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), nil
+	default:
+		return nil, fmt.Errorf("%w: %v", errNoSize, args[0])
+	}
+}
+
+// exprType returns the JSON type of the value, "number" for integers too.
+func exprType(_ *exprEnv, args []any) (any, error) {
+	if t := jsonType(args[0]); t != "integer" {
+		return t, nil
+	}
+
+	return "number", nil
+}
+
+// exprAbs returns the absolute value of a number.
+func exprAbs(_ *exprEnv, args []any) (any, error) {
+	x, ok := exprNumber(args[0])
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", errNotNumber, args[0])
+	}
+
+	return math.Abs(x), nil
+}
+
+// exprMatches checks if the string matches the regular expression.
+func exprMatches(env *exprEnv, args []any) (any, error) {
+	s, okS := args[0].(string)
+	pattern, okP := args[1].(string)
+
+	if !okS || !okP {
+		return nil, fmt.Errorf("%w: %v, %v", errNotString, args[0], args[1])
+	}
+
+	if err := env.spend(len(s) + len(pattern)); err != nil {
+		return nil, err
+	}
+
+	c := exprRegexps.get(pattern, func(pattern string) compiledRegexp {
+		re, err := regexp.Compile(pattern)
+
+		return compiledRegexp{re: re, err: err}
+	})
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return c.re.MatchString(s), nil
+}
+
+// compiledRegexp is the result of the compilation of a regular expression.
+type compiledRegexp struct {
+	re  *regexp.Regexp
+	err error
+}
+
+// stringFunc returns a function transforming a string.
+func stringFunc(f func(string) string) func(env *exprEnv, args []any) (any, error) {
+	return func(env *exprEnv, args []any) (any, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %v", errNotString, args[0])
+		}
+
+		return f(s), env.spend(len(s))
+	}
+}
+
+// stringPredicate returns a function checking two strings.
+func stringPredicate(f func(s, t string) bool) func(env *exprEnv, args []any) (any, error) {
+	return func(env *exprEnv, args []any) (any, error) {
+		s, okS := args[0].(string)
+		t, okT := args[1].(string)
+
+		if !okS || !okT {
+			return nil, fmt.Errorf("%w: %v, %v", errNotString, args[0], args[1])
+		}
+
+		return f(s, t), env.spend(len(s))
+	}
+}
+
+// exprContains checks if the first argument contains the second one, see contains.
+func exprContains(env *exprEnv, args []any) (any, error) {
+	return contains(env, args[0], args[1])
+}
+
+// contains checks if the string contains the substring, or if the slice
+// contains the value.
+func contains(env *exprEnv, container, value any) (bool, error) {
+	if s, ok := container.(string); ok {
+		sub, ok := value.(string)
+		if !ok {
+			return false, fmt.Errorf("%w: %v", errNotString, value)
+		}
+
+		return strings.Contains(s, sub), env.spend(len(s))
+	}
+
+	items, ok := elements(container)
+	if !ok {
+		return false, fmt.Errorf("%w: %v", errNotList, container)
+	}
+
+	for _, item := range items {
+		if eq, err := exprEqual(env, item, value); eq || err != nil {
+			return eq, err
+		}
+	}
+
+	return false, nil
+}
+
+// exprEqual checks if two values are equal as JSON values like jsonEqual,
+// every value visited costing 1.
+func exprEqual(env *exprEnv, a, b any) (bool, error) {
+	if err := env.spend(1); err != nil {
+		return false, err
+	}
+
+	ta, tb := jsonType(a), jsonType(b)
+
+	switch {
+	case ta == "array" && tb == "array":
+		x, y := reflect.ValueOf(a), reflect.ValueOf(b)
+		if x.Len() != y.Len() {
+			return false, nil
+		}
+
+		for i := range x.Len() {
+			if eq, err := exprEqual(env, x.Index(i).Interface(), y.Index(i).Interface()); !eq || err != nil {
+				return false, err
+			}
+		}
+
+		return true, nil
+	case ta == "object" && tb == "object":
+		x, y := reflect.ValueOf(a), reflect.ValueOf(b)
+		if x.Len() != y.Len() {
+			return false, nil
+		}
+
+		for _, key := range x.MapKeys() {
+			other := mapIndexString(y, key.String())
+			if !other.IsValid() {
+				return false, nil
+			}
+
+			if eq, err := exprEqual(env, x.MapIndex(key).Interface(), other.Interface()); !eq || err != nil {
+				return false, err
+			}
+		}
+
+		return true, nil
+	default:
+		return jsonEqual(a, b), nil
+	}
+}
+
+// quantifier returns a macro checking if the number of elements for which
+// the predicate is true satisfies the check. The elements are visited until
+// the result is the one which stops the visit.
+func quantifier(check func(n, total int) bool, stop bool) func(env *exprEnv, args []exprNode) (any, error) {
+	return func(env *exprEnv, args []exprNode) (any, error) {
+		n, total, err := visit(env, args, stop)
+		if err != nil {
+			return nil, err
+		}
+
+		return check(n, total), nil
+	}
+}
+
+// exprCount returns the number of elements for which the predicate is true.
+func exprCount(env *exprEnv, args []exprNode) (any, error) {
+	n, _, err := visit(env, args, nil)
+
+	return float64(n), err
+}
+
+// visit evaluates the predicate, the second argument, for the elements of
+// the list, the first argument, with "it" bound to the element. It returns
+// the number of elements for which the predicate is true and the number of
+// elements. It stops at the first result equal to stop, if stop is a boolean.
+func visit(env *exprEnv, args []exprNode, stop any) (int, int, error) {
+	list, err := args[0].eval(env)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	items, ok := elements(list)
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: %v", errNotList, list)
+	}
+
+	env.items = append(env.items, nil)
+	defer func() { env.items = env.items[:len(env.items)-1] }()
+
+	n := 0
+
+	for _, item := range items {
+		env.items[len(env.items)-1] = item
+
+		res, err := args[1].eval(env)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		b, ok := res.(bool)
+		if !ok {
+			return 0, 0, fmt.Errorf("%w: %v", errNotBoolean, res)
+		}
+
+		if b {
+			n++
+		}
+
+		if b == stop {
+			break
+		}
+	}
+
+	return n, len(items), nil
+}
+
+// field returns the value of the key of a map with string keys, or nil.
+func field(value any, key string) any {
+	if m, ok := value.(map[string]any); ok {
+		return m[key]
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil
+	}
+
+	return valueOf(mapIndexString(v, key))
+}
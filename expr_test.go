@@ -0,0 +1,154 @@
+package deeply_test
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+func TestExpr(t *testing.T) {
+	actual := map[string]any{
+		"name":  "gripmock",
+		"items": []any{map[string]any{"qty": 2.0, "sku": "A-1"}, map[string]any{"qty": 12.0, "sku": "B-2"}, map[string]any{"qty": "7"}},
+		"limit": 10.0,
+	}
+
+	for _, tt := range []struct {
+		expr string
+		want bool
+	}{
+		{`size(items) > 2 && items[0].qty <= 10`, true},
+		{`size(items) > 3 || items[1].qty <= 10`, false},
+		{`items[2].qty == "7" && items[2].qty != 7 && items[1].qty > 11.5`, true},
+		{`items[5] == null && missing == null && items[0].missing == null`, true},
+		{`name == "gripmock" && startsWith(name, 'grip') && !endsWith(name, "x")`, true},
+		{`upper(name) == "GRIPMOCK" && lower("ABC") == "abc" && size("héllo") == 5`, true},
+		{`matches(name, "^g.*k$") && contains(name, "pm") && "A-1" in ["A-1", "B-2"]`, true},
+		{`all(items, it.qty == "7" || it.qty > 0) && any(items, it.sku == "B-2") && count(items, it.qty != "7" && it.qty > 5) == 1`, true},
+		{`all(items, it.qty < limit)`, false},
+		{`any(items, any(items, it.qty == 12))`, true},
+		{`(1 + 2) * 3 - 4 / 2 == 7 && 7 % 4 == 3 && -abs(-2) == -2 && "a" + "b" == "ab"`, true},
+		{`"abc" < "abd" && 2 >= 2 && !(1 > 2)`, true},
+		{`type(name) == "string" && type(items) == "array" && type(limit) == "number" && type(@) == "object"`, true},
+		{`@.name == $.name && @["name"] == "gripmock"`, true},
+		{`1 / 0 == 1`, false},
+		{`name > 1`, false},
+		{`'10' > 9 || '10' <= 9`, false},
+		{`'Inf' > 1e308`, false},
+		{`'1' + 2 == 3`, false},
+		{`-'1' == -1`, false},
+		{`abs('-1') == 1`, false},
+		{`items[2].qty > 5`, false},
+		{`size(1) == 1`, false},
+		{`name`, false},
+		{`!name`, false},
+		{`all(name, true)`, false},
+	} {
+		require.Equal(t, tt.want, deeply.Matches(map[string]any{"$expr": tt.expr}, actual), tt.expr)
+	}
+}
+
+func TestExpr_Nested(t *testing.T) {
+	expect := map[string]any{
+		"limit": 10.0,
+		"items": map[string]any{"$all": map[string]any{"$expr": "qty <= $.limit"}},
+	}
+
+	require.True(t, deeply.Matches(expect, map[string]any{"limit": 10.0, "items": []any{map[string]any{"qty": 10.0}}}))
+	require.False(t, deeply.Matches(expect, map[string]any{"limit": 10.0, "items": []any{map[string]any{"qty": 11.0}}}))
+
+	contains := map[string]any{"items": map[string]any{"$expr": "size(@) <= $.limit"}}
+	require.True(t, deeply.Contains(contains, map[string]any{"limit": 2, "items": []any{1, 2}, "other": true}))
+	require.False(t, deeply.Contains(contains, map[string]any{"limit": 1, "items": []any{1, 2}}))
+	require.True(t, deeply.EqualsIgnoreArrayOrder([]any{map[string]any{"$expr": "@ > $[0]"}, 1.0}, []any{1.0, 2.0}))
+
+	require.Zero(t, deeply.RankMatch(contains["items"], []any{1}), "the root is the ranked value")
+	require.Greater(t,
+		deeply.RankMatch(contains, map[string]any{"limit": 2, "items": []any{1, 2}}),
+		deeply.RankMatch(contains, map[string]any{"limit": 1, "items": []any{1, 2}}))
+
+	// The mismatches are explained within the document.
+	mismatches := deeply.Explain(contains, map[string]any{"limit": 1, "items": []any{1, 2}}, deeply.ModeContains)
+	require.Len(t, mismatches, 1)
+	require.Equal(t, "$.items", mismatches[0].Path)
+	require.Empty(t, deeply.Explain(contains, map[string]any{"limit": 2, "items": []any{1, 2}}, deeply.ModeContains))
+}
+
+func TestExpr_Cost(t *testing.T) {
+	items := make([]any, 200)
+	for i := range items {
+		items[i] = float64(i)
+	}
+
+	require.True(t, deeply.Matches(map[string]any{"$expr": "all(@, it >= 0)"}, items))
+	require.False(t, deeply.Matches(map[string]any{"$expr": "all(@, all($, true))"}, items))
+	require.False(t, deeply.Matches(map[string]any{"$expr": "count(@, any(@, it < 0)) == 0"}, items))
+
+	// The comparisons cost the values they visit.
+	lists := make([]any, 3000)
+	for i := range lists {
+		lists[i] = items[:100]
+	}
+
+	require.True(t, deeply.Matches(map[string]any{"$expr": "@[0] == @[1] && @[0] in @"}, lists))
+	require.False(t, deeply.Matches(map[string]any{"$expr": "count($, $ == $) >= 0"}, lists))
+	require.False(t, deeply.Matches(map[string]any{"$expr": "count($, $ != $) == 0"}, lists))
+	require.False(t, deeply.Matches(map[string]any{"$expr": "count($, @ in [$]) >= 0"}, lists))
+}
+
+func TestExpr_Cache(t *testing.T) {
+	for i := range 1000 {
+		require.True(t, deeply.Matches(map[string]any{"$expr": fmt.Sprintf("@ == %d", i)}, i))
+	}
+
+	require.LessOrEqual(t, deeply.CompiledExpressions(), 256)
+
+	for i := range 1000 {
+		require.True(t, deeply.Matches(map[string]any{"$expr": fmt.Sprintf("matches(@, '^%d$')", i)}, strconv.Itoa(i)))
+	}
+
+	require.False(t, deeply.Matches(map[string]any{"$expr": "matches(@, '(')"}, "("))
+}
+
+func TestCheckExpressions(t *testing.T) {
+	require.NoError(t, deeply.CheckExpressions(map[string]any{
+		"a": map[string]any{"$expr": "size(items) > 2 && items[0].qty <= 10"},
+		"b": []any{map[string]any{"$expr": "any(@, it == 'x')"}},
+		"c": map[string]any{"$expr": 1, "other": "not an operator node"},
+	}))
+
+	for _, tt := range []struct {
+		expr string
+		err  string
+	}{
+		{`size(items > 2`, `at 14: expected ",", found end of expression`},
+		{`a &&`, `at 4: unexpected end of expression`},
+		{`a b`, `at 2: unexpected b`},
+		{`unknown(1)`, `at 0: unknown function unknown`},
+		{`size(1, 2)`, `at 0: size takes 1 argument, found 2`},
+		{`all(a)`, `at 0: all takes 2 arguments, found 1`},
+		{`"abc`, `at 0: unterminated string`},
+		{`a # b`, `at 2: unexpected character '#'`},
+		{`a.1`, `at 2: expected a field name, found 1`},
+		{`in`, `at 0: unexpected in`},
+		{`@ 'in' [1]`, `at 2: unexpected "in"`},
+		{strings.Repeat("(", 100) + "1" + strings.Repeat(")", 100), `nested deeper than 64`},
+		{strings.Repeat("a", 5000), `longer than 4096 bytes`},
+	} {
+		err := deeply.CheckExpressions(map[string]any{"x": []any{map[string]any{"$expr": tt.expr}}})
+		require.ErrorIs(t, err, deeply.ErrExpr, tt.expr)
+		require.ErrorContains(t, err, "$.x[0]: invalid expression", tt.expr)
+		require.ErrorContains(t, err, tt.err, tt.expr)
+
+		require.False(t, deeply.Matches(map[string]any{"$expr": tt.expr}, nil), tt.expr)
+	}
+
+	err := deeply.CheckExpressions(map[string]any{"a": map[string]any{"$expr": 1}, "b": map[string]any{"$expr": "("}})
+	require.ErrorContains(t, err, "$.a: invalid expression: not a string")
+	require.ErrorContains(t, err, "$.b: invalid expression")
+}
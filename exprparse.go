@@ -0,0 +1,455 @@
+package deeply
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// exprMaxLength is the length of the longest expression compiled by $expr.
+const exprMaxLength = 4096
+
+// exprMaxDepth is the deepest nesting of the expressions compiled by $expr.
+const exprMaxDepth = 64
+
+// tokenKind is the kind of a token of an expression.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenPunct
+)
+
+// token is a token of an expression.
+type token struct {
+	kind  tokenKind
+	text  string // The source of the token, or the value of a string.
+	pos   int    // The byte offset of the token in the expression.
+	value float64
+}
+
+// puncts holds the punctuation of expressions, the longest first.
+//
+//nolint:gochecknoglobals
+var puncts = []string{
+	"&&", "||", "==", "!=", "<=", ">=",
+	"(", ")", "[", "]", ".", ",", "!", "<", ">", "+", "-", "*", "/", "%", "@", "$",
+}
+
+// binaryPrecedence holds the precedence of the binary operators, the higher the tighter.
+//
+//nolint:gochecknoglobals
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4, "in": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+// unaryPrecedence is the precedence of the operands of the unary operators.
+const unaryPrecedence = 7
+
+// tokenize splits an expression into tokens.
+func tokenize(src string) ([]token, error) {
+	var res []token
+
+	for pos := 0; pos < len(src); {
+		r, size := utf8.DecodeRuneInString(src[pos:])
+
+		switch {
+		case unicode.IsSpace(r):
+			pos += size
+
+			continue
+		case r >= '0' && r <= '9':
+			end := scanNumber(src, pos)
+
+			value, err := strconv.ParseFloat(src[pos:end], 64)
+			if err != nil {
+				return nil, exprErrorf(pos, "invalid number %s", src[pos:end])
+			}
+
+			res = append(res, token{kind: tokenNumber, text: src[pos:end], pos: pos, value: value})
+			pos = end
+
+			continue
+		case r == '"' || r == '\'':
+			value, end, err := scanString(src, pos)
+			if err != nil {
+				return nil, err
+			}
+
+			res = append(res, token{kind: tokenString, text: value, pos: pos})
+			pos = end
+
+			continue
+		case r == '_' || unicode.IsLetter(r):
+			end := pos + size
+			for end < len(src) {
+				r, size := utf8.DecodeRuneInString(src[end:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+
+				end += size
+			}
+
+			res = append(res, token{kind: tokenIdent, text: src[pos:end], pos: pos})
+			pos = end
+
+			continue
+		}
+
+		punct := ""
+
+		for _, p := range puncts {
+			if strings.HasPrefix(src[pos:], p) {
+				punct = p
+
+				break
+			}
+		}
+
+		if punct == "" {
+			return nil, exprErrorf(pos, "unexpected character %q", r)
+		}
+
+		res = append(res, token{kind: tokenPunct, text: punct, pos: pos})
+		pos += len(punct)
+	}
+
+	return append(res, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+// scanNumber returns the end of the number starting at the position.
+func scanNumber(src string, pos int) int {
+	digits := func(pos int) int {
+		for pos < len(src) && src[pos] >= '0' && src[pos] <= '9' {
+			pos++
+		}
+
+		return pos
+	}
+
+	end := digits(pos)
+
+	if end+1 < len(src) && src[end] == '.' && src[end+1] >= '0' && src[end+1] <= '9' {
+		end = digits(end + 1)
+	}
+
+	if end < len(src) && (src[end] == 'e' || src[end] == 'E') {
+		exp := end + 1
+		if exp < len(src) && (src[exp] == '+' || src[exp] == '-') {
+			exp++
+		}
+
+		if exp < len(src) && src[exp] >= '0' && src[exp] <= '9' {
+			end = digits(exp)
+		}
+	}
+
+	return end
+}
+
+// scanString returns the value and the end of the quoted string starting at
+// the position. The escape sequences are those of Go.
+func scanString(src string, pos int) (string, int, error) {
+	quote := src[pos]
+
+	var sb strings.Builder
+
+	for s := src[pos+1:]; ; {
+		if s == "" || s[0] == '\n' {
+			return "", 0, exprErrorf(pos, "unterminated string")
+		}
+
+		if s[0] == quote {
+			return sb.String(), len(src) - len(s) + 1, nil
+		}
+
+		r, _, tail, err := strconv.UnquoteChar(s, quote)
+		if err != nil {
+			return "", 0, exprErrorf(len(src)-len(s), "invalid escape sequence")
+		}
+
+		sb.WriteRune(r)
+		s = tail
+	}
+}
+
+// exprParser parses the tokens of an expression.
+type exprParser struct {
+	tokens []token
+	next   int
+	depth  int
+}
+
+// compileExpr parses an expression and checks the names and the arguments
+// of its functions.
+func compileExpr(src string) (exprNode, error) {
+	if len(src) > exprMaxLength {
+		return nil, fmt.Errorf("%w: longer than %d bytes", ErrExpr, exprMaxLength)
+	}
+
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens}
+
+	res, err := p.parse(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, exprErrorf(t.pos, "unexpected %s", t.describe())
+	}
+
+	return res, nil
+}
+
+// peek returns the next token.
+func (p *exprParser) peek() token {
+	return p.tokens[p.next]
+}
+
+// advance returns the next token and moves past it.
+func (p *exprParser) advance() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+
+	return t
+}
+
+// accept moves past the next token if it is the punctuation.
+func (p *exprParser) accept(punct string) bool {
+	if t := p.peek(); t.kind == tokenPunct && t.text == punct {
+		p.next++
+
+		return true
+	}
+
+	return false
+}
+
+// expect moves past the next token, which must be the punctuation.
+func (p *exprParser) expect(punct string) error {
+	if !p.accept(punct) {
+		t := p.peek()
+
+		return exprErrorf(t.pos, "expected %q, found %s", punct, t.describe())
+	}
+
+	return nil
+}
+
+// parse parses the binary operations of operators of a higher precedence
+// than the minimum.
+func (p *exprParser) parse(minPrecedence int) (exprNode, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+
+	if p.depth > exprMaxDepth {
+		return nil, exprErrorf(p.peek().pos, "nested deeper than %d", exprMaxDepth)
+	}
+
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+
+		precedence, ok := binaryPrecedence[t.text]
+		operator := t.kind == tokenPunct || t.kind == tokenIdent && t.text == "in"
+		if !ok || !operator || precedence <= minPrecedence {
+			return left, nil
+		}
+
+		p.advance()
+
+		right, err := p.parse(precedence)
+		if err != nil {
+			return nil, err
+		}
+
+		left = binaryNode{op: t.text, left: left, right: right}
+	}
+}
+
+// unary parses the unary operations.
+func (p *exprParser) unary() (exprNode, error) {
+	t := p.peek()
+	if t.kind != tokenPunct || t.text != "!" && t.text != "-" {
+		return p.postfix()
+	}
+
+	p.advance()
+
+	operand, err := p.parse(unaryPrecedence)
+	if err != nil {
+		return nil, err
+	}
+
+	return unaryNode{op: t.text, operand: operand}, nil
+}
+
+// postfix parses the member accesses and the indexes of a primary expression.
+func (p *exprParser) postfix() (exprNode, error) {
+	res, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch {
+		case p.accept("."):
+			t := p.advance()
+			if t.kind != tokenIdent {
+				return nil, exprErrorf(t.pos, "expected a field name, found %s", t.describe())
+			}
+
+			res = indexNode{target: res, index: literalNode{value: t.text}}
+		case p.accept("["):
+			index, err := p.parse(0)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+
+			res = indexNode{target: res, index: index}
+		default:
+			return res, nil
+		}
+	}
+}
+
+// primary parses literals, names, function calls and parenthesized expressions.
+func (p *exprParser) primary() (exprNode, error) {
+	t := p.advance()
+
+	switch t.kind {
+	case tokenNumber:
+		return literalNode{value: t.value}, nil
+	case tokenString:
+		return literalNode{value: t.text}, nil
+	case tokenIdent:
+		return p.ident(t)
+	case tokenPunct:
+		switch t.text {
+		case "@":
+			return currentNode{}, nil
+		case "$":
+			return rootNode{}, nil
+		case "(":
+			res, err := p.parse(0)
+			if err != nil {
+				return nil, err
+			}
+
+			return res, p.expect(")")
+		case "[":
+			items, err := p.list("]")
+			if err != nil {
+				return nil, err
+			}
+
+			return listNode{items: items}, nil
+		}
+	case tokenEOF:
+	}
+
+	return nil, exprErrorf(t.pos, "unexpected %s", t.describe())
+}
+
+// ident parses a keyword, a name or a function call.
+func (p *exprParser) ident(t token) (exprNode, error) {
+	switch t.text {
+	case "true":
+		return literalNode{value: true}, nil
+	case "false":
+		return literalNode{value: false}, nil
+	case "null":
+		return literalNode{value: nil}, nil
+	case "in":
+		return nil, exprErrorf(t.pos, "unexpected in")
+	}
+
+	if !p.accept("(") {
+		return nameNode{name: t.text}, nil
+	}
+
+	args, err := p.list(")")
+	if err != nil {
+		return nil, err
+	}
+
+	fn, ok := exprFuncs[t.text]
+	if !ok {
+		return nil, exprErrorf(t.pos, "unknown function %s", t.text)
+	}
+
+	if len(args) < fn.minArgs || len(args) > fn.maxArgs {
+		return nil, exprErrorf(t.pos, "%s takes %s, found %d", t.text, fn.arity(), len(args))
+	}
+
+	return callNode{name: t.text, fn: fn, args: args}, nil
+}
+
+// list parses expressions separated by commas up to the closing punctuation.
+func (p *exprParser) list(closing string) ([]exprNode, error) {
+	var res []exprNode
+
+	if p.accept(closing) {
+		return res, nil
+	}
+
+	for {
+		item, err := p.parse(0)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, item)
+
+		if p.accept(closing) {
+			return res, nil
+		}
+
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// describe describes the token for the syntax errors.
+func (t token) describe() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return strconv.Quote(t.text)
+	default:
+		return t.text
+	}
+}
+
+// exprErrorf returns a syntax error at the position of the expression.
+func exprErrorf(pos int, format string, args ...any) error {
+	return fmt.Errorf("%w: at %d: %s", ErrExpr, pos, fmt.Sprintf(format, args...))
+}
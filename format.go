@@ -0,0 +1,231 @@
+package deeply
+
+import (
+	"encoding/base64"
+	"log"
+	"math/bits"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// format checks if a string is in a format. The argument is the part of the
+// $format operand after the colon, e.g. "10.0.0.0/8" for "cidr:10.0.0.0/8".
+// It returns a match score between 0 and 1, where 1 means the string is in the format.
+type format func(value, arg string) float64
+
+// formats holds the known formats of the $format operator keyed by their name.
+//
+//nolint:gochecknoglobals
+var formats = map[string]format{
+	"uuid":     formatUUID,
+	"email":    formatEmail,
+	"ipv4":     formatIPv4,
+	"ipv6":     formatIPv6,
+	"cidr":     formatCIDR,
+	"uri":      formatURI,
+	"semver":   formatSemver,
+	"base64":   formatBase64,
+	"hostname": formatHostname,
+}
+
+// matchFormat checks if the actual string is in the format of $format.
+func matchFormat(node map[string]any, actual any, _ cmp) bool {
+	return rankFormat(node, actual, nil) == 1
+}
+
+// rankFormat scores the actual string against the format of $format.
+// The operand is the name of the format optionally followed by a colon and
+// an argument, e.g. "uuid", "cidr:10.0.0.0/8" or "semver:>=1.2 <2".
+func rankFormat(node map[string]any, actual any, _ ranker) float64 {
+	operand, ok := node["$format"].(string)
+	if !ok {
+		log.Printf("Error on parsing $format operand %v: not a string\n", node["$format"])
+
+		return 0
+	}
+
+	name, arg, _ := strings.Cut(operand, ":")
+
+	check, ok := formats[name]
+	if !ok {
+		log.Printf("Error on parsing $format operand %s: unknown format\n", operand)
+
+		return 0
+	}
+
+	value, ok := actual.(string)
+	if !ok {
+		return 0
+	}
+
+	return check(value, arg)
+}
+
+// formatUUID checks if the value is a UUID in the canonical 8-4-4-4-12 form.
+func formatUUID(value, _ string) float64 {
+	const length = 36
+
+	if len(value) != length {
+		return 0
+	}
+
+	for i := range len(value) {
+		switch i {
+		case 8, 13, 18, 23: //nolint:mnd
+			if value[i] != '-' {
+				return 0
+			}
+		default:
+			if !isHex(value[i]) {
+				return 0
+			}
+		}
+	}
+
+	return 1
+}
+
+// isHex checks if a byte is a hexadecimal digit.
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// formatEmail checks if the value is a bare email address, without a display name.
+func formatEmail(value, _ string) float64 {
+	address, err := mail.ParseAddress(value)
+
+	return matchScore(err == nil && address.Address == value && address.Name == "")
+}
+
+// formatIPv4 checks if the value is an IPv4 address.
+func formatIPv4(value, _ string) float64 {
+	addr, err := netip.ParseAddr(value)
+
+	return matchScore(err == nil && addr.Is4())
+}
+
+// formatIPv6 checks if the value is an IPv6 address.
+func formatIPv6(value, _ string) float64 {
+	addr, err := netip.ParseAddr(value)
+
+	return matchScore(err == nil && addr.Is6())
+}
+
+// formatCIDR checks if the value is a network in CIDR notation or, if the
+// argument is a network, an address inside of it. Addresses outside of the
+// network score by the number of leading bits they share with it.
+func formatCIDR(value, arg string) float64 {
+	if arg == "" {
+		_, err := netip.ParsePrefix(value)
+
+		return matchScore(err == nil)
+	}
+
+	prefix, err := netip.ParsePrefix(arg)
+	if err != nil {
+		log.Printf("Error on parsing $format cidr network %s error:%v\n", arg, err)
+
+		return 0
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil || addr.BitLen() != prefix.Addr().BitLen() {
+		return 0
+	}
+
+	if prefix.Contains(addr) {
+		return 1
+	}
+
+	// Count the leading bits shared by the address and the network.
+	common := 0
+
+	for i, b := range addr.AsSlice() {
+		diff := bits.LeadingZeros8(b ^ prefix.Masked().Addr().AsSlice()[i])
+		if common += diff; diff < 8 { //nolint:mnd
+			break
+		}
+	}
+
+	return float64(common) / float64(prefix.Bits()) / 2 //nolint:mnd
+}
+
+// formatURI checks if the value is an absolute URI.
+func formatURI(value, _ string) float64 {
+	u, err := url.Parse(value)
+
+	return matchScore(err == nil && u.Scheme != "")
+}
+
+// formatBase64 checks if the value is base64 encoded, using either the
+// standard or the URL alphabet, with or without padding.
+func formatBase64(value, _ string) float64 {
+	for _, encoding := range []*base64.Encoding{
+		base64.StdEncoding,
+		base64.URLEncoding,
+		base64.RawStdEncoding,
+		base64.RawURLEncoding,
+	} {
+		if _, err := encoding.Strict().DecodeString(value); err == nil {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// formatHostname checks if the value is a hostname as defined by RFC 1123.
+func formatHostname(value, _ string) float64 {
+	const (
+		maxLength      = 253
+		maxLabelLength = 63
+	)
+
+	value = strings.TrimSuffix(value, ".")
+	if value == "" || len(value) > maxLength {
+		return 0
+	}
+
+	for label := range strings.SplitSeq(value, ".") {
+		if label == "" || len(label) > maxLabelLength || label[0] == '-' || label[len(label)-1] == '-' {
+			return 0
+		}
+
+		for i := range len(label) {
+			c := label[i]
+			if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-') {
+				return 0
+			}
+		}
+	}
+
+	return 1
+}
+
+// formatSemver checks if the value is a semantic version and, if the argument
+// is a constraint, satisfies it. Versions outside of the constraint score 0.5.
+func formatSemver(value, arg string) float64 {
+	version, ok := parseSemver(value)
+	if !ok {
+		return 0
+	}
+
+	if arg == "" {
+		return 1
+	}
+
+	constraint, ok := parseSemverConstraint(arg)
+	if !ok {
+		log.Printf("Error on parsing $format semver constraint %s\n", arg)
+
+		return 0
+	}
+
+	if constraint.check(version) {
+		return 1
+	}
+
+	return 0.5 //nolint:mnd
+}
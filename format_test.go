@@ -0,0 +1,153 @@
+package deeply_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+func format(name string) map[string]any {
+	return map[string]any{"$format": name}
+}
+
+func TestFormat_Simple(t *testing.T) {
+	cases := []struct {
+		format string
+		valid  []any
+		broken []any
+	}{
+		{
+			format: "uuid",
+			valid:  []any{"123e4567-e89b-12d3-a456-426614174000", "123E4567-E89B-12D3-A456-426614174000"},
+			broken: []any{"123e4567e89b12d3a456426614174000", "123e4567-e89b-12d3-a456-42661417400g", 1},
+		},
+		{
+			format: "email",
+			valid:  []any{"user@example.com", "first.last+tag@sub.example.org"},
+			broken: []any{"user", "User <user@example.com>", "user@", ""},
+		},
+		{
+			format: "ipv4",
+			valid:  []any{"127.0.0.1", "10.20.30.40"},
+			broken: []any{"::1", "256.0.0.1", "10.0.0.0/8", "localhost"},
+		},
+		{
+			format: "ipv6",
+			valid:  []any{"::1", "2001:db8::ff00:42:8329", "fe80::1%eth0"},
+			broken: []any{"127.0.0.1", "2001:db8::g"},
+		},
+		{
+			format: "cidr",
+			valid:  []any{"10.0.0.0/8", "2001:db8::/32"},
+			broken: []any{"10.0.0.1", "10.0.0.0/33"},
+		},
+		{
+			format: "cidr:10.0.0.0/8",
+			valid:  []any{"10.1.2.3", "10.255.255.255"},
+			broken: []any{"11.0.0.1", "::1", "10.0.0.0/8"},
+		},
+		{
+			format: "uri",
+			valid:  []any{"https://example.com/path?q=1", "urn:isbn:0451450523", "mailto:user@example.com"},
+			broken: []any{"/relative/path", "example.com", "://missing"},
+		},
+		{
+			format: "base64",
+			valid:  []any{"aGVsbG8=", "aGVsbG8", "-_-_", ""},
+			broken: []any{"aGVsbG8=!", "a"},
+		},
+		{
+			format: "hostname",
+			valid:  []any{"example.com", "localhost", "a-b.c-d.example.", "xn--80ak6aa92e.com"},
+			broken: []any{"-example.com", "example-.com", "exa_mple.com", "a..b", ""},
+		},
+		{
+			format: "semver",
+			valid:  []any{"1.2.3", "0.0.0", "1.0.0-alpha.1", "1.0.0+build.5", "1.0.0-rc-1+build"},
+			broken: []any{"1.2", "v1.2.3", "01.2.3", "1.2.3-", "1.2.3-a..b"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.format, func(t *testing.T) {
+			for _, v := range c.valid {
+				require.True(t, deeply.Matches(format(c.format), v), v)
+				require.True(t, deeply.Contains(format(c.format), v), v)
+				require.InDelta(t, 1., deeply.RankMatch(format(c.format), v), 1e-9, v)
+			}
+
+			for _, v := range c.broken {
+				require.False(t, deeply.Matches(format(c.format), v), v)
+				require.False(t, deeply.Contains(format(c.format), v), v)
+				require.Less(t, deeply.RankMatch(format(c.format), v), 1., v)
+			}
+		})
+	}
+}
+
+func TestFormat_Semver(t *testing.T) {
+	cases := []struct {
+		constraint string
+		valid      []string
+		broken     []string
+	}{
+		{">=1.2 <2", []string{"1.2.0", "1.9.9"}, []string{"1.1.9", "2.0.0", "2.0.0-alpha"}},
+		{"^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"1.2.2", "2.0.0"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"~1.2", []string{"1.2.0", "1.2.9"}, []string{"1.3.0"}},
+		{"1.2", []string{"1.2.0", "1.2.7"}, []string{"1.3.0", "1.1.0"}},
+		{">1.2", []string{"1.3.0"}, []string{"1.2.9"}},
+		{"<=1.2", []string{"1.2.9"}, []string{"1.3.0"}},
+		{"<1.0.0 || >=3", []string{"0.9.0", "3.1.0"}, []string{"1.0.0", "2.5.0"}},
+		{"!=1.0.0,>=1", []string{"1.0.1"}, []string{"1.0.0", "0.1.0"}},
+		{">=1.0.0-beta.2", []string{"1.0.0-beta.11", "1.0.0-rc.1", "1.0.0"}, []string{"1.0.0-beta.1", "1.0.0-alpha"}},
+		{">= 1.2 < 2 || ^ 3.1", []string{"1.2.0", "1.9.9", "3.2.0"}, []string{"1.1.9", "2.0.0", "4.0.0"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.constraint, func(t *testing.T) {
+			for _, v := range c.valid {
+				require.True(t, deeply.Matches(format("semver:"+c.constraint), v), v)
+			}
+
+			for _, v := range c.broken {
+				require.False(t, deeply.Matches(format("semver:"+c.constraint), v), v)
+				require.InDelta(t, .5, deeply.RankMatch(format("semver:"+c.constraint), v), 1e-9, v)
+			}
+		})
+	}
+
+	require.False(t, deeply.Matches(format("semver:>=x"), "1.0.0"))
+	require.False(t, deeply.Matches(format("semver:||"), "1.0.0"))
+	require.False(t, deeply.Matches(format("semver:>=1 <"), "1.0.0"), "an operator without a version")
+}
+
+func TestFormat_Nested(t *testing.T) {
+	expect := map[string]any{
+		"id":     format("uuid"),
+		"client": map[string]any{"ip": format("cidr:192.168.0.0/16")},
+	}
+
+	require.True(t, deeply.Contains(expect, map[string]any{
+		"id":     "123e4567-e89b-12d3-a456-426614174000",
+		"client": map[string]any{"ip": "192.168.1.10", "port": 443},
+	}))
+	require.False(t, deeply.Contains(expect, map[string]any{
+		"id":     "123e4567-e89b-12d3-a456-426614174000",
+		"client": map[string]any{"ip": "10.0.0.1", "port": 443},
+	}))
+
+	require.False(t, deeply.Matches(format("unknown"), "value"))
+	require.False(t, deeply.Matches(map[string]any{"$format": 1}, "value"))
+}
+
+func TestFormat_RankMatch(t *testing.T) {
+	cidr := format("cidr:10.0.0.0/8")
+
+	require.Equal(t,
+		[]any{"10.0.0.1", "11.0.0.1", "12.0.0.1", "200.0.0.1"},
+		ranker(cidr, []any{"200.0.0.1", "10.0.0.1", "12.0.0.1", "11.0.0.1"}))
+}
@@ -0,0 +1,313 @@
+package deeply_test
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+// treeReader builds values from the bytes of a fuzz input.
+// It returns zeros once the input is exhausted, so every input builds a value.
+type treeReader struct {
+	data []byte
+}
+
+// next returns the next byte of the input.
+func (r *treeReader) next() int {
+	if len(r.data) == 0 {
+		return 0
+	}
+
+	b := r.data[0]
+	r.data = r.data[1:]
+
+	return int(b)
+}
+
+// maxTreeDepth is the maximum nesting of the generated maps and slices.
+const maxTreeDepth = 3
+
+// tree builds a nested map[string]any/[]any tree with regex-free leaves.
+// Keys, strings and numbers come from small sets, so that generated trees
+// often share keys and values.
+func (r *treeReader) tree(depth int) any {
+	kind := r.next() % 8 //nolint:mnd
+	if depth >= maxTreeDepth {
+		kind %= 4
+	}
+
+	switch kind {
+	case 0:
+		return nil
+	case 1:
+		return r.next()%2 == 1
+	case 2: //nolint:mnd
+		return float64(r.next()%5 - 2) //nolint:mnd
+	case 3: //nolint:mnd
+		return r.word()
+	case 4, 5: //nolint:mnd
+		res := make([]any, r.next()%4) //nolint:mnd
+		for i := range res {
+			res[i] = r.tree(depth + 1)
+		}
+
+		return res
+	default:
+		res := make(map[string]any)
+		for range r.next() % 4 { //nolint:mnd
+			res[r.key()] = r.tree(depth + 1)
+		}
+
+		return res
+	}
+}
+
+// operatorKeys are the keys starting with "$" of the generated maps: a few
+// operators comparing their operands in the mode, so that they don't depend
+// on the order of the slices either, and a key which isn't an operator.
+//
+//nolint:gochecknoglobals
+var operatorKeys = []string{"$any", "$all", "$size", "$value", "$x"}
+
+// key returns a key of a map: a word prefixed with "k", or sometimes a key
+// starting with "$", so that some maps are operator nodes.
+func (r *treeReader) key() string {
+	if b := r.next(); b%4 == 3 { //nolint:mnd
+		return operatorKeys[b/4%len(operatorKeys)]
+	}
+
+	return "k" + r.word()
+}
+
+// hasOperatorKeys checks if the value contains a key starting with "$".
+func hasOperatorKeys(value any) bool {
+	switch v := value.(type) {
+	case []any:
+		return slices.ContainsFunc(v, hasOperatorKeys)
+	case map[string]any:
+		for key, item := range v {
+			if strings.HasPrefix(key, "$") || hasOperatorKeys(item) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// word returns a short string of the letters a, b and c.
+func (r *treeReader) word() string {
+	b := make([]byte, r.next()%3) //nolint:mnd
+	for i := range b {
+		b[i] = byte('a' + r.next()%3) //nolint:mnd
+	}
+
+	return string(b)
+}
+
+// mutate returns a copy of the value with some of its parts dropped, added,
+// replaced or reordered, so that it is close to the original.
+func (r *treeReader) mutate(value any, depth int) any {
+	switch op := r.next() % 8; { //nolint:mnd
+	case op == 0:
+		return r.tree(depth)
+	case op < 4: //nolint:mnd
+		return value
+	}
+
+	switch v := value.(type) {
+	case []any:
+		res := make([]any, 0, len(v)+1)
+		for _, item := range v {
+			res = append(res, r.mutate(item, depth+1))
+		}
+
+		switch r.next() % 4 { //nolint:mnd
+		case 0:
+			res = append(res, r.tree(depth+1))
+		case 1:
+			if len(res) > 0 {
+				res = res[:len(res)-1]
+			}
+		case 2: //nolint:mnd
+			if len(res) > 1 {
+				i := r.next() % (len(res) - 1)
+				res[i], res[i+1] = res[i+1], res[i]
+			}
+		}
+
+		return res
+	case map[string]any:
+		res := make(map[string]any, len(v)+1)
+
+		for _, key := range slices.Sorted(func(yield func(string) bool) {
+			for key := range v {
+				if !yield(key) {
+					return
+				}
+			}
+		}) {
+			if r.next()%5 != 0 { //nolint:mnd
+				res[key] = r.mutate(v[key], depth+1)
+			}
+		}
+
+		if r.next()%3 == 0 { //nolint:mnd
+			res[r.key()] = r.tree(depth + 1)
+		}
+
+		return res
+	default:
+		return r.tree(depth)
+	}
+}
+
+// reverseAll returns a copy of the value with all its slices reversed.
+func reverseAll(value any) any {
+	switch v := value.(type) {
+	case []any:
+		res := make([]any, len(v))
+		for i, item := range v {
+			res[len(v)-1-i] = reverseAll(item)
+		}
+
+		return res
+	case map[string]any:
+		res := make(map[string]any, len(v))
+		for key, item := range v {
+			res[key] = reverseAll(item)
+		}
+
+		return res
+	default:
+		return value
+	}
+}
+
+// checkReflexive checks that a regex-free value matches itself in every mode.
+func checkReflexive(t *testing.T, x any) {
+	t.Helper()
+
+	require.True(t, deeply.Equals(x, x), "Equals(x, x): %#v", x)
+	require.True(t, deeply.EqualsIgnoreArrayOrder(x, x), "EqualsIgnoreArrayOrder(x, x): %#v", x)
+	require.True(t, deeply.Contains(x, x), "Contains(x, x): %#v", x)
+	require.True(t, deeply.ContainsIgnoreArrayOrder(x, x), "ContainsIgnoreArrayOrder(x, x): %#v", x)
+	require.True(t, deeply.Matches(x, x), "Matches(x, x): %#v", x)
+	require.True(t, deeply.MatchesIgnoreArrayOrder(x, x), "MatchesIgnoreArrayOrder(x, x): %#v", x)
+}
+
+// checkImplications checks the relations between the modes for a pair of values.
+func checkImplications(t *testing.T, x, y any) {
+	t.Helper()
+
+	implies := func(a, b bool, name string) {
+		t.Helper()
+
+		if a {
+			require.True(t, b, "%s: %#v, %#v", name, x, y)
+		}
+	}
+
+	equals, equalsIgnoreOrder := deeply.Equals(x, y), deeply.EqualsIgnoreArrayOrder(x, y)
+	contains, containsIgnoreOrder := deeply.Contains(x, y), deeply.ContainsIgnoreArrayOrder(x, y)
+	matches, matchesIgnoreOrder := deeply.Matches(x, y), deeply.MatchesIgnoreArrayOrder(x, y)
+
+	// The modes ignoring the order don't depend on the order.
+	ry := reverseAll(y)
+	require.Equal(t, equalsIgnoreOrder, deeply.EqualsIgnoreArrayOrder(x, ry), "EqualsIgnoreArrayOrder order: %#v, %#v", x, y)
+	require.Equal(t, containsIgnoreOrder, deeply.ContainsIgnoreArrayOrder(x, ry), "ContainsIgnoreArrayOrder order: %#v, %#v", x, y)
+	require.Equal(t, matchesIgnoreOrder, deeply.MatchesIgnoreArrayOrder(x, ry), "MatchesIgnoreArrayOrder order: %#v, %#v", x, y)
+
+	// The operators check the values of the other side whatever they are,
+	// the relations below hold for plain values only.
+	if hasOperatorKeys(x) || hasOperatorKeys(y) {
+		return
+	}
+
+	implies(equals, contains, "Equals => Contains")
+	implies(equals, equalsIgnoreOrder, "Equals => EqualsIgnoreArrayOrder")
+	implies(contains, containsIgnoreOrder, "Contains => ContainsIgnoreArrayOrder")
+	implies(equalsIgnoreOrder, containsIgnoreOrder, "EqualsIgnoreArrayOrder => ContainsIgnoreArrayOrder")
+	implies(contains, matches, "Contains => Matches")
+	implies(matches, matchesIgnoreOrder, "Matches => MatchesIgnoreArrayOrder")
+	implies(containsIgnoreOrder, matchesIgnoreOrder, "ContainsIgnoreArrayOrder => MatchesIgnoreArrayOrder")
+
+	// Equality is symmetric.
+	require.Equal(t, equals, deeply.Equals(y, x), "Equals symmetry: %#v, %#v", x, y)
+	require.Equal(t, equalsIgnoreOrder, deeply.EqualsIgnoreArrayOrder(y, x), "EqualsIgnoreArrayOrder symmetry: %#v, %#v", x, y)
+
+	// A value scores the most against itself, up to the order of the additions.
+	require.GreaterOrEqual(t, deeply.RankMatch(x, x)+1e-9, deeply.RankMatch(x, y), "RankMatch maximum: %#v, %#v", x, y)
+}
+
+// checkDistance checks that the distance is symmetric and in the range [0, 1].
+func checkDistance(t *testing.T, s, u string) {
+	t.Helper()
+
+	d := deeply.Distance(s, u)
+
+	require.InDelta(t, d, deeply.Distance(u, s), 1e-9, "symmetry: %q, %q", s, u)
+	require.GreaterOrEqual(t, d, 0.0, "range: %q, %q", s, u)
+	require.LessOrEqual(t, d, 1.0, "range: %q, %q", s, u)
+	require.Equal(t, s == u, d == 1, "identity: %q, %q", s, u)
+}
+
+func FuzzMatchers(f *testing.F) {
+	f.Add([]byte{}, []byte{})
+	f.Add([]byte{7, 2, 3, 1, 4, 3, 0, 6, 1, 2}, []byte{5, 5, 5, 1})
+	f.Add([]byte{4, 3, 3, 1, 1, 3, 1, 1, 3, 1, 2}, []byte{5, 2, 1})
+	f.Add([]byte{4, 2, 3, 1, 0, 3, 1, 1}, []byte{7, 7, 2, 0})
+	f.Add([]byte{6, 1, 15, 4, 2, 0, 1, 0}, []byte{4, 6, 3})
+	f.Add([]byte{4, 2, 6, 1, 3, 3, 2, 1, 0}, []byte{})
+
+	f.Fuzz(func(t *testing.T, data, mutation []byte) {
+		x := (&treeReader{data: data}).tree(0)
+		y := (&treeReader{data: mutation}).mutate(x, 0)
+
+		checkReflexive(t, x)
+		checkImplications(t, x, y)
+	})
+}
+
+func FuzzDistance(f *testing.F) {
+	f.Add("", "")
+	f.Add("kitten", "sitting")
+	f.Add("abc", "abd")
+	f.Add("héllo", "hello")
+	f.Add("a", "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")
+
+	f.Fuzz(checkDistance)
+}
+
+func TestMatchers_Properties(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2)) //nolint:gosec
+
+	random := func() []byte {
+		b := make([]byte, 64) //nolint:mnd
+		for i := range b {
+			b[i] = byte(rng.UintN(256)) //nolint:mnd
+		}
+
+		return b
+	}
+
+	for range 2000 {
+		x := (&treeReader{data: random()}).tree(0)
+		y := (&treeReader{data: random()}).mutate(x, 0)
+
+		checkReflexive(t, x)
+		checkImplications(t, x, y)
+	}
+
+	words := []string{"", "a", "ab", "ba", "abc", "kitten", "sitting", "héllo", "hello", "日本語", "日本"}
+	for _, s := range words {
+		for _, u := range words {
+			checkDistance(t, s, u)
+		}
+	}
+}
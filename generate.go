@@ -0,0 +1,857 @@
+package deeply
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"net/netip"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cast"
+)
+
+// ErrGenerate is returned by Generate if it can't find a value matching the expectation.
+var ErrGenerate = errors.New("can't generate a matching value")
+
+// generateAttempts is the number of candidates tried for an expectation.
+const generateAttempts = 32
+
+// generateMaxLength is the longest string and the longest array required
+// by a schema which Generate builds.
+const generateMaxLength = 1 << 16
+
+// GenerateOption configures Generate.
+type GenerateOption func(*generator)
+
+// WithSeed sets the seed of the random choices of Generate. Generate returns
+// the same value for the same expectation, mode and seed. The default seed is 0.
+func WithSeed(seed uint64) GenerateOption {
+	return func(g *generator) {
+		g.rng = rand.New(rand.NewPCG(seed, 0)) //nolint:gosec
+	}
+}
+
+// generator builds values matching expectations.
+type generator struct {
+	rng  *rand.Rand
+	mode Mode
+}
+
+// Generate returns a value which matches the expectation in the mode, e.g.
+// an example payload for a stub.
+//
+// Maps get the expected keys only, regular expressions of Matches are
+// turned into strings by walking their syntax trees and operator nodes get
+// values satisfying their operators. Other values are returned as is.
+// Every candidate is checked with the matching function of the mode, so a
+// returned value always matches; ErrGenerate is returned if no candidate did.
+func Generate(expect any, mode Mode, opts ...GenerateOption) (any, error) {
+	g := &generator{rng: rand.New(rand.NewPCG(0, 0)), mode: mode} //nolint:gosec
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	value, err := g.generate(expect)
+	if err != nil {
+		return nil, err
+	}
+
+	// The leaves are checked as they are generated, the whole value once.
+	if !g.mode.Match(expect, value) {
+		return nil, fmt.Errorf("%w for %s", ErrGenerate, formatJSON(expect))
+	}
+
+	return value, nil
+}
+
+// generate returns a value matching the expectation. Maps and slices are
+// built once from the values of their elements, the other expectations are
+// tried until a candidate matches. Errors end the generation at once, so
+// that a nested expectation which can't be satisfied doesn't make its
+// parents retry.
+func (g *generator) generate(expect any) (any, error) {
+	if g.composite(expect) {
+		return g.candidate(expect)
+	}
+
+	for range generateAttempts {
+		value, err := g.candidate(expect)
+		if err != nil {
+			return nil, err
+		}
+
+		if g.mode.Match(expect, value) {
+			return value, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w for %s", ErrGenerate, formatJSON(expect))
+}
+
+// composite checks if the expectation is a map or a slice whose value is
+// built from the values of its elements.
+func (g *generator) composite(expect any) bool {
+	if _, ok := asOperatorNode(expect); ok {
+		return false
+	}
+
+	switch expect.(type) {
+	case map[string]any:
+		return true
+	case []any:
+		return g.mode.orderedSlices() || g.mode.ignoreArrayOrder()
+	default:
+		return false
+	}
+}
+
+// candidate returns a value which is likely to match the expectation.
+func (g *generator) candidate(expect any) (any, error) {
+	if node, ok := asOperatorNode(expect); ok {
+		return g.operator(node)
+	}
+
+	switch v := expect.(type) {
+	case string:
+		if g.mode != ModeMatches && g.mode != ModeMatchesIgnoreArrayOrder {
+			return v, nil
+		}
+
+		if s, ok := g.regex(v); ok {
+			return s, nil
+		}
+
+		return v, nil
+	case map[string]any:
+		res := make(map[string]any, len(v))
+
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			value, err := g.generate(v[key])
+			if err != nil {
+				return nil, err
+			}
+
+			res[key] = value
+		}
+
+		return res, nil
+	case []any:
+		// Contains compares ordered slices as a whole.
+		if !g.mode.orderedSlices() && !g.mode.ignoreArrayOrder() {
+			return v, nil
+		}
+
+		return g.generateAll(v)
+	default:
+		return v, nil
+	}
+}
+
+// generateAll generates a value for each expectation.
+func (g *generator) generateAll(expect []any) ([]any, error) {
+	res := make([]any, 0, len(expect))
+
+	for _, item := range expect {
+		value, err := g.generate(item)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, value)
+	}
+
+	return res, nil
+}
+
+// operator returns a candidate for an operator node. The operators of a
+// group are generated together, so that e.g. the bounds of
+// {"$gt": 1, "$lt": 5} are satisfied at once. Nodes mixing groups get a
+// candidate of a random group, the others are left to the check.
+func (g *generator) operator(node operatorNode) (any, error) {
+	switch name := node.names[g.rng.IntN(len(node.names))]; name {
+	case "$gt", "$gte", "$lt", "$lte", "$eq", "$ne":
+		return g.comparison(node.args)
+	case "$before", "$after", "$within", "$sameDay":
+		return g.time(node.args)
+	case "$any", "$all", "$none", "$count", "$size", "$subsequence", "$subslice", "$prefix", "$suffix":
+		return g.array(node.args)
+	case "$format":
+		return g.format(node.args)
+	case "$jsonSchema":
+		return g.schema(node.args)
+	case "$approx":
+		if n, ok := toNumber(node.args["$approx"]); ok {
+			return n, nil
+		}
+
+		return nil, fmt.Errorf("%w: $approx is not a number", ErrGenerate)
+	case "$value":
+		return g.generate(node.args["$value"])
+	default:
+		return nil, fmt.Errorf("%w: unsupported operator %s", ErrGenerate, name)
+	}
+}
+
+// comparison returns a number or a string satisfying the comparison operators.
+func (g *generator) comparison(node map[string]any) (any, error) {
+	if v, ok := node["$eq"]; ok {
+		return v, nil
+	}
+
+	var (
+		lo, hi         = math.Inf(-1), math.Inf(1)
+		loOpen, hiOpen bool
+		strLo, strHi   *string
+	)
+
+	for _, name := range []string{"$gt", "$gte", "$lt", "$lte"} {
+		operand, ok := node[name]
+		if !ok {
+			continue
+		}
+
+		lower := name == "$gt" || name == "$gte"
+
+		if n, ok := toNumber(operand); ok {
+			if lower && n >= lo {
+				lo, loOpen = n, name == "$gt"
+			} else if !lower && n <= hi {
+				hi, hiOpen = n, name == "$lt"
+			}
+
+			continue
+		}
+
+		if s, ok := operand.(string); ok {
+			if lower {
+				strLo = &s
+			} else {
+				strHi = &s
+			}
+		}
+	}
+
+	switch {
+	case strLo != nil && node["$gt"] != nil:
+		return *strLo + g.word(), nil
+	case strLo != nil:
+		return *strLo, nil
+	case strHi != nil && node["$lt"] != nil:
+		return strings.TrimSuffix(*strHi, (*strHi)[max(len(*strHi)-1, 0):]), nil
+	case strHi != nil:
+		return *strHi, nil
+	case math.IsInf(lo, -1) && math.IsInf(hi, 1):
+		if _, ok := node["$ne"].(string); ok {
+			return g.word(), nil
+		}
+
+		return float64(g.rng.IntN(100)), nil //nolint:mnd
+	}
+
+	return g.number(lo, loOpen, hi, hiOpen, false)
+}
+
+// number returns a number between the bounds, an integer if possible.
+// Infinite bounds are unbounded.
+func (g *generator) number(lo float64, loOpen bool, hi float64, hiOpen bool, integer bool) (float64, error) {
+	const spread = 10
+
+	inside := func(n float64) bool {
+		return (n > lo || !loOpen && n == lo) && (n < hi || !hiOpen && n == hi)
+	}
+
+	// Inverted bounds, NaN and empty open intervals leave no number.
+	if !(lo <= hi) || lo == hi && (loOpen || hiOpen) {
+		return 0, fmt.Errorf("%w: no number between %v and %v", ErrGenerate, lo, hi)
+	}
+
+	var n float64
+
+	switch {
+	case math.IsInf(lo, -1) && math.IsInf(hi, 1):
+		n = float64(g.rng.IntN(spread))
+	case math.IsInf(hi, 1):
+		n = math.Floor(lo) + float64(1+g.rng.IntN(spread))
+	case math.IsInf(lo, -1):
+		n = math.Ceil(hi) - float64(1+g.rng.IntN(spread))
+	default:
+		n = math.Ceil(lo) + float64(g.rng.IntN(int(min(hi-lo, spread))+1))
+		if !inside(n) {
+			n = math.Ceil(lo)
+		}
+
+		if !inside(n) && !integer {
+			n = lo + (hi-lo)/2 //nolint:mnd
+		}
+	}
+
+	if !inside(n) {
+		return 0, fmt.Errorf("%w: no number between %v and %v", ErrGenerate, lo, hi)
+	}
+
+	return n, nil
+}
+
+// time returns a time satisfying the time operators, formatted as RFC 3339.
+func (g *generator) time(node map[string]any) (any, error) {
+	var lo, hi *time.Time
+
+	narrow := func(from, to time.Time) {
+		if lo == nil || from.After(*lo) {
+			lo = &from
+		}
+
+		if hi == nil || to.Before(*hi) {
+			hi = &to
+		}
+	}
+
+	if t, ok := operandTime(node["$after"]); ok {
+		narrow(t, t.AddDate(1000, 0, 0)) //nolint:mnd
+	}
+
+	if t, ok := operandTime(node["$before"]); ok {
+		narrow(t.AddDate(-1000, 0, 0), t) //nolint:mnd
+	}
+
+	if _, ok := node["$within"]; ok {
+		now := currentTime()
+		// Any time within the window scores 1, so the current time is within any window.
+		narrow(now, now)
+	}
+
+	if day, ok := operandTime(node["$sameDay"]); ok {
+		start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+		narrow(start, start.AddDate(0, 0, 1).Add(-time.Nanosecond))
+	}
+
+	if lo == nil || hi.Before(*lo) {
+		return nil, fmt.Errorf("%w: no time satisfying %s", ErrGenerate, formatJSON(node))
+	}
+
+	span := hi.Sub(*lo)
+	if span > 0 {
+		span = time.Duration(g.rng.Int64N(int64(span)))
+	}
+
+	return lo.Add(span).UTC().Format(time.RFC3339Nano), nil
+}
+
+// array returns a slice satisfying the array operators: the elements
+// required by the sequence operators, $any and $count come first, then the
+// slice is filled up to $size with elements matching $all.
+func (g *generator) array(node map[string]any) (any, error) {
+	var res []any
+
+	for _, name := range []string{"$prefix", "$subslice", "$subsequence"} {
+		if expect, ok := elements(node[name]); ok {
+			items, err := g.generateAll(expect)
+			if err != nil {
+				return nil, err
+			}
+
+			res = append(res, items...)
+		}
+	}
+
+	if expect, ok := node["$any"]; ok {
+		item, err := g.generate(expect)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, item)
+	}
+
+	if of, ok := node["$of"]; ok && node["$count"] != nil {
+		for range g.count(node["$count"]) {
+			item, err := g.generate(of)
+			if err != nil {
+				return nil, err
+			}
+
+			res = append(res, item)
+		}
+	}
+
+	size := len(res)
+
+	switch {
+	case node["$size"] != nil:
+		size = g.count(node["$size"])
+	case node["$count"] != nil && node["$of"] == nil:
+		size = g.count(node["$count"])
+	case node["$all"] != nil && len(res) == 0:
+		size = 1
+	}
+
+	suffix, _ := elements(node["$suffix"])
+
+	for len(res)+len(suffix) < size {
+		item, err := g.filler(node)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, item)
+	}
+
+	items, err := g.generateAll(suffix)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(res, items...), nil
+}
+
+// filler returns an element padding the slice of array operators.
+func (g *generator) filler(node map[string]any) (any, error) {
+	if expect, ok := node["$all"]; ok {
+		return g.generate(expect)
+	}
+
+	return g.word(), nil
+}
+
+// count returns the smallest count satisfying the expectation of $count or $size.
+func (g *generator) count(expect any) int {
+	const maxCount = 16
+
+	for n := range maxCount {
+		if matchNumber(expect, n, g.mode.compare()) {
+			return n
+		}
+	}
+
+	return 0
+}
+
+// format returns a string in the format of $format.
+//
+//nolint:cyclop
+func (g *generator) format(node map[string]any) (any, error) {
+	operand, _ := node["$format"].(string)
+	name, arg, _ := strings.Cut(operand, ":")
+
+	switch name {
+	case "uuid":
+		b := make([]byte, 16) //nolint:mnd
+		for i := range b {
+			b[i] = byte(g.rng.UintN(256)) //nolint:mnd
+		}
+
+		b[6], b[8] = b[6]&0x0f|0x40, b[8]&0x3f|0x80
+
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+	case "email":
+		return g.word() + "@example.com", nil
+	case "ipv4":
+		return fmt.Sprintf("192.0.2.%d", 1+g.rng.IntN(254)), nil //nolint:mnd
+	case "ipv6":
+		return fmt.Sprintf("2001:db8::%x", 1+g.rng.IntN(0xffff)), nil //nolint:mnd
+	case "cidr":
+		return g.cidr(arg)
+	case "uri":
+		return "https://example.com/" + g.word(), nil
+	case "semver":
+		return g.semver(arg)
+	case "base64":
+		return base64.StdEncoding.EncodeToString([]byte(g.word())), nil
+	case "hostname":
+		return g.word() + ".example.com", nil
+	default:
+		return nil, fmt.Errorf("%w: unknown format %s", ErrGenerate, operand)
+	}
+}
+
+// cidr returns a network, or an address in the network of the argument.
+func (g *generator) cidr(arg string) (any, error) {
+	if arg == "" {
+		return fmt.Sprintf("192.0.2.0/%d", 24+g.rng.IntN(9)), nil //nolint:mnd
+	}
+
+	prefix, err := netip.ParsePrefix(arg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrGenerate, err)
+	}
+
+	addr := prefix.Masked().Addr().AsSlice()
+
+	// Randomize the host bits.
+	for i := range addr {
+		if host := prefix.Bits() - i*8; host < 8 { //nolint:mnd
+			addr[i] |= byte(g.rng.UintN(256)) & (0xff >> max(host, 0)) //nolint:mnd
+		}
+	}
+
+	res, _ := netip.AddrFromSlice(addr)
+
+	return res.String(), nil
+}
+
+//nolint:gochecknoglobals
+var semverNumbers = regexp.MustCompile(`\d+(\.\d+){0,2}`)
+
+// semver returns a version satisfying the constraint. The candidates are the
+// versions of the constraint and their neighbors.
+func (g *generator) semver(arg string) (any, error) {
+	if arg == "" {
+		return fmt.Sprintf("%d.%d.%d", g.rng.IntN(10), g.rng.IntN(10), g.rng.IntN(10)), nil //nolint:mnd
+	}
+
+	candidates := []string{"0.0.0", "1.0.0"}
+
+	for _, version := range semverNumbers.FindAllString(arg, -1) {
+		parts := make([]int, 3) //nolint:mnd
+
+		for i, part := range strings.Split(version, ".") {
+			parts[i], _ = strconv.Atoi(part)
+		}
+
+		major, minor, patch := parts[0], parts[1], parts[2]
+
+		candidates = append(candidates,
+			fmt.Sprintf("%d.%d.%d", major, minor, patch),
+			fmt.Sprintf("%d.%d.%d", major, minor, patch+1),
+			fmt.Sprintf("%d.%d.0", major, minor+1),
+			fmt.Sprintf("%d.0.0", major+1),
+		)
+
+		if patch > 0 {
+			candidates = append(candidates, fmt.Sprintf("%d.%d.%d", major, minor, patch-1))
+		}
+
+		if minor > 0 {
+			candidates = append(candidates, fmt.Sprintf("%d.%d.0", major, minor-1))
+		}
+
+		if major > 0 {
+			candidates = append(candidates, fmt.Sprintf("%d.0.0", major-1))
+		}
+	}
+
+	candidates = slices.DeleteFunc(candidates, func(v string) bool { return formatSemver(v, arg) != 1 })
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: no version satisfying %s", ErrGenerate, arg)
+	}
+
+	return candidates[g.rng.IntN(len(candidates))], nil
+}
+
+// schema returns a value conforming to the JSON Schema of $jsonSchema.
+func (g *generator) schema(node map[string]any) (any, error) {
+	return g.schemaValue(node["$jsonSchema"])
+}
+
+// schemaValue returns a value conforming to the schema, with the required
+// properties and the minimum number of items only.
+//
+//nolint:cyclop,funlen
+func (g *generator) schemaValue(schema any) (any, error) {
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			return nil, fmt.Errorf("%w: false schema", ErrGenerate)
+		}
+
+		return nil, nil //nolint:nilnil
+	case map[string]any:
+		if v, ok := s["const"]; ok {
+			return v, nil
+		}
+
+		if v, ok := s["enum"].([]any); ok && len(v) > 0 {
+			return v[g.rng.IntN(len(v))], nil
+		}
+
+		s = g.mergeSchemas(s)
+
+		switch schemaType(s) {
+		case "string":
+			return g.schemaString(s)
+		case "integer", "number":
+			lo, loOpen, hi, hiOpen := math.Inf(-1), false, math.Inf(1), false
+
+			if v, ok := s["minimum"]; ok {
+				lo = cast.ToFloat64(v)
+			}
+
+			if v, ok := s["exclusiveMinimum"]; ok {
+				lo, loOpen = cast.ToFloat64(v), true
+			}
+
+			if v, ok := s["maximum"]; ok {
+				hi = cast.ToFloat64(v)
+			}
+
+			if v, ok := s["exclusiveMaximum"]; ok {
+				hi, hiOpen = cast.ToFloat64(v), true
+			}
+
+			return g.number(lo, loOpen, hi, hiOpen, schemaType(s) == "integer")
+		case "boolean":
+			return g.rng.IntN(2) == 1, nil //nolint:mnd
+		case "array":
+			size := cast.ToInt(s["minItems"])
+			if size > generateMaxLength {
+				return nil, fmt.Errorf("%w: minItems %d is over %d", ErrGenerate, size, generateMaxLength)
+			}
+
+			if _, ok := s["items"]; ok && size == 0 && cast.ToInt(s["maxItems"]) != 0 || s["maxItems"] == nil && size == 0 {
+				size = 1
+			}
+
+			res := make([]any, 0, size)
+
+			for range size {
+				item, err := g.schemaValue(schemaOr(s["items"]))
+				if err != nil {
+					return nil, err
+				}
+
+				res = append(res, item)
+			}
+
+			return res, nil
+		case "object":
+			properties, _ := s["properties"].(map[string]any)
+			required, _ := s["required"].([]any)
+			res := make(map[string]any, len(required))
+
+			for _, name := range required {
+				value, err := g.schemaValue(schemaOr(properties[cast.ToString(name)]))
+				if err != nil {
+					return nil, err
+				}
+
+				res[cast.ToString(name)] = value
+			}
+
+			return res, nil
+		default:
+			return nil, nil //nolint:nilnil
+		}
+	default:
+		return nil, fmt.Errorf("%w: invalid schema %v", ErrGenerate, schema)
+	}
+}
+
+// mergeSchemas merges the subschemas of allOf and of a random branch of
+// anyOf or oneOf into a copy of the schema. The schema takes precedence.
+func (g *generator) mergeSchemas(schema map[string]any) map[string]any {
+	subs, _ := schema["allOf"].([]any)
+
+	for _, name := range []string{"anyOf", "oneOf"} {
+		if branches, ok := schema[name].([]any); ok && len(branches) > 0 {
+			subs = append(slices.Clone(subs), branches[g.rng.IntN(len(branches))])
+		}
+	}
+
+	if len(subs) == 0 {
+		return schema
+	}
+
+	res := make(map[string]any, len(schema))
+	for key, value := range schema {
+		res[key] = value
+	}
+
+	for _, sub := range subs {
+		if sub, ok := sub.(map[string]any); ok {
+			for key, value := range g.mergeSchemas(sub) {
+				if _, ok := res[key]; !ok {
+					res[key] = value
+				}
+			}
+		}
+	}
+
+	return res
+}
+
+// schemaOr returns the schema, or the schema accepting anything if it is missing.
+func schemaOr(schema any) any {
+	if schema == nil {
+		return true
+	}
+
+	return schema
+}
+
+// schemaType returns the type of the values conforming to the schema: its
+// first type or the type implied by its keywords.
+func schemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		if len(t) > 0 {
+			return cast.ToString(t[0])
+		}
+	}
+
+	implied := map[string]string{
+		"properties": "object", "required": "object", "minProperties": "object",
+		"items": "array", "minItems": "array",
+		"pattern": "string", "minLength": "string", "maxLength": "string",
+		"minimum": "number", "maximum": "number", "exclusiveMinimum": "number", "exclusiveMaximum": "number",
+	}
+
+	for _, keyword := range slices.Sorted(maps.Keys(schema)) {
+		if t, ok := implied[keyword]; ok {
+			return t
+		}
+	}
+
+	return ""
+}
+
+// schemaString returns a string conforming to the string keywords of the schema.
+func (g *generator) schemaString(schema map[string]any) (string, error) {
+	res := g.word()
+
+	if pattern, ok := schema["pattern"].(string); ok {
+		if s, ok := g.regex(pattern); ok {
+			res = s
+		}
+	}
+
+	if v, ok := schema["minLength"]; ok {
+		if length := cast.ToInt(v); length > generateMaxLength {
+			return "", fmt.Errorf("%w: minLength %d is over %d", ErrGenerate, length, generateMaxLength)
+		}
+
+		if n := cast.ToInt(v) - utf8.RuneCountInString(res); n > 0 {
+			res += strings.Repeat("x", n)
+		}
+	}
+
+	if v, ok := schema["maxLength"]; ok {
+		if runes := []rune(res); len(runes) > cast.ToInt(v) {
+			res = string(runes[:max(cast.ToInt(v), 0)])
+		}
+	}
+
+	return res, nil
+}
+
+// word returns a random lowercase word.
+func (g *generator) word() string {
+	const (
+		minLength = 3
+		maxLength = 8
+	)
+
+	b := make([]byte, minLength+g.rng.IntN(maxLength-minLength+1))
+	for i := range b {
+		b[i] = byte('a' + g.rng.IntN(26)) //nolint:mnd
+	}
+
+	return string(b)
+}
+
+// regex returns a string matching the regular expression, built from its syntax tree.
+// It returns false if the expression is invalid or matches nothing.
+func (g *generator) regex(expr string) (string, bool) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+
+	var sb strings.Builder
+
+	ok := g.regexNode(re.Simplify(), &sb)
+
+	return sb.String(), ok
+}
+
+// regexNode writes a string matching the node of a syntax tree.
+// Assertions are skipped and left to the check of the candidate.
+//
+//nolint:cyclop
+func (g *generator) regexNode(re *syntax.Regexp, sb *strings.Builder) bool {
+	const maxRepeat = 3
+
+	repeat := func(lo, hi int) bool {
+		for range lo + g.rng.IntN(hi-lo+1) {
+			if !g.regexNode(re.Sub[0], sb) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	switch re.Op { //nolint:exhaustive
+	case syntax.OpNoMatch:
+		return false
+	case syntax.OpLiteral:
+		sb.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return false
+		}
+
+		sb.WriteRune(g.classRune(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		sb.WriteByte(byte('a' + g.rng.IntN(26))) //nolint:mnd
+	case syntax.OpCapture:
+		return g.regexNode(re.Sub[0], sb)
+	case syntax.OpStar:
+		return repeat(0, maxRepeat-1)
+	case syntax.OpPlus:
+		return repeat(1, maxRepeat)
+	case syntax.OpQuest:
+		return repeat(0, 1)
+	case syntax.OpRepeat:
+		hi := re.Max
+		if hi < 0 || hi > re.Min+maxRepeat {
+			hi = re.Min + maxRepeat
+		}
+
+		return repeat(re.Min, hi)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !g.regexNode(sub, sb) {
+				return false
+			}
+		}
+	case syntax.OpAlternate:
+		return g.regexNode(re.Sub[g.rng.IntN(len(re.Sub))], sb)
+	}
+
+	return true
+}
+
+// classRune returns a rune of a character class given by its ranges,
+// preferring letters and digits, then printable ASCII characters.
+func (g *generator) classRune(ranges []rune) rune {
+	for _, preferred := range [][2]rune{{'0', 'z'}, {' ', '~'}, {0, 0x10FFFF}} {
+		var candidates [][2]rune
+
+		for i := 0; i+1 < len(ranges); i += 2 {
+			lo, hi := max(ranges[i], preferred[0]), min(ranges[i+1], preferred[1])
+			if lo <= hi {
+				candidates = append(candidates, [2]rune{lo, hi})
+			}
+		}
+
+		if len(candidates) > 0 {
+			c := candidates[g.rng.IntN(len(candidates))]
+
+			return c[0] + g.rng.Int32N(c[1]-c[0]+1)
+		}
+	}
+
+	return ranges[0]
+}
@@ -0,0 +1,163 @@
+package deeply_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+//nolint:gochecknoglobals
+var generateExpectations = []any{
+	"^[a-z]{3,8}@example\\.(com|org)$",
+	`^\d{3}-\d{4}$`,
+	"(?i)^hello, (world|there)!?$",
+	"[^a-z]+",
+	"^\\p{Greek}+$",
+	42.0,
+	true,
+	nil,
+	map[string]any{},
+	map[string]any{
+		"name":    "^al",
+		"age":     map[string]any{"$gte": 18.0, "$lt": 21.0},
+		"tags":    []any{"^a", "b$"},
+		"address": map[string]any{"city": "Berlin", "zip": `^\d{5}$`},
+	},
+	map[string]any{"$gt": "m"},
+	map[string]any{"$lte": 3.5, "$ne": 3.5},
+	map[string]any{"$eq": []any{1.0, 2.0}},
+	map[string]any{"$after": "2024-01-01T00:00:00Z", "$before": "2024-01-02T00:00:00Z"},
+	map[string]any{"$sameDay": "2024-02-29"},
+	map[string]any{"$within": "5m"},
+	map[string]any{"$format": "uuid"},
+	map[string]any{"$format": "email"},
+	map[string]any{"$format": "ipv4"},
+	map[string]any{"$format": "ipv6"},
+	map[string]any{"$format": "cidr"},
+	map[string]any{"$format": "cidr:10.20.0.0/14"},
+	map[string]any{"$format": "uri"},
+	map[string]any{"$format": "semver:>=1.2.3 <2"},
+	map[string]any{"$format": "semver:^0.3"},
+	map[string]any{"$format": "base64"},
+	map[string]any{"$format": "hostname"},
+	map[string]any{"$any": "^x"},
+	map[string]any{"$all": map[string]any{"$gt": 10.0}, "$size": map[string]any{"$gte": 2.0}},
+	map[string]any{"$none": "^x"},
+	map[string]any{"$count": 2.0, "$of": "^y"},
+	map[string]any{"$prefix": []any{"a"}, "$suffix": []any{"z"}, "$subsequence": []any{"m", "n"}},
+	map[string]any{"$jsonSchema": map[string]any{
+		"type":     "object",
+		"required": []any{"id", "name", "tags", "kind"},
+		"properties": map[string]any{
+			"id":   map[string]any{"type": "integer", "minimum": 1.0, "exclusiveMaximum": 3.0},
+			"name": map[string]any{"type": "string", "pattern": "^[A-Z]", "minLength": 5.0, "maxLength": 6.0},
+			"tags": map[string]any{"items": map[string]any{"enum": []any{"a", "b"}}, "minItems": 2.0},
+			"kind": map[string]any{"oneOf": []any{map[string]any{"const": "x"}, map[string]any{"type": "boolean"}}},
+		},
+	}},
+}
+
+//nolint:gochecknoglobals
+var generateModes = []deeply.Mode{
+	deeply.ModeMatches,
+	deeply.ModeMatchesIgnoreArrayOrder,
+	deeply.ModeContains,
+	deeply.ModeContainsIgnoreArrayOrder,
+	deeply.ModeEquals,
+	deeply.ModeEqualsIgnoreArrayOrder,
+}
+
+func TestGenerate_Property(t *testing.T) {
+	restore := deeply.SetClock(func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) })
+	defer restore()
+
+	for _, expect := range generateExpectations {
+		for _, mode := range generateModes {
+			for seed := range uint64(20) {
+				value, err := deeply.Generate(expect, mode, deeply.WithSeed(seed))
+				require.NoError(t, err, "%v %v %d", expect, mode, seed)
+				require.True(t, mode.Match(expect, value), "%v %v %d: %#v", expect, mode, seed, value)
+			}
+		}
+	}
+}
+
+func TestGenerate_Seed(t *testing.T) {
+	expect := map[string]any{"id": `^[a-f0-9]{8}$`, "name": "^[a-z]+$"}
+
+	a, err := deeply.Generate(expect, deeply.ModeMatches, deeply.WithSeed(1))
+	require.NoError(t, err)
+
+	b, err := deeply.Generate(expect, deeply.ModeMatches, deeply.WithSeed(1))
+	require.NoError(t, err)
+	require.Equal(t, a, b)
+
+	c, err := deeply.Generate(expect, deeply.ModeMatches, deeply.WithSeed(2))
+	require.NoError(t, err)
+	require.NotEqual(t, a, c)
+
+	d, err := deeply.Generate(expect, deeply.ModeMatches)
+	require.NoError(t, err)
+
+	e, err := deeply.Generate(expect, deeply.ModeMatches, deeply.WithSeed(0))
+	require.NoError(t, err)
+	require.Equal(t, d, e)
+}
+
+func TestGenerate_Modes(t *testing.T) {
+	value, err := deeply.Generate(map[string]any{"name": "^al"}, deeply.ModeContains)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"name": "^al"}, value)
+
+	value, err = deeply.Generate(map[string]any{"id": `^\d{4}$`}, deeply.ModeMatches)
+	require.NoError(t, err)
+	require.Regexp(t, `^\d{4}$`, value.(map[string]any)["id"])
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	for _, expect := range []any{
+		`^a\bb$`,
+		map[string]any{"$gt": 5.0, "$lt": 5.0},
+		map[string]any{"$gt": 5, "$lt": 1},
+		map[string]any{"$gt": 5.0, "$lt": 3.5},
+		map[string]any{"$gte": 5.0, "$lt": 5.0},
+		map[string]any{"$jsonSchema": map[string]any{"minimum": 5, "maximum": 1}},
+		map[string]any{"$jsonSchema": map[string]any{"type": "integer", "minimum": 1.2, "maximum": 1.8}},
+		map[string]any{"$jsonSchema": map[string]any{"minLength": 1 << 40}},
+		map[string]any{"$jsonSchema": map[string]any{"type": "array", "minItems": 1 << 40}},
+		map[string]any{"$after": "2024-01-02", "$before": "2024-01-01"},
+		map[string]any{"$format": "unknown"},
+		map[string]any{"$format": "semver:>2 <1"},
+		map[string]any{"$jsonSchema": false},
+		map[string]any{"$any": "^x", "$none": "^x"},
+	} {
+		_, err := deeply.Generate(expect, deeply.ModeMatches)
+		require.ErrorIs(t, err, deeply.ErrGenerate, "%v", expect)
+	}
+}
+
+func TestGenerate_DeepErrors(t *testing.T) {
+	var nested, quantified any = `^a\bb$`, `^a\bb$`
+
+	for range 8 {
+		nested = map[string]any{"a": nested, "b": []any{nested}}
+		quantified = map[string]any{"$all": quantified}
+	}
+
+	for _, expect := range []any{nested, quantified} {
+		for _, mode := range generateModes {
+			start := time.Now()
+
+			// The other modes compare the strings literally.
+			_, err := deeply.Generate(expect, mode)
+			if mode == deeply.ModeMatches || mode == deeply.ModeMatchesIgnoreArrayOrder {
+				require.ErrorIs(t, err, deeply.ErrGenerate, "%v", mode)
+			}
+
+			require.Less(t, time.Since(start), time.Second, "%v", mode)
+		}
+	}
+}
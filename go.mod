@@ -3,12 +3,17 @@ module github.com/gripmock/deeply
 go 1.24
 
 require (
+	github.com/google/go-cmp v0.7.0
+	github.com/onsi/gomega v1.38.2
 	github.com/spf13/cast v1.10.0
 	github.com/stretchr/testify v1.11.1
+	go.yaml.in/yaml/v3 v3.0.4
+	golang.org/x/text v0.28.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.43.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
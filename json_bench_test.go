@@ -0,0 +1,115 @@
+package deeply_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gripmock/deeply"
+)
+
+// decodeJSON decodes the JSON document like the payloads of requests.
+func decodeJSON(b *testing.B, data string) any {
+	b.Helper()
+
+	var res any
+	if err := json.Unmarshal([]byte(data), &res); err != nil {
+		b.Fatal(err)
+	}
+
+	return res
+}
+
+const benchDocument = `{
+	"id": 42,
+	"name": "gripmock",
+	"active": true,
+	"parent": null,
+	"tags": ["grpc", "mock", "testing"],
+	"owner": {"login": "octocat", "email": "octocat@example.com", "age": 12.5},
+	"items": [
+		{"sku": "A-1", "qty": 1, "price": 9.99},
+		{"sku": "B-2", "qty": 3, "price": 19.5},
+		{"sku": "C-3", "qty": 2, "price": 0.5}
+	]
+}`
+
+func BenchmarkMatches_JSON(b *testing.B) {
+	expect := decodeJSON(b, `{"name": "^grip", "owner": {"email": "@example\\.com$"}, "items": [{"sku": "A-1"}]}`)
+	actual := decodeJSON(b, benchDocument)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		deeply.Matches(expect, actual)
+	}
+}
+
+func BenchmarkContains_JSON(b *testing.B) {
+	expect := decodeJSON(b, `{"name": "gripmock", "owner": {"login": "octocat"}, "items": [{"sku": "B-2"}]}`)
+	actual := decodeJSON(b, benchDocument)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		deeply.Contains(expect, actual)
+	}
+}
+
+func BenchmarkEquals_JSON(b *testing.B) {
+	expect := decodeJSON(b, benchDocument)
+	actual := decodeJSON(b, benchDocument)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		deeply.Equals(expect, actual)
+	}
+}
+
+func BenchmarkRankMatch_JSON(b *testing.B) {
+	expect := decodeJSON(b, `{"name": "gripmok", "active": true, "owner": {"login": "octocat", "age": 12}, "tags": ["grpc", "mock"]}`)
+	actual := decodeJSON(b, benchDocument)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		deeply.RankMatch(expect, actual)
+	}
+}
+
+func BenchmarkContainsIgnoreArrayOrder_Equal(b *testing.B) {
+	items := make([]any, 4000)
+	for i := range items {
+		items[i] = "x"
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		deeply.ContainsIgnoreArrayOrder(items, items)
+	}
+}
+
+func BenchmarkContainsIgnoreArrayOrder_Reordered(b *testing.B) {
+	// The greedy pairing gives the first "ab" to ^a, an augmenting path
+	// through all the pairs moves it to the last ^ab.
+	expect := make([]any, 0, 200)
+	actual := make([]any, 0, 200)
+
+	for range 100 {
+		expect = append(expect, "^a", "^ab")
+		actual = append(actual, "ab", "ac")
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		deeply.MatchesIgnoreArrayOrder(expect, actual)
+	}
+}
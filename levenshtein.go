@@ -0,0 +1,247 @@
+package deeply
+
+import (
+	"unicode/utf8"
+)
+
+// myersMaxLen is the length of the longest string compared by the
+// bit-parallel algorithm, the number of bits of its vectors.
+const myersMaxLen = 64
+
+// distance calculates the Levenshtein distance between two strings.
+// It returns a float64 representing the distance normalized by the length of the
+// longer string.
+//
+// The Levenshtein distance is a measure of the number of single-character edits
+// needed to transform one string into another, such as insertion, deletion, or
+// substitution.
+//
+// Parameters:
+// - s: The first string.
+// - t: The second string.
+func distance(s, t string) float64 {
+	return distanceAtLeast(s, t, 0)
+}
+
+// distanceAtLeast calculates the normalized Levenshtein distance between two
+// strings like distance, but gives up and returns 0 as soon as the result is
+// known to be lower than minScore.
+func distanceAtLeast(s, t string, minScore float64) float64 {
+	// Fast path for identical strings
+	if s == t {
+		return 1.0
+	}
+
+	// Fast path for empty strings
+	if len(s) == 0 || len(t) == 0 {
+		return 0.0
+	}
+
+	// ASCII fast path optimization (common case). Invalid UTF-8 strings are
+	// compared byte by byte too, their invalid bytes would all decode to U+FFFD.
+	if isASCII(s) && isASCII(t) || !utf8.ValidString(s) || !utf8.ValidString(t) {
+		return normalizedDistance([]byte(s), []byte(t), minScore)
+	}
+
+	// Unicode path for non-ASCII strings
+	return normalizedDistance([]rune(s), []rune(t), minScore)
+}
+
+// normalizedDistance calculates the Levenshtein distance between two
+// non-empty strings normalized by the length of the longer one.
+// It returns 0 if the result is lower than minScore.
+func normalizedDistance[E byte | rune](a, b []E, minScore float64) float64 {
+	maxLength := max(len(a), len(b))
+
+	// The score reaches minScore if the distance is at most the limit.
+	limit := maxLength
+	if minScore > 0 {
+		limit = int(float64(maxLength)*(1-minScore) + 1e-9) //nolint:mnd
+	}
+
+	d, ok := levenshtein(a, b, limit)
+	if !ok {
+		return 0
+	}
+
+	if score := float64(maxLength-d) / float64(maxLength); score >= minScore {
+		return score
+	}
+
+	return 0
+}
+
+// levenshtein calculates the Levenshtein distance between two strings if it
+// is at most the limit. It returns false as soon as the distance is known to
+// exceed the limit.
+//
+// Strings of up to 64 characters are compared by the bit-parallel algorithm
+// of Myers, longer ones in the diagonal band of Ukkonen.
+func levenshtein[E byte | rune](a, b []E, limit int) (int, bool) {
+	// Let a be the shorter string.
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	// Every extra character of the longer string costs an insertion.
+	if len(b)-len(a) > limit || limit < 0 {
+		return 0, false
+	}
+
+	if len(a) == 0 {
+		return len(b), true
+	}
+
+	if len(a) <= myersMaxLen {
+		return myers(a, b, limit)
+	}
+
+	return ukkonen(a, b, limit)
+}
+
+// peq holds the positions of the characters in the pattern of the
+// bit-parallel algorithm: the bit i of the mask of a character is set if
+// the character is at the position i.
+type peq struct {
+	low   [256]uint64           // Masks of the characters lower than 256.
+	high  [myersMaxLen]peqEntry // Masks of the other characters.
+	count int                   // Number of the other characters.
+}
+
+// peqEntry is the mask of a character of the pattern.
+type peqEntry struct {
+	char rune
+	mask uint64
+}
+
+// add sets the bit of the position of the character.
+func (p *peq) add(c rune, bit uint64) {
+	if c < 256 { //nolint:mnd
+		p.low[c] |= bit
+
+		return
+	}
+
+	for i := range p.count {
+		if p.high[i].char == c {
+			p.high[i].mask |= bit
+
+			return
+		}
+	}
+
+	p.high[p.count] = peqEntry{char: c, mask: bit}
+	p.count++
+}
+
+// get returns the mask of the positions of the character.
+func (p *peq) get(c rune) uint64 {
+	if c < 256 { //nolint:mnd
+		return p.low[c]
+	}
+
+	for i := range p.count {
+		if p.high[i].char == c {
+			return p.high[i].mask
+		}
+	}
+
+	return 0
+}
+
+// myers calculates the Levenshtein distance with the bit-parallel algorithm
+// of Myers, as formulated by Hyyrö. The pattern a must be 1 to 64 characters
+// long. The columns of the distance matrix are encoded as bit vectors of
+// vertical deltas, so each character of b costs a few word operations.
+// The distance changes by at most 1 per character, so the algorithm gives up
+// once the remaining characters can't bring it back under the limit.
+func myers[E byte | rune](a, b []E, limit int) (int, bool) {
+	var p peq
+
+	for i, c := range a {
+		p.add(rune(c), 1<<i)
+	}
+
+	var (
+		last   = uint64(1) << (len(a) - 1)
+		pv, mv = ^uint64(0), uint64(0) // Positive and negative vertical deltas.
+		score  = len(a)
+	)
+
+	for j, c := range b {
+		eq := p.get(rune(c))
+		xv := eq | mv
+		xh := (((eq & pv) + pv) ^ pv) | eq
+		ph := mv | ^(xh | pv) // Positive horizontal deltas.
+		mh := pv & xh         // Negative horizontal deltas.
+
+		switch {
+		case ph&last != 0:
+			score++
+		case mh&last != 0:
+			score--
+		}
+
+		// The first row of the matrix grows by 1 per character.
+		ph = ph<<1 | 1
+		mh <<= 1
+		pv = mh | ^(xv | ph)
+		mv = ph & xv
+
+		if score-(len(b)-j-1) > limit {
+			return 0, false
+		}
+	}
+
+	return score, score <= limit
+}
+
+// ukkonen calculates the Levenshtein distance in the diagonal band of the
+// matrix where it can be at most the limit, as proposed by Ukkonen, and
+// gives up as soon as a whole row of the band exceeds the limit.
+// The string a must not be longer than b.
+func ukkonen[E byte | rune](a, b []E, limit int) (int, bool) {
+	outside := limit + 1 // The value of the cells outside the band.
+
+	row := make([]int, len(a)+1)
+	for i := range row {
+		row[i] = min(i, outside)
+	}
+
+	for j := 1; j <= len(b); j++ {
+		lo, hi := max(1, j-limit), min(len(a), j+limit)
+
+		// The cell left of the band, the diagonal of its first cell.
+		diag := row[lo-1]
+		left := outside
+
+		if lo == 1 {
+			left = min(j, outside)
+		}
+
+		row[lo-1] = left
+		rowMin := left
+
+		// The cell above the last one is out of the band of the previous row.
+		if hi > j-1+limit {
+			row[hi] = outside
+		}
+
+		for i := lo; i <= hi; i++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			value := min(row[i]+1, left+1, diag+cost)
+			diag, row[i], left = row[i], value, value
+			rowMin = min(rowMin, value)
+		}
+
+		if rowMin > limit {
+			return 0, false
+		}
+	}
+
+	return row[len(a)], row[len(a)] <= limit
+}
@@ -0,0 +1,137 @@
+package deeply_test
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+// referenceDistance calculates the normalized Levenshtein distance between
+// two strings with the whole matrix, comparing their runes.
+func referenceDistance(s, t string) float64 {
+	a, b := []rune(s), []rune(t)
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev = cur
+	}
+
+	maxLength := max(len(a), len(b))
+
+	return float64(maxLength-prev[len(b)]) / float64(maxLength)
+}
+
+// randomString returns a string of up to n characters of the alphabet.
+func randomString(rng *rand.Rand, alphabet []rune, n int) string {
+	var sb strings.Builder
+
+	for range rng.IntN(n + 1) {
+		sb.WriteRune(alphabet[rng.IntN(len(alphabet))])
+	}
+
+	return sb.String()
+}
+
+func TestLevenshtein_Reference(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4)) //nolint:gosec
+
+	alphabets := [][]rune{[]rune("ab"), []rune("abcdefgh"), []rune("aåäöø̃日本語")}
+
+	for range 3000 {
+		alphabet := alphabets[rng.IntN(len(alphabets))]
+		s := randomString(rng, alphabet, 1+rng.IntN(150))
+		u := randomString(rng, alphabet, 1+rng.IntN(150))
+
+		if s == "" || u == "" {
+			continue
+		}
+
+		require.InDelta(t, referenceDistance(s, u), deeply.Distance(s, u), 1e-9, "%q, %q", s, u)
+	}
+}
+
+func TestLevenshtein_AtLeast(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6)) //nolint:gosec
+
+	for range 3000 {
+		alphabet := []rune("abcø")
+		s := randomString(rng, alphabet, 1+rng.IntN(100))
+		u := randomString(rng, alphabet, 1+rng.IntN(100))
+		minScore := rng.Float64()
+
+		score := deeply.Distance(s, u)
+		if score >= minScore {
+			require.InDelta(t, score, deeply.DistanceAtLeast(s, u, minScore), 1e-9, "%q, %q, %v", s, u, minScore)
+		} else {
+			require.Zero(t, deeply.DistanceAtLeast(s, u, minScore), "%q, %q, %v", s, u, minScore)
+		}
+	}
+
+	// The scores on the bound are kept.
+	require.InDelta(t, .5, deeply.DistanceAtLeast("abcd", "abxy", .5), 1e-9)
+	require.InDelta(t, 1., deeply.DistanceAtLeast("abcd", "abcd", 1), 1e-9)
+	require.Zero(t, deeply.DistanceAtLeast("abcd", "abcx", 1))
+}
+
+func TestRanker_RankMatchAtLeast(t *testing.T) {
+	r := deeply.NewRanker()
+
+	score, ok := r.RankMatchAtLeast("hello", "hella", .5)
+	require.True(t, ok)
+	require.InDelta(t, deeply.RankMatch("hello", "hella"), score, 1e-9)
+
+	_, ok = r.RankMatchAtLeast("hello", "zzzzz", .5)
+	require.False(t, ok)
+
+	_, ok = r.RankMatchAtLeast(map[string]any{"a": "hello"}, map[string]any{"a": "zzzzz"}, 3)
+	require.False(t, ok)
+
+	score, ok = r.RankMatchAtLeast(map[string]any{"a": "hello"}, map[string]any{"a": "hello"}, 1)
+	require.True(t, ok)
+	require.InDelta(t, deeply.RankMatch(map[string]any{"a": "hello"}, map[string]any{"a": "hello"}), score, 1e-9)
+}
+
+func TestRanker_BestMatch(t *testing.T) {
+	r := deeply.NewRanker()
+
+	best, score := r.BestMatch("hella", []any{"world", "zzzzz", "hello", "help", "hello"})
+	require.Equal(t, 2, best)
+	require.InDelta(t, deeply.RankMatch("hello", "hella"), score, 1e-9)
+
+	best, _ = r.BestMatch(map[string]any{"name": "alice"}, []any{
+		map[string]any{"name": "bob"},
+		map[string]any{"name": "^ali"},
+		map[string]any{"name": "alina"},
+	})
+	require.Equal(t, 1, best)
+
+	best, score = r.BestMatch("x", []any{"a", "b"})
+	require.Equal(t, 0, best, "the first candidate wins ties")
+	require.Zero(t, score)
+
+	best, _ = r.BestMatch("x", nil)
+	require.Equal(t, -1, best)
+}
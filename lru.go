@@ -0,0 +1,73 @@
+package deeply
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lru caches the values computed from their keys, evicting the least
+// recently used one once it holds size values.
+type lru[K comparable, V any] struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // The entries from the most to the least recently used.
+	items map[K]*list.Element
+}
+
+// lruEntry is an element of the order of a cache.
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// newLRU returns an empty cache of the size.
+func newLRU[K comparable, V any](size int) *lru[K, V] {
+	return &lru[K, V]{size: size, order: list.New(), items: make(map[K]*list.Element, size)}
+}
+
+// get returns the cached value of the key, or computes and caches it.
+// The value is computed without the lock, so concurrent misses of a key may
+// compute it more than once.
+func (c *lru[K, V]) get(key K, compute func(K) V) V {
+	c.mu.Lock()
+
+	if e, ok := c.items[key]; ok {
+		c.order.MoveToFront(e)
+		entry, _ := e.Value.(lruEntry[K, V])
+		c.mu.Unlock()
+
+		return entry.value
+	}
+
+	c.mu.Unlock()
+
+	value := compute(key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.order.MoveToFront(e)
+
+		return value
+	}
+
+	c.items[key] = c.order.PushFront(lruEntry[K, V]{key: key, value: value})
+
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		entry, _ := c.order.Remove(oldest).(lruEntry[K, V])
+
+		delete(c.items, entry.key)
+	}
+
+	return value
+}
+
+// len returns the number of cached values.
+func (c *lru[K, V]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
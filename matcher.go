@@ -0,0 +1,28 @@
+package deeply
+
+// Matcher checks values like the matching function of its mode, with options
+// such as a tolerance for numbers. The zero value checks like Matches.
+type Matcher struct {
+	mode      Mode
+	tolerance tolerance // Differences under which numbers are equal.
+}
+
+// MatchOption configures a Matcher.
+type MatchOption func(*Matcher)
+
+// NewMatcher returns a Matcher checking values in the mode, configured by the options.
+func NewMatcher(mode Mode, opts ...MatchOption) *Matcher {
+	m := &Matcher{mode: mode}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Match checks if the actual value matches the expected value like the
+// matching function of the mode of the matcher, using its options.
+func (m *Matcher) Match(expect, actual any) bool {
+	return documentsOf(m.mode).compareWithin(m.tolerance, actual, expect, actual)
+}
@@ -15,27 +15,64 @@ import (
 //   - The expected and actual values are both maps and have the same number of keys.
 //   - The expected and actual values match using a regular expression.
 //   - The expected and actual values are deeply equal using reflect.DeepEqual.
+//
+// If the expected value is an operator node, such as {"$before": "2024-01-01T00:00:00Z"},
+// the actual value is checked by the operator instead.
+// Values of a type with a comparator are compared by it, see RegisterTypeComparator.
 func Matches(expect, actual any) bool {
-	return mapDeepMatches(expect, actual, Matches) ||
-		slicesDeepMatches(expect, actual, Matches) ||
+	return documents[ModeMatches].compare(actual, expect, actual)
+}
+
+// matches is Matches within the document.
+func (d *document) matches(expect, actual any) bool {
+	if node, ok := asOperatorNode(expect); ok {
+		return node.match(actual, d.compare, d.root)
+	}
+
+	if ok, found := matchByType(expect, actual); found {
+		return ok
+	}
+
+	return mapDeepMatches(expect, actual, d.compare) ||
+		slicesDeepMatches(expect, actual, d.compare) ||
 		regexMatch(expect, actual) ||
-		reflect.DeepEqual(expect, actual)
+		d.equal(expect, actual)
 }
 
 // MatchesIgnoreArrayOrder checks if the expected and actual values match
 // ignoring the order of arrays. It behaves similarly to Matches except that it
 // uses slicesDeepContains instead of slicesDeepMatches to compare slices.
 func MatchesIgnoreArrayOrder(expect, actual any) bool {
-	return mapDeepMatches(expect, actual, MatchesIgnoreArrayOrder) ||
-		slicesDeepContains(expect, actual, MatchesIgnoreArrayOrder) ||
+	return documents[ModeMatchesIgnoreArrayOrder].compare(actual, expect, actual)
+}
+
+// matchesIgnoreArrayOrder is MatchesIgnoreArrayOrder within the document.
+func (d *document) matchesIgnoreArrayOrder(expect, actual any) bool {
+	if node, ok := asOperatorNode(expect); ok {
+		return node.match(actual, d.compare, d.root)
+	}
+
+	if ok, found := matchByType(expect, actual); found {
+		return ok
+	}
+
+	return mapDeepMatches(expect, actual, d.compare) ||
+		slicesDeepContains(expect, actual, d.compare) ||
 		regexMatch(expect, actual) ||
-		reflect.DeepEqual(expect, actual)
+		d.equal(expect, actual)
 }
 
 // slicesDeepMatches checks if the expected and actual slices match.
 // It returns true if the expected and actual values are both slices and have
 // the same length.
 func slicesDeepMatches(expect, actual any, compare cmp) bool {
+	// Fast path for decoded JSON arrays.
+	if a, ok := expect.([]any); ok {
+		if b, ok := actual.([]any); ok {
+			return len(a) == len(b) && slicesDeepEquals(a, b, compare)
+		}
+	}
+
 	// Check if the types of the expected and actual values are equal.
 	if reflect.TypeOf(expect) != reflect.TypeOf(actual) {
 		return false
@@ -51,12 +88,12 @@ func slicesDeepMatches(expect, actual any, compare cmp) bool {
 		return false
 	}
 
-	// Convert the expected and actual values to reflect.Value.
-	a := reflect.ValueOf(expect)
-	b := reflect.ValueOf(actual)
+	// Convert the expected and actual values to slices of values.
+	a, _ := elements(expect)
+	b, _ := elements(actual)
 
 	// If the lengths of the slices are not equal, return false.
-	if a.Len() != b.Len() {
+	if len(a) != len(b) {
 		return false
 	}
 
@@ -67,6 +104,13 @@ func slicesDeepMatches(expect, actual any, compare cmp) bool {
 // It returns true if the expected and actual values are both maps and have
 // the same number of keys.
 func mapDeepMatches(expect, actual any, compare cmp) bool {
+	// Fast path for decoded JSON objects.
+	if left, ok := expect.(map[string]any); ok {
+		if right, ok := actual.(map[string]any); ok {
+			return len(left) <= len(right) && mapStringDeepEquals(left, right, compare)
+		}
+	}
+
 	// Check if the types of the expected and actual values are equal.
 	if reflect.TypeOf(expect) != reflect.TypeOf(actual) {
 		return false
@@ -117,14 +161,15 @@ func regexMatch(expect, actual any) bool {
 		return false
 	}
 
-	// Convert the expected and actual values to string.
-	var (
-		expectedStr, expectedStringOk = expect.(string)        // Expected regular expression as a string.
-		actualStr, actualStringErr    = cast.ToStringE(actual) // Actual string to be matched.
-	)
+	// If the expected value is not a string, return false.
+	expectedStr, ok := expect.(string) // Expected regular expression as a string.
+	if !ok {
+		return false
+	}
 
-	// If the values are not string, return false.
-	if !expectedStringOk || actualStringErr != nil {
+	// If the actual value can't be converted to a string, return false.
+	actualStr, err := cast.ToStringE(actual) // Actual string to be matched.
+	if err != nil {
 		return false
 	}
 
@@ -245,3 +245,9 @@ func TestMatches_Boundary_False(t *testing.T) {
 		},
 	}))
 }
+
+func TestMatches_Slices_OrderIgnore_Pairing(t *testing.T) {
+	// The first pattern matches both elements, the pairing must leave "b" to it.
+	require.True(t, deeply.MatchesIgnoreArrayOrder([]any{"a|b", "a"}, []any{"a", "b"}))
+	require.False(t, deeply.MatchesIgnoreArrayOrder([]any{"a|b", "a"}, []any{"b", "b"}))
+}
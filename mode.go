@@ -0,0 +1,101 @@
+package deeply
+
+import (
+	"fmt"
+)
+
+// Mode is a matching function of the package: Matches, Contains, Equals or
+// their variants ignoring the order of arrays.
+type Mode int
+
+const (
+	// ModeMatches is the mode of Matches.
+	ModeMatches Mode = iota
+	// ModeMatchesIgnoreArrayOrder is the mode of MatchesIgnoreArrayOrder.
+	ModeMatchesIgnoreArrayOrder
+	// ModeContains is the mode of Contains.
+	ModeContains
+	// ModeContainsIgnoreArrayOrder is the mode of ContainsIgnoreArrayOrder.
+	ModeContainsIgnoreArrayOrder
+	// ModeEquals is the mode of Equals.
+	ModeEquals
+	// ModeEqualsIgnoreArrayOrder is the mode of EqualsIgnoreArrayOrder.
+	ModeEqualsIgnoreArrayOrder
+)
+
+// modeNames holds the names of the modes, as returned by Mode.String.
+//
+//nolint:gochecknoglobals
+var modeNames = map[Mode]string{
+	ModeMatches:                  "matches",
+	ModeMatchesIgnoreArrayOrder:  "matches-ignore-array-order",
+	ModeContains:                 "contains",
+	ModeContainsIgnoreArrayOrder: "contains-ignore-array-order",
+	ModeEquals:                   "equals",
+	ModeEqualsIgnoreArrayOrder:   "equals-ignore-array-order",
+}
+
+// ParseMode returns the mode with the name, e.g. "contains" or "matches-ignore-array-order".
+func ParseMode(name string) (Mode, error) {
+	for mode, modeName := range modeNames {
+		if modeName == name {
+			return mode, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown mode %q", name) //nolint:err113
+}
+
+// String returns the name of the mode.
+func (m Mode) String() string {
+	if name, ok := modeNames[m]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// Match checks if the actual value matches the expected value in the mode.
+func (m Mode) Match(expect, actual any) bool {
+	return m.compare()(expect, actual)
+}
+
+// matchIn checks if the actual value matches the expected value in the mode,
+// the actual value being part of the document of the root.
+func (m Mode) matchIn(root, expect, actual any) bool {
+	return documentsOf(m).compare(root, expect, actual)
+}
+
+// compare returns the matching function of the mode.
+func (m Mode) compare() cmp {
+	switch m {
+	case ModeMatchesIgnoreArrayOrder:
+		return MatchesIgnoreArrayOrder
+	case ModeContains:
+		return Contains
+	case ModeContainsIgnoreArrayOrder:
+		return ContainsIgnoreArrayOrder
+	case ModeEquals:
+		return Equals
+	case ModeEqualsIgnoreArrayOrder:
+		return EqualsIgnoreArrayOrder
+	default:
+		return Matches
+	}
+}
+
+// partialMaps checks if the mode allows the actual maps to have more keys than the expected ones.
+func (m Mode) partialMaps() bool {
+	return m != ModeEquals && m != ModeEqualsIgnoreArrayOrder
+}
+
+// ignoreArrayOrder checks if the mode ignores the order of elements in arrays.
+func (m Mode) ignoreArrayOrder() bool {
+	return m == ModeMatchesIgnoreArrayOrder || m == ModeContainsIgnoreArrayOrder || m == ModeEqualsIgnoreArrayOrder
+}
+
+// orderedSlices checks if the mode compares the elements of arrays pairwise by
+// their index using its matching function, rather than using reflect.DeepEqual.
+func (m Mode) orderedSlices() bool {
+	return m == ModeMatches || m == ModeEquals
+}
@@ -0,0 +1,75 @@
+package deeply_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+func TestMode_Parse(t *testing.T) {
+	for _, mode := range []deeply.Mode{
+		deeply.ModeMatches,
+		deeply.ModeMatchesIgnoreArrayOrder,
+		deeply.ModeContains,
+		deeply.ModeContainsIgnoreArrayOrder,
+		deeply.ModeEquals,
+		deeply.ModeEqualsIgnoreArrayOrder,
+	} {
+		parsed, err := deeply.ParseMode(mode.String())
+
+		require.NoError(t, err)
+		require.Equal(t, mode, parsed)
+	}
+
+	_, err := deeply.ParseMode("similar")
+
+	require.Error(t, err)
+	require.Equal(t, "Mode(42)", deeply.Mode(42).String())
+}
+
+func TestMode_Match(t *testing.T) {
+	expect := map[string]any{"a": "^a", "b": []any{1, 2}}
+	actual := map[string]any{"a": "abc", "b": []any{2, 1}, "c": 1}
+
+	require.False(t, deeply.ModeMatches.Match(expect, actual))
+	require.True(t, deeply.ModeMatchesIgnoreArrayOrder.Match(expect, actual))
+	require.False(t, deeply.ModeContainsIgnoreArrayOrder.Match(expect, actual))
+	require.True(t, deeply.ModeContainsIgnoreArrayOrder.Match(map[string]any{"b": []any{1, 2}}, actual))
+	require.False(t, deeply.ModeEqualsIgnoreArrayOrder.Match(map[string]any{"b": []any{1, 2}}, actual))
+	require.True(t, deeply.ModeEquals.Match(actual, actual))
+}
+
+func TestMode_OperatorKeys(t *testing.T) {
+	// Payloads whose keys look like operators match themselves in every mode.
+	for _, value := range []any{
+		map[string]any{"$value": 2.0},
+		map[string]any{"$size": 2.0},
+		map[string]any{"$gt": 5.0, "$custom": "x"},
+		map[string]any{"data": map[string]any{"$before": "2024-01-01", "$expr": "false"}},
+		[]any{map[string]any{"$none": "^x"}},
+	} {
+		for _, mode := range []deeply.Mode{
+			deeply.ModeMatches,
+			deeply.ModeMatchesIgnoreArrayOrder,
+			deeply.ModeContains,
+			deeply.ModeContainsIgnoreArrayOrder,
+			deeply.ModeEquals,
+			deeply.ModeEqualsIgnoreArrayOrder,
+		} {
+			require.True(t, mode.Match(value, value), "%v %v", mode, value)
+		}
+
+		require.Positive(t, deeply.RankMatch(value, value), "%v", value)
+	}
+
+	require.True(t, deeply.Equals(map[string]any{"$size": 2.0}, []any{1, 2}), "still an operator")
+	require.False(t, deeply.Equals(map[string]any{"$size": 2.0}, map[string]any{"$size": 3.0}))
+	require.InDelta(t, 1., deeply.RankMatch(map[string]any{"$value": 2.0}, map[string]any{"$value": 2.0}), 1e-9)
+
+	// $eq compares its operand literally, without operators.
+	escaped := map[string]any{"$eq": map[string]any{"$size": 2.0}}
+	require.True(t, deeply.Matches(escaped, map[string]any{"$size": 2}))
+	require.False(t, deeply.Matches(escaped, []any{1, 2}))
+}
@@ -0,0 +1,162 @@
+package deeply
+
+import (
+	"slices"
+	"strings"
+)
+
+// operator is an expectation node evaluated by a dedicated function instead of
+// a deep comparison, e.g. {"$before": "2024-01-01T00:00:00Z"}.
+//
+// The match function reports whether the actual value satisfies the operator
+// and the rank function scores how close the actual value is, between 0 and 1.
+// Both receive the whole node so that operators can read their modifiers
+// (keys which start with "$" but are not operators themselves).
+//
+// The operators reading the root of the actual document, such as $expr,
+// set eval instead: it reports whether the actual value satisfies the
+// operator and its rank is 1 if it does and 0 otherwise.
+type operator struct {
+	match func(node map[string]any, actual any, compare cmp) bool
+	rank  func(node map[string]any, actual any, compare ranker) float64
+	eval  func(node map[string]any, actual, root any) bool
+}
+
+// operators holds the known operators keyed by their name.
+//
+//nolint:gochecknoglobals
+var operators = map[string]operator{
+	"$before":  {match: matchBefore, rank: rankBefore},
+	"$after":   {match: matchAfter, rank: rankAfter},
+	"$within":  {match: matchWithin, rank: rankWithin},
+	"$sameDay": {match: matchSameDay, rank: rankSameDay},
+	"$format":  {match: matchFormat, rank: rankFormat},
+
+	"$jsonSchema": {match: matchSchema, rank: rankSchema},
+
+	"$any":   {match: matchAny, rank: rankAny},
+	"$all":   {match: matchAll, rank: rankAllQuantifier},
+	"$none":  {match: matchNone, rank: rankNone},
+	"$count": {match: matchCount, rank: rankCount},
+	"$size":  {match: matchSize, rank: rankSize},
+
+	"$subsequence": {match: matchSubsequence, rank: rankSubsequence},
+	"$subslice":    {match: matchSubslice, rank: rankSubslice},
+	"$prefix":      {match: matchPrefix, rank: rankPrefix},
+	"$suffix":      {match: matchSuffix, rank: rankSuffix},
+
+	"$gt":  comparisonOperator("$gt"),
+	"$gte": comparisonOperator("$gte"),
+	"$lt":  comparisonOperator("$lt"),
+	"$lte": comparisonOperator("$lte"),
+	"$eq":  {match: matchEq, rank: rankEq},
+	"$ne":  {match: matchNe, rank: rankNe},
+
+	"$approx": {match: matchApprox, rank: rankApprox},
+
+	"$value": {match: matchValue, rank: rankValue},
+
+	"$expr": {eval: evalExpr},
+}
+
+// operatorNode is an expectation map recognized as a set of operators.
+type operatorNode struct {
+	args  map[string]any // The whole expectation node.
+	names []string       // Sorted names of the operators found in the node.
+}
+
+// asOperatorNode checks if the expected value is an operator node.
+// An operator node is a non-empty map[string]any whose keys all start with "$"
+// and at least one of them is a known operator.
+func asOperatorNode(expect any) (operatorNode, bool) {
+	node, ok := expect.(map[string]any)
+	if !ok || len(node) == 0 {
+		return operatorNode{}, false
+	}
+
+	// Check the keys first, so that the other maps cost no allocation.
+	count := 0
+
+	for key := range node {
+		if !strings.HasPrefix(key, "$") {
+			return operatorNode{}, false
+		}
+
+		if _, ok := operators[key]; ok {
+			count++
+		}
+	}
+
+	if count == 0 {
+		return operatorNode{}, false
+	}
+
+	names := make([]string, 0, count)
+
+	for key := range node {
+		if _, ok := operators[key]; ok {
+			names = append(names, key)
+		}
+	}
+
+	slices.Sort(names)
+
+	return operatorNode{args: node, names: names}, true
+}
+
+// match checks if the actual value satisfies every operator of the node,
+// or is the node itself. The root is the actual document the value belongs to.
+func (n operatorNode) match(actual any, compare cmp, root any) bool {
+	for _, name := range n.names {
+		if op := operators[name]; op.eval != nil {
+			if !op.eval(n.args, actual, root) {
+				return n.literal(actual, compare)
+			}
+		} else if !op.match(n.args, actual, compare) {
+			return n.literal(actual, compare)
+		}
+	}
+
+	return true
+}
+
+// literal checks if the actual value is a map with the keys of the node
+// and values matching its values, so that the payloads with keys looking
+// like operators match themselves.
+func (n operatorNode) literal(actual any, compare cmp) bool {
+	m, ok := actual.(map[string]any)
+
+	return ok && len(m) == len(n.args) && mapStringDeepEquals(n.args, m, compare)
+}
+
+// rank calculates the average match score of the operators of the node,
+// at least 1 if the actual value is the node itself.
+// The root is the actual document the value belongs to.
+func (n operatorNode) rank(actual any, compare ranker, root any) float64 {
+	var res float64
+
+	for _, name := range n.names {
+		if op := operators[name]; op.eval != nil {
+			res += matchScore(op.eval(n.args, actual, root))
+		} else {
+			res += op.rank(n.args, actual, compare)
+		}
+	}
+
+	res /= float64(len(n.names))
+
+	if n.literal(actual, deepEqual) {
+		return max(res, 1)
+	}
+
+	return res
+}
+
+// matchScore converts a boolean result to a match score.
+func matchScore(ok bool) float64 {
+	if ok {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,287 @@
+package deeply
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// Stub is an expectation together with the mode it is matched in, as analyzed by Overlaps.
+type Stub struct {
+	// Name identifies the stub in the reports, e.g. its file name.
+	Name string
+	// Expect is the expectation of the stub.
+	Expect any
+	// Mode is the mode the expectation is matched in.
+	Mode Mode
+}
+
+// OverlapKind is the kind of an overlap between two stubs.
+type OverlapKind int
+
+const (
+	// OverlapAmbiguous means that some inputs match both stubs, so the
+	// choice between them is left to the ranking.
+	OverlapAmbiguous OverlapKind = iota
+	// OverlapShadowed means that every input matching the second stub also
+	// matches the first one, so the second stub never matches alone.
+	OverlapShadowed
+)
+
+// Overlap is a pair of stubs which can match the same input, found by Overlaps.
+type Overlap struct {
+	// First and Second are the stubs. A shadowed stub is the second one.
+	First, Second Stub
+	// Kind is the kind of the overlap.
+	Kind OverlapKind
+}
+
+// String returns the overlap as a sentence naming the stubs.
+func (o Overlap) String() string {
+	if o.Kind == OverlapShadowed {
+		return fmt.Sprintf("%s is shadowed by %s", o.Second.Name, o.First.Name)
+	}
+
+	return fmt.Sprintf("%s and %s are ambiguous", o.First.Name, o.Second.Name)
+}
+
+// Overlaps reports the pairs of stubs which can match the same input, in the
+// order of the stubs.
+//
+// Two stubs overlap if their literals don't conflict, their regular
+// expressions intersect (checked with the product of their automata) and
+// their maps and arrays allow a common value in their modes. A stub is
+// shadowed if the other stub subsumes it, e.g. a Contains stub with a subset
+// of its keys. Of two stubs matching the same inputs, the later one is
+// reported as shadowed.
+//
+// The analysis errs on the side of reporting: operator nodes and regular
+// expressions with line or word boundaries are assumed to overlap with
+// anything, and are subsumed only by identical expectations.
+func Overlaps(stubs []Stub) []Overlap {
+	var res []Overlap
+
+	for i := range stubs {
+		for j := i + 1; j < len(stubs); j++ {
+			a, b := stubs[i], stubs[j]
+
+			switch {
+			case subsumes(a.Expect, b.Expect, a.Mode, b.Mode):
+				res = append(res, Overlap{First: a, Second: b, Kind: OverlapShadowed})
+			case subsumes(b.Expect, a.Expect, b.Mode, a.Mode):
+				res = append(res, Overlap{First: b, Second: a, Kind: OverlapShadowed})
+			case intersects(a.Expect, b.Expect, a.Mode, b.Mode):
+				res = append(res, Overlap{First: a, Second: b, Kind: OverlapAmbiguous})
+			}
+		}
+	}
+
+	return res
+}
+
+// expectKind classifies an expectation by the set of values it matches.
+type expectKind int
+
+const (
+	kindLiteral   expectKind = iota // Matches the value itself only.
+	kindRegex                       // Matches the scalars whose string form matches the expression.
+	kindMap                         // Matches the maps of the same type by their keys.
+	kindOrdered                     // Matches the slices of the same length element by element.
+	kindUnordered                   // Matches the slices containing the elements in any order.
+	kindUnknown                     // Matches values the analysis can't describe, e.g. operator nodes.
+)
+
+// kindOf returns the kind of the expectation in the mode.
+func kindOf(expect any, mode Mode) expectKind {
+	if isOperatorNode(expect) {
+		return kindUnknown
+	}
+
+	if s, ok := expect.(string); ok && (mode == ModeMatches || mode == ModeMatchesIgnoreArrayOrder) {
+		if _, err := regexp.Compile(s); err == nil {
+			return kindRegex
+		}
+
+		return kindLiteral
+	}
+
+	if expect == nil {
+		return kindLiteral
+	}
+
+	switch reflect.TypeOf(expect).Kind() { //nolint:exhaustive
+	case reflect.Map:
+		return kindMap
+	case reflect.Slice:
+		switch {
+		case mode.orderedSlices():
+			return kindOrdered
+		case mode.ignoreArrayOrder():
+			return kindUnordered
+		default:
+			return kindLiteral
+		}
+	default:
+		return kindLiteral
+	}
+}
+
+// intersects checks if some value matches both expectations in their modes.
+// It returns true if the question can't be answered.
+func intersects(a, b any, ma, mb Mode) bool {
+	ka, kb := kindOf(a, ma), kindOf(b, mb)
+
+	switch {
+	case ka == kindLiteral:
+		return mb.Match(b, a)
+	case kb == kindLiteral:
+		return ma.Match(a, b)
+	case ka == kindUnknown || kb == kindUnknown:
+		return true
+	case ka == kindRegex && kb == kindRegex:
+		// The expressions match themselves as literal strings too.
+		return a == b || ma.Match(a, b) || mb.Match(b, a) || regexIntersects(a.(string), b.(string))
+	case ka == kindRegex || kb == kindRegex:
+		return false
+	case reflect.TypeOf(a) != reflect.TypeOf(b):
+		return false
+	case ka == kindMap && kb == kindMap:
+		return mapsIntersect(reflect.ValueOf(a), reflect.ValueOf(b), ma, mb)
+	case ka == kindMap || kb == kindMap:
+		return false
+	}
+
+	return slicesIntersect(reflect.ValueOf(a), reflect.ValueOf(b), ma, mb, ka, kb)
+}
+
+// mapsIntersect checks if some map matches both maps in their modes.
+func mapsIntersect(a, b reflect.Value, ma, mb Mode) bool {
+	for _, key := range a.MapKeys() {
+		value := b.MapIndex(key)
+
+		switch {
+		case !value.IsValid() && !mb.partialMaps():
+			return false
+		case value.IsValid() && !intersects(a.MapIndex(key).Interface(), value.Interface(), ma, mb):
+			return false
+		}
+	}
+
+	for _, key := range b.MapKeys() {
+		if !a.MapIndex(key).IsValid() && !ma.partialMaps() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// slicesIntersect checks if some slice matches both slices in their modes.
+func slicesIntersect(a, b reflect.Value, ma, mb Mode, ka, kb expectKind) bool {
+	edge := func(i, j int) bool {
+		return intersects(a.Index(i).Interface(), b.Index(j).Interface(), ma, mb)
+	}
+
+	// Unordered slices constrain the length only in Equals modes.
+	exactA, exactB := ka == kindOrdered || !ma.partialMaps(), kb == kindOrdered || !mb.partialMaps()
+
+	switch {
+	case ka == kindOrdered && kb == kindOrdered:
+		if a.Len() != b.Len() {
+			return false
+		}
+
+		for i := range a.Len() {
+			if !edge(i, i) {
+				return false
+			}
+		}
+
+		return true
+	case exactA && exactB:
+		return a.Len() == b.Len() && matching(a.Len(), b.Len(), edge) == a.Len()
+	case exactA:
+		return b.Len() <= a.Len() && matching(a.Len(), b.Len(), edge) == b.Len()
+	case exactB:
+		return a.Len() <= b.Len() && matching(a.Len(), b.Len(), edge) == a.Len()
+	default:
+		// The actual slice may hold the elements of both.
+		return true
+	}
+}
+
+// subsumes checks if every value matching b in its mode also matches a in its mode.
+// It returns false if the question can't be answered.
+func subsumes(a, b any, ma, mb Mode) bool {
+	if ma == mb && reflect.DeepEqual(a, b) {
+		return true
+	}
+
+	ka, kb := kindOf(a, ma), kindOf(b, mb)
+
+	switch {
+	case kb == kindLiteral:
+		return ma.Match(a, b)
+	case ka == kindUnknown || kb == kindUnknown || ka == kindLiteral:
+		return false
+	case ka == kindRegex && kb == kindRegex:
+		// The expression b matches itself as a literal string too, but no
+		// input is expected to be the text of a pattern.
+		return regexSubsumes(a.(string), b.(string))
+	case ka == kindRegex || kb == kindRegex:
+		return false
+	case reflect.TypeOf(a) != reflect.TypeOf(b):
+		return false
+	case ka == kindMap && kb == kindMap:
+		return mapSubsumes(reflect.ValueOf(a), reflect.ValueOf(b), ma, mb)
+	case ka == kindMap || kb == kindMap:
+		return false
+	}
+
+	return sliceSubsumes(reflect.ValueOf(a), reflect.ValueOf(b), ma, mb, ka, kb)
+}
+
+// mapSubsumes checks if every map matching b also matches a.
+func mapSubsumes(a, b reflect.Value, ma, mb Mode) bool {
+	// Maps matching b may have any keys b doesn't mention, unless b is exact.
+	if !ma.partialMaps() && (mb.partialMaps() || a.Len() != b.Len()) {
+		return false
+	}
+
+	for _, key := range a.MapKeys() {
+		value := b.MapIndex(key)
+		if !value.IsValid() || !subsumes(a.MapIndex(key).Interface(), value.Interface(), ma, mb) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sliceSubsumes checks if every slice matching b also matches a.
+func sliceSubsumes(a, b reflect.Value, ma, mb Mode, ka, kb expectKind) bool {
+	edge := func(i, j int) bool {
+		return subsumes(a.Index(i).Interface(), b.Index(j).Interface(), ma, mb)
+	}
+
+	exactB := kb == kindOrdered || !mb.partialMaps()
+
+	switch {
+	case ka == kindOrdered:
+		if kb != kindOrdered || a.Len() != b.Len() {
+			return false
+		}
+
+		for i := range a.Len() {
+			if !edge(i, i) {
+				return false
+			}
+		}
+
+		return true
+	case !ma.partialMaps():
+		return exactB && a.Len() == b.Len() && matching(a.Len(), b.Len(), edge) == a.Len()
+	default:
+		return matching(a.Len(), b.Len(), edge) == a.Len()
+	}
+}
@@ -0,0 +1,100 @@
+package deeply_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+// overlap returns the overlaps of two stubs as strings.
+func overlap(a, b any, ma, mb deeply.Mode) []string {
+	var res []string
+
+	for _, o := range deeply.Overlaps([]deeply.Stub{{Name: "a", Expect: a, Mode: ma}, {Name: "b", Expect: b, Mode: mb}}) {
+		res = append(res, o.String())
+	}
+
+	return res
+}
+
+func TestOverlaps_Literals(t *testing.T) {
+	require.Empty(t, overlap(map[string]any{"name": "bob"}, map[string]any{"name": "alice"}, deeply.ModeEquals, deeply.ModeEquals))
+	require.Equal(t, []string{"b is shadowed by a"},
+		overlap(map[string]any{"name": "bob"}, map[string]any{"name": "bob"}, deeply.ModeEquals, deeply.ModeEquals))
+	require.Empty(t, overlap(1.0, "1", deeply.ModeEquals, deeply.ModeContains))
+}
+
+func TestOverlaps_Contains(t *testing.T) {
+	require.Equal(t, []string{"b is shadowed by a"},
+		overlap(map[string]any{"name": "bob"}, map[string]any{"name": "bob", "age": 42.0}, deeply.ModeContains, deeply.ModeContains))
+	require.Equal(t, []string{"a is shadowed by b"},
+		overlap(map[string]any{"name": "bob", "age": 42.0}, map[string]any{"name": "bob"}, deeply.ModeContains, deeply.ModeContains))
+	require.Equal(t, []string{"a and b are ambiguous"},
+		overlap(map[string]any{"name": "bob"}, map[string]any{"age": 42.0}, deeply.ModeContains, deeply.ModeContains))
+	require.Empty(t, overlap(map[string]any{"name": "bob"}, map[string]any{"age": 42.0}, deeply.ModeContains, deeply.ModeEquals))
+	require.Equal(t, []string{"b is shadowed by a"},
+		overlap(map[string]any{"name": "bob"}, map[string]any{"name": "bob", "age": 42.0}, deeply.ModeContains, deeply.ModeEquals))
+	require.Empty(t, overlap(map[string]any{"name": "bob"}, map[string]any{"name": "bob", "age": 42.0}, deeply.ModeEquals, deeply.ModeContains))
+}
+
+func TestOverlaps_Regex(t *testing.T) {
+	m := deeply.ModeMatches
+
+	require.Equal(t, []string{"a and b are ambiguous"}, overlap("^a", "b$", m, m))
+	require.Empty(t, overlap("^a+$", "^b+$", m, m))
+	require.Empty(t, overlap("^[0-9]{3}$", "^[0-9]{4}$", m, m))
+	require.Equal(t, []string{"b is shadowed by a"}, overlap("^[0-9]+$", "^[0-9]{4}$", m, m))
+	require.Equal(t, []string{"b is shadowed by a"}, overlap("al", "^alice$", m, m))
+	require.Equal(t, []string{"a is shadowed by b"}, overlap("(?i)^BOB$", "^[a-zA-Z]*[oO]", m, m))
+	require.Equal(t, []string{"a and b are ambiguous"}, overlap("^x.*y$", "^.{3}$", m, m))
+	require.Empty(t, overlap("^x\\d+y$", "^.{2}$", m, m))
+	require.Empty(t, overlap("^a$", "^ab", m, m))
+	require.Equal(t, []string{"a and b are ambiguous"}, overlap("a$", "ba", m, m))
+
+	// Literal inputs are checked by matching them.
+	require.Equal(t, []string{"a is shadowed by b"}, overlap(map[string]any{"id": 42.0}, map[string]any{"id": "^4"}, m, m))
+	require.Empty(t, overlap(map[string]any{"id": 52.0}, map[string]any{"id": "^4"}, m, m))
+
+	// Word boundaries can't be analyzed, so the expressions are assumed to overlap.
+	require.Equal(t, []string{"a and b are ambiguous"}, overlap(`\bcat\b`, "^dog$", m, m))
+}
+
+func TestOverlaps_Slices(t *testing.T) {
+	require.Empty(t, overlap([]any{"^a", "^b"}, []any{"^b", "^a"}, deeply.ModeMatches, deeply.ModeMatches))
+	require.Equal(t, []string{"b is shadowed by a"},
+		overlap([]any{"^a", "^b"}, []any{"^b", "^a"}, deeply.ModeMatchesIgnoreArrayOrder, deeply.ModeMatches))
+	require.Equal(t, []string{"a and b are ambiguous"},
+		overlap([]any{"^a", "^b"}, []any{"^b", "a"}, deeply.ModeMatchesIgnoreArrayOrder, deeply.ModeMatches))
+	require.Equal(t, []string{"b is shadowed by a"},
+		overlap([]any{"b"}, []any{"a", "b"}, deeply.ModeContainsIgnoreArrayOrder, deeply.ModeEqualsIgnoreArrayOrder))
+	require.Empty(t, overlap([]any{"a", "b", "c"}, []any{"a", "b"}, deeply.ModeContainsIgnoreArrayOrder, deeply.ModeEqualsIgnoreArrayOrder))
+	require.Empty(t, overlap([]any{"a", "b"}, []any{"a", "c"}, deeply.ModeEqualsIgnoreArrayOrder, deeply.ModeEqualsIgnoreArrayOrder))
+	require.Equal(t, []string{"a and b are ambiguous"},
+		overlap([]any{"a", "b"}, []any{"c"}, deeply.ModeContainsIgnoreArrayOrder, deeply.ModeContainsIgnoreArrayOrder))
+}
+
+func TestOverlaps_Operators(t *testing.T) {
+	adult := map[string]any{"age": map[string]any{"$gte": 18.0}}
+
+	require.Equal(t, []string{"a and b are ambiguous"},
+		overlap(adult, map[string]any{"age": map[string]any{"$lt": 10.0}}, deeply.ModeContains, deeply.ModeContains))
+	require.Equal(t, []string{"b is shadowed by a"}, overlap(adult, adult, deeply.ModeContains, deeply.ModeContains))
+	require.Equal(t, []string{"b is shadowed by a"}, overlap(adult, map[string]any{"age": 20.0}, deeply.ModeContains, deeply.ModeContains))
+	require.Empty(t, overlap(adult, map[string]any{"age": 10.0}, deeply.ModeContains, deeply.ModeContains))
+}
+
+func TestOverlaps_Order(t *testing.T) {
+	stubs := []deeply.Stub{
+		{Name: "bob", Expect: map[string]any{"name": "bob"}, Mode: deeply.ModeContains},
+		{Name: "alice", Expect: map[string]any{"name": "alice"}, Mode: deeply.ModeContains},
+		{Name: "any", Expect: map[string]any{}, Mode: deeply.ModeContains},
+	}
+
+	overlaps := deeply.Overlaps(stubs)
+	require.Len(t, overlaps, 2)
+	require.Equal(t, deeply.OverlapShadowed, overlaps[0].Kind)
+	require.Equal(t, "bob is shadowed by any", overlaps[0].String())
+	require.Equal(t, "alice is shadowed by any", overlaps[1].String())
+}
@@ -0,0 +1,110 @@
+package deeply
+
+import (
+	"reflect"
+)
+
+// Policy tells a Ranker how to score a kind of difference between the
+// expected and actual maps, see WithExtraKeys, WithMissingKeys,
+// WithTypeMismatch and WithNulls.
+type Policy int
+
+const (
+	// PolicyDefault scores the difference like RankMatch.
+	PolicyDefault Policy = iota
+	// PolicyIgnore leaves the value out of the score of the map, as if its
+	// key was on neither side.
+	PolicyIgnore
+	// PolicyPenalize scores the value 0 and counts its weight in the total
+	// weight of the map, whichever side the value is on.
+	PolicyPenalize
+	// PolicyFatal scores the whole map 0.
+	PolicyFatal
+)
+
+// WithExtraKeys sets the policy for the keys of the actual maps missing
+// from the expected ones. By default they count in the total weight of the
+// map if the actual map weighs more than the expected one, so that
+// {"a": 1} scores the same against {"a": 1, "b": 2} as against
+// {"a": 1, "c": 3}, both lower than against {"a": 1}.
+func WithExtraKeys(policy Policy) RankOption {
+	return func(r *Ranker) {
+		r.extraKeys = policy
+	}
+}
+
+// WithMissingKeys sets the policy for the keys of the expected maps missing
+// from the actual ones. By default they count in the total weight of the
+// map if the expected map weighs more than the actual one.
+func WithMissingKeys(policy Policy) RankOption {
+	return func(r *Ranker) {
+		r.missingKeys = policy
+	}
+}
+
+// WithTypeMismatch sets the policy for the values of the same key whose
+// JSON types differ, e.g. a string expected and an object found. Integers
+// and floating point numbers are of the same type and operator nodes are
+// never mismatched. By default the values are scored like RankMatch does,
+// e.g. a regular expression against the string form of a number.
+func WithTypeMismatch(policy Policy) RankOption {
+	return func(r *Ranker) {
+		r.typeMismatch = policy
+	}
+}
+
+// WithNulls sets the policy for the values of the same key which are nil on
+// one side only. By default nil is scored like RankMatch does, as a value
+// equal to nil only. PolicyIgnore leaves such keys out of the score, which
+// suits payloads where null and absent fields mean the same.
+func WithNulls(policy Policy) RankOption {
+	return func(r *Ranker) {
+		r.nulls = policy
+	}
+}
+
+// pairPolicy returns the policy applying to the values of the same key.
+func (r *Ranker) pairPolicy(expect, actual any) Policy {
+	switch {
+	case (expect == nil) != (actual == nil):
+		return r.nulls
+	case expect == nil || isOperatorNode(expect):
+		return PolicyDefault
+	case valueKind(expect) != valueKind(actual):
+		return r.typeMismatch
+	default:
+		return PolicyDefault
+	}
+}
+
+// countOneSided adds the weight of a value present in one map only to the
+// total weights of the maps according to the policy: own is the total of
+// the map holding the value, other the total of the other one.
+// It returns false if the policy is fatal.
+func countOneSided(policy Policy, weight float64, own, other *float64) bool {
+	switch policy {
+	case PolicyIgnore:
+	case PolicyPenalize:
+		*own += weight
+		*other += weight
+	case PolicyFatal:
+		return false
+	default:
+		*own += weight
+	}
+
+	return true
+}
+
+// valueKind returns the JSON type of a value, with integers and floating
+// point numbers of the same type, or its Go type if it has no JSON type.
+func valueKind(value any) string {
+	switch t := jsonType(value); t {
+	case "integer":
+		return "number"
+	case "":
+		return reflect.TypeOf(value).String()
+	default:
+		return t
+	}
+}
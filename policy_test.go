@@ -0,0 +1,126 @@
+package deeply_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+// keys returns a map with the key "a" and n keys with the prefix.
+func keys(prefix string, n int) map[string]any {
+	res := map[string]any{"a": 1.0}
+	for i := range n {
+		res[fmt.Sprintf("%s%d", prefix, i)] = float64(i)
+	}
+
+	return res
+}
+
+func TestPolicy_ExtraKeys(t *testing.T) {
+	expect := keys("", 0)
+	unit := deeply.NewRanker(deeply.WithExtraKeys(deeply.PolicyIgnore)).RankMatch(expect, keys("extra", 1))
+
+	for n := 1; n <= 4; n++ {
+		actual := keys("extra", n)
+
+		// The extra keys dilute the score by default: 1/2, 1/3, 1/4, 1/5.
+		require.InDelta(t, unit/float64(1+n), deeply.RankMatch(expect, actual), 1e-9, n)
+		require.InDelta(t, unit/float64(1+n),
+			deeply.NewRanker(deeply.WithExtraKeys(deeply.PolicyDefault)).RankMatch(expect, actual), 1e-9, n)
+		require.InDelta(t, unit/float64(1+n),
+			deeply.NewRanker(deeply.WithExtraKeys(deeply.PolicyPenalize)).RankMatch(expect, actual), 1e-9, n)
+
+		// Or don't matter at all.
+		require.InDelta(t, unit,
+			deeply.NewRanker(deeply.WithExtraKeys(deeply.PolicyIgnore)).RankMatch(expect, actual), 1e-9, n)
+
+		// Or rule the map out.
+		require.Zero(t, deeply.NewRanker(deeply.WithExtraKeys(deeply.PolicyFatal)).RankMatch(expect, actual), n)
+	}
+}
+
+func TestPolicy_MissingKeys(t *testing.T) {
+	actual := keys("", 0)
+	unit := deeply.NewRanker(deeply.WithMissingKeys(deeply.PolicyIgnore)).RankMatch(keys("missing", 1), actual)
+
+	for n := 1; n <= 4; n++ {
+		expect := keys("missing", n)
+
+		require.InDelta(t, unit/float64(1+n), deeply.RankMatch(expect, actual), 1e-9, n)
+		require.InDelta(t, unit/float64(1+n),
+			deeply.NewRanker(deeply.WithMissingKeys(deeply.PolicyPenalize)).RankMatch(expect, actual), 1e-9, n)
+		require.InDelta(t, unit,
+			deeply.NewRanker(deeply.WithMissingKeys(deeply.PolicyIgnore)).RankMatch(expect, actual), 1e-9, n)
+		require.Zero(t, deeply.NewRanker(deeply.WithMissingKeys(deeply.PolicyFatal)).RankMatch(expect, actual), n)
+	}
+}
+
+func TestPolicy_MissingAndExtraKeys(t *testing.T) {
+	unit := deeply.NewRanker(
+		deeply.WithMissingKeys(deeply.PolicyIgnore),
+		deeply.WithExtraKeys(deeply.PolicyIgnore),
+	).RankMatch(keys("missing", 1), keys("extra", 1))
+
+	penalize := deeply.NewRanker(deeply.WithMissingKeys(deeply.PolicyPenalize), deeply.WithExtraKeys(deeply.PolicyPenalize))
+	penalizeMissing := deeply.NewRanker(deeply.WithMissingKeys(deeply.PolicyPenalize))
+
+	for n := 1; n <= 4; n++ {
+		expect, actual := keys("missing", n), keys("extra", n)
+
+		// By default the larger map counts only: 1/2, 1/3, 1/4, 1/5.
+		require.InDelta(t, unit/float64(1+n), deeply.RankMatch(expect, actual), 1e-9, n)
+
+		// Penalized keys count on both sides: 1/3, 1/5, 1/7, 1/9.
+		require.InDelta(t, unit/float64(1+2*n), penalize.RankMatch(expect, actual), 1e-9, n)
+		require.InDelta(t, unit/float64(1+2*n), penalizeMissing.RankMatch(expect, actual), 1e-9, n)
+	}
+}
+
+func TestPolicy_TypeMismatch(t *testing.T) {
+	expect := map[string]any{"a": 1.0, "b": "[0-9]"}
+	actual := map[string]any{"a": 1.0, "b": 7}
+
+	unit := deeply.RankMatch(map[string]any{"a": 1.0}, map[string]any{"a": 1.0, "c": 2.0}) * 2
+
+	// The regular expression matches the string form of the number by default.
+	require.InDelta(t, (unit+2)/2, deeply.RankMatch(expect, actual), 1e-9)
+	require.InDelta(t, unit/2,
+		deeply.NewRanker(deeply.WithTypeMismatch(deeply.PolicyPenalize)).RankMatch(expect, actual), 1e-9)
+	require.InDelta(t, unit,
+		deeply.NewRanker(deeply.WithTypeMismatch(deeply.PolicyIgnore)).RankMatch(expect, actual), 1e-9)
+	require.Zero(t, deeply.NewRanker(deeply.WithTypeMismatch(deeply.PolicyFatal)).RankMatch(expect, actual))
+
+	// Numbers of different Go types, operator nodes and nulls are not mismatched.
+	fatal := deeply.NewRanker(deeply.WithTypeMismatch(deeply.PolicyFatal))
+	require.Positive(t, fatal.RankMatch(map[string]any{"a": 1.0, "b": 2}, map[string]any{"a": 1.0, "b": int64(2)}))
+	require.Positive(t, fatal.RankMatch(map[string]any{"a": 1.0, "b": map[string]any{"$gt": "a"}}, map[string]any{"a": 1.0, "b": 7}))
+	require.Positive(t, fatal.RankMatch(map[string]any{"a": 1.0, "b": nil}, map[string]any{"a": 1.0, "b": 7}))
+}
+
+func TestPolicy_Nulls(t *testing.T) {
+	expect := map[string]any{"a": 1.0, "b": "x"}
+	actual := map[string]any{"a": 1.0, "b": nil}
+
+	unit := deeply.RankMatch(map[string]any{"a": 1.0}, map[string]any{"a": 1.0, "c": 2.0}) * 2
+
+	// Nil is a value like any other by default.
+	require.InDelta(t, unit/2, deeply.RankMatch(expect, actual), 1e-9)
+	require.InDelta(t, unit/2, deeply.RankMatch(actual, expect), 1e-9)
+	require.InDelta(t, unit/2,
+		deeply.NewRanker(deeply.WithNulls(deeply.PolicyPenalize)).RankMatch(expect, actual), 1e-9)
+
+	// Or the same as an absent key.
+	ignore := deeply.NewRanker(deeply.WithNulls(deeply.PolicyIgnore))
+	require.InDelta(t, unit, ignore.RankMatch(expect, actual), 1e-9)
+	require.InDelta(t, unit, ignore.RankMatch(actual, expect), 1e-9)
+
+	require.Zero(t, deeply.NewRanker(deeply.WithNulls(deeply.PolicyFatal)).RankMatch(expect, actual))
+
+	// Nulls on both sides are equal.
+	require.InDelta(t,
+		deeply.RankMatch(actual, actual),
+		deeply.NewRanker(deeply.WithNulls(deeply.PolicyFatal)).RankMatch(actual, actual), 1e-9)
+}
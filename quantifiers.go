@@ -0,0 +1,213 @@
+package deeply
+
+import (
+	"reflect"
+)
+
+// elements returns the elements of a slice or an array.
+// It returns false if the value is neither a slice nor an array.
+// A []any is returned as is and must not be modified.
+func elements(value any) ([]any, bool) {
+	// Fast path for decoded JSON arrays.
+	if s, ok := value.([]any); ok {
+		return s, true
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+
+	res := make([]any, v.Len())
+	for i := range v.Len() {
+		res[i] = v.Index(i).Interface()
+	}
+
+	return res, true
+}
+
+// clampScore limits a match score to the range between 0 and 1.
+// RankMatch scores collections above 1, quantifiers need comparable scores.
+func clampScore(score float64) float64 {
+	return min(max(score, 0), 1)
+}
+
+// matchAny checks if at least one element of the actual slice matches $any.
+func matchAny(node map[string]any, actual any, compare cmp) bool {
+	items, ok := elements(actual)
+	if !ok {
+		return false
+	}
+
+	for _, item := range items {
+		if compare(node["$any"], item) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rankAny scores the actual slice by its element closest to $any.
+func rankAny(node map[string]any, actual any, compare ranker) float64 {
+	items, ok := elements(actual)
+	if !ok {
+		return 0
+	}
+
+	var res float64
+
+	for _, item := range items {
+		res = max(res, clampScore(compare(node["$any"], item)))
+	}
+
+	return res
+}
+
+// matchAll checks if every element of the actual slice matches $all.
+// An empty slice matches.
+func matchAll(node map[string]any, actual any, compare cmp) bool {
+	items, ok := elements(actual)
+	if !ok {
+		return false
+	}
+
+	for _, item := range items {
+		if !compare(node["$all"], item) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// rankAllQuantifier scores the actual slice by the average score of its elements against $all.
+func rankAllQuantifier(node map[string]any, actual any, compare ranker) float64 {
+	items, ok := elements(actual)
+	if !ok {
+		return 0
+	}
+
+	if len(items) == 0 {
+		return 1
+	}
+
+	var res float64
+
+	for _, item := range items {
+		res += clampScore(compare(node["$all"], item))
+	}
+
+	return res / float64(len(items))
+}
+
+// matchNone checks if no element of the actual slice matches $none.
+func matchNone(node map[string]any, actual any, compare cmp) bool {
+	items, ok := elements(actual)
+	if !ok {
+		return false
+	}
+
+	for _, item := range items {
+		if compare(node["$none"], item) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// rankNone scores the actual slice by the distance of its element closest to $none.
+func rankNone(node map[string]any, actual any, compare ranker) float64 {
+	items, ok := elements(actual)
+	if !ok {
+		return 0
+	}
+
+	var res float64
+
+	for _, item := range items {
+		res = max(res, clampScore(compare(node["$none"], item)))
+	}
+
+	return 1 - res
+}
+
+// matchCount checks if the number of elements of the actual slice matching
+// $of satisfies $count. Without $of all the elements are counted.
+// $count is either a number or an expectation such as {"$gte": 2}.
+func matchCount(node map[string]any, actual any, compare cmp) bool {
+	items, ok := elements(actual)
+	if !ok {
+		return false
+	}
+
+	count := 0
+
+	for _, item := range items {
+		if of, ok := node["$of"]; !ok || compare(of, item) {
+			count++
+		}
+	}
+
+	return matchNumber(node["$count"], count, compare)
+}
+
+// rankCount scores the number of elements of the actual slice scoring 1
+// against $of by $count.
+func rankCount(node map[string]any, actual any, compare ranker) float64 {
+	items, ok := elements(actual)
+	if !ok {
+		return 0
+	}
+
+	count := 0
+
+	for _, item := range items {
+		if of, ok := node["$of"]; !ok || clampScore(compare(of, item)) == 1 {
+			count++
+		}
+	}
+
+	return rankNumber(node["$count"], count, compare)
+}
+
+// matchSize checks if the length of the actual slice satisfies $size.
+// $size is either a number or an expectation such as {"$lt": 10}.
+func matchSize(node map[string]any, actual any, compare cmp) bool {
+	items, ok := elements(actual)
+	if !ok {
+		return false
+	}
+
+	return matchNumber(node["$size"], len(items), compare)
+}
+
+// rankSize scores the length of the actual slice by $size.
+func rankSize(node map[string]any, actual any, compare ranker) float64 {
+	items, ok := elements(actual)
+	if !ok {
+		return 0
+	}
+
+	return rankNumber(node["$size"], len(items), compare)
+}
+
+// matchNumber checks if the number satisfies the expectation.
+// Numeric expectations are compared by value regardless of their Go type.
+func matchNumber(expect any, n int, compare cmp) bool {
+	if isNumber(expect) {
+		return jsonEqual(expect, n)
+	}
+
+	return compare(expect, n)
+}
+
+// rankNumber scores the number by the expectation.
+func rankNumber(expect any, n int, compare ranker) float64 {
+	if isNumber(expect) {
+		return matchScore(jsonEqual(expect, n))
+	}
+
+	return clampScore(compare(expect, n))
+}
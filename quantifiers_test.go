@@ -0,0 +1,105 @@
+package deeply_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+func TestQuantifiers_Any(t *testing.T) {
+	expect := map[string]any{"$any": map[string]any{"sku": "^A-"}}
+
+	require.True(t, deeply.Matches(expect, []any{
+		map[string]any{"sku": "B-1"},
+		map[string]any{"sku": "A-2"},
+	}))
+	require.False(t, deeply.Matches(expect, []any{map[string]any{"sku": "B-1"}}))
+	require.False(t, deeply.Matches(expect, []any{}))
+	require.False(t, deeply.Matches(expect, map[string]any{"sku": "A-2"}))
+
+	require.True(t, deeply.Equals(map[string]any{"$any": 2}, []int{1, 2, 3}))
+	require.False(t, deeply.Equals(map[string]any{"$any": "^2$"}, []string{"1", "2"}))
+	require.True(t, deeply.Matches(map[string]any{"$any": "^2$"}, []string{"1", "2"}))
+}
+
+func TestQuantifiers_AllNone(t *testing.T) {
+	all := map[string]any{"$all": map[string]any{"qty": map[string]any{"$gt": 0}}}
+
+	require.True(t, deeply.Contains(all, []any{
+		map[string]any{"qty": 1, "sku": "a"},
+		map[string]any{"qty": 2.5, "sku": "b"},
+	}))
+	require.False(t, deeply.Contains(all, []any{
+		map[string]any{"qty": 1},
+		map[string]any{"qty": 0},
+	}))
+	require.True(t, deeply.Contains(all, []any{}))
+
+	none := map[string]any{"$none": "^debug"}
+
+	require.True(t, deeply.Matches(none, []string{"info", "warn"}))
+	require.False(t, deeply.Matches(none, []string{"info", "debug: x"}))
+	require.False(t, deeply.Matches(none, "info"))
+}
+
+func TestQuantifiers_CountSize(t *testing.T) {
+	count := map[string]any{"$count": map[string]any{"$gte": 2}, "$of": map[string]any{"status": "ok"}}
+
+	require.True(t, deeply.Contains(count, []any{
+		map[string]any{"status": "ok"},
+		map[string]any{"status": "failed"},
+		map[string]any{"status": "ok"},
+	}))
+	require.False(t, deeply.Contains(count, []any{
+		map[string]any{"status": "ok"},
+		map[string]any{"status": "failed"},
+	}))
+
+	require.True(t, deeply.Matches(map[string]any{"$count": 2.0, "$of": "^a"}, []string{"ab", "b", "ac"}))
+	require.True(t, deeply.Matches(map[string]any{"$count": 3}, []string{"ab", "b", "ac"}))
+
+	require.True(t, deeply.Matches(map[string]any{"$size": 2.0}, []any{1, 2}))
+	require.False(t, deeply.Matches(map[string]any{"$size": 2}, []any{1, 2, 3}))
+	require.True(t, deeply.Matches(map[string]any{"$size": map[string]any{"$lt": 3}}, []int{1, 2}))
+	require.False(t, deeply.Matches(map[string]any{"$size": 0}, nil))
+
+	expect := map[string]any{
+		"items": map[string]any{
+			"$size": map[string]any{"$gte": 1},
+			"$all":  map[string]any{"price": map[string]any{"$lte": 100}},
+		},
+	}
+
+	require.True(t, deeply.Matches(expect, map[string]any{"items": []any{map[string]any{"price": 10}}}))
+	require.False(t, deeply.Matches(expect, map[string]any{"items": []any{}}))
+	require.False(t, deeply.Matches(expect, map[string]any{"items": []any{map[string]any{"price": 110}}}))
+}
+
+func TestQuantifiers_RankMatch(t *testing.T) {
+	anyB := map[string]any{"$any": "b"}
+
+	require.InDelta(t, 1., deeply.RankMatch(anyB, []string{"a", "b"}), 1e-9)
+	require.Zero(t, deeply.RankMatch(anyB, "b"))
+
+	all := map[string]any{"$all": "a"}
+
+	require.Equal(t,
+		[]any{[]string{"a", "a"}, []string{"a", "b"}, []string{"b", "b"}},
+		ranker(all, []any{[]string{"b", "b"}, []string{"a", "a"}, []string{"a", "b"}}))
+
+	none := map[string]any{"$none": "a"}
+
+	require.InDelta(t, 1., deeply.RankMatch(none, []string{"b", "c"}), 1e-9)
+	require.Zero(t, deeply.RankMatch(none, []string{"b", "a"}))
+
+	require.InDelta(t, 1., deeply.RankMatch(map[string]any{"$count": 1, "$of": "a"}, []string{"a", "b"}), 1e-9)
+	require.Zero(t, deeply.RankMatch(map[string]any{"$count": 2, "$of": "a"}, []string{"a", "b"}))
+
+	size := map[string]any{"$size": map[string]any{"$lte": 2}}
+
+	require.Equal(t,
+		[]any{[]int{1}, []int{1, 2, 3}, []int{1, 2, 3, 4, 5, 6, 7}},
+		ranker(size, []any{[]int{1, 2, 3, 4, 5, 6, 7}, []int{1}, []int{1, 2, 3}}))
+}
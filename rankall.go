@@ -0,0 +1,167 @@
+package deeply
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// rankChunk is the number of candidates a worker of RankAll takes at once.
+const rankChunk = 64
+
+// RankAllOptions configures RankAll.
+type RankAllOptions struct {
+	// Workers is the number of goroutines ranking the candidates,
+	// DefaultWorkers if it is not positive.
+	Workers int
+	// TopK is the number of best candidates returned, all of them if it is not positive.
+	TopK int
+	// MinScore is the score under which the candidates are left out.
+	MinScore float64
+}
+
+// Ranked is a candidate ranked by RankAll.
+type Ranked struct {
+	// Index is the index of the candidate.
+	Index int
+	// Score is the score of the candidate, see RankMatch.
+	Score float64
+}
+
+// DefaultWorkers returns the number of workers RankAll uses by default, the
+// number of goroutines that can run simultaneously (GOMAXPROCS).
+func DefaultWorkers() int {
+	return runtime.GOMAXPROCS(0)
+}
+
+// RankAll ranks the candidate expectations against the actual value like
+// the RankAll method of a Ranker without options.
+func RankAll(ctx context.Context, actual any, candidates []any, opts RankAllOptions) ([]Ranked, error) {
+	return (&Ranker{}).RankAll(ctx, actual, candidates, opts)
+}
+
+// RankAll scores the candidate expectations against the actual value with
+// RankMatch and returns them from the highest score to the lowest, the
+// lowest index first on equal scores. The result is the same for any number
+// of workers.
+//
+// The candidates are shared out in chunks between a bounded pool of workers.
+// Each worker keeps its own TopK best candidates and skips the candidates
+// which can't make it cheaply, see RankMatchAtLeast; the results of the
+// workers are merged at the end.
+//
+// RankAll stops and returns the error of the context as soon as it is done.
+func (r *Ranker) RankAll(ctx context.Context, actual any, candidates []any, opts RankAllOptions) ([]Ranked, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers()
+	}
+
+	workers = max(min(workers, (len(candidates)+rankChunk-1)/rankChunk), 1)
+
+	var (
+		next    atomic.Int64 // Index of the next chunk.
+		wg      sync.WaitGroup
+		results = make([][]Ranked, workers)
+	)
+
+	for w := range workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			top := topK{k: opts.TopK}
+
+			for {
+				start := int(next.Add(1)-1) * rankChunk
+				if start >= len(candidates) {
+					break
+				}
+
+				for i := start; i < min(start+rankChunk, len(candidates)); i++ {
+					if ctx.Err() != nil {
+						return
+					}
+
+					score, ok := r.RankMatchAtLeast(candidates[i], actual, max(opts.MinScore, top.threshold()))
+					if ok {
+						top.add(Ranked{Index: i, Score: score})
+					}
+				}
+			}
+
+			results[w] = top.items
+		}()
+	}
+
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	merged := topK{k: opts.TopK, items: slices.Concat(results...)}
+	slices.SortFunc(merged.items, compareRanked)
+
+	return merged.truncate(), nil
+}
+
+// compareRanked orders the ranked candidates from the highest score to the
+// lowest, and by their index on equal scores.
+func compareRanked(a, b Ranked) int {
+	switch {
+	case a.Score > b.Score:
+		return -1
+	case a.Score < b.Score:
+		return 1
+	default:
+		return a.Index - b.Index
+	}
+}
+
+// topK keeps the k best ranked candidates in order, all of them if k is not
+// positive.
+type topK struct {
+	k     int
+	items []Ranked
+}
+
+// threshold returns the score a candidate must reach to be kept, 0 until k
+// candidates are kept.
+func (t *topK) threshold() float64 {
+	if t.k <= 0 || len(t.items) < t.k {
+		return 0
+	}
+
+	return t.items[len(t.items)-1].Score
+}
+
+// add keeps the ranked candidate if it is among the k best ones.
+func (t *topK) add(item Ranked) {
+	// All the candidates are kept, they are sorted once merged.
+	if t.k <= 0 {
+		t.items = append(t.items, item)
+
+		return
+	}
+
+	i, _ := slices.BinarySearchFunc(t.items, item, compareRanked)
+	if i >= t.k {
+		return
+	}
+
+	t.items = slices.Insert(t.items, i, item)
+	t.items = t.truncate()
+}
+
+// truncate returns the k best ranked candidates.
+func (t *topK) truncate() []Ranked {
+	if t.k > 0 && len(t.items) > t.k {
+		return t.items[:t.k]
+	}
+
+	return t.items
+}
@@ -0,0 +1,135 @@
+package deeply_test
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+// stubs returns n candidate expectations, some of them scoring the same.
+func stubs(n int) []any {
+	res := make([]any, n)
+
+	for i := range n {
+		switch i % 3 {
+		case 0:
+			res[i] = map[string]any{"name": fmt.Sprintf("user%d", i%50), "age": float64(i % 7)}
+		case 1:
+			res[i] = map[string]any{"name": "^user1", "tags": []any{"a", fmt.Sprint(i % 4)}}
+		default:
+			res[i] = fmt.Sprintf("user%d", i)
+		}
+	}
+
+	return res
+}
+
+// rankAll ranks the candidates one by one with RankMatch.
+func rankAll(r *deeply.Ranker, actual any, candidates []any, topK int, minScore float64) []deeply.Ranked {
+	var res []deeply.Ranked
+
+	for i, candidate := range candidates {
+		if score := r.RankMatch(candidate, actual); score >= minScore {
+			res = append(res, deeply.Ranked{Index: i, Score: score})
+		}
+	}
+
+	slices.SortStableFunc(res, func(a, b deeply.Ranked) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	if topK > 0 && len(res) > topK {
+		res = res[:topK]
+	}
+
+	return res
+}
+
+func TestRankAll_Deterministic(t *testing.T) {
+	actual := map[string]any{"name": "user12", "age": 5.0, "tags": []any{"a", "2"}}
+	candidates := stubs(500)
+	ranker := deeply.NewRanker(deeply.WithWeight("$.name", 3))
+
+	for _, topK := range []int{0, 1, 10, 2000} {
+		expected := rankAll(ranker, actual, candidates, topK, 0)
+
+		for _, workers := range []int{0, 1, 2, 3, 16} {
+			res, err := ranker.RankAll(context.Background(), actual, candidates, deeply.RankAllOptions{Workers: workers, TopK: topK})
+			require.NoError(t, err)
+			require.Equal(t, expected, res, "topK %d, workers %d", topK, workers)
+		}
+	}
+}
+
+func TestRankAll_MinScore(t *testing.T) {
+	candidates := []any{"hello", "help", "world", "hello", "zzzzz"}
+
+	res, err := deeply.RankAll(context.Background(), "hello", candidates, deeply.RankAllOptions{MinScore: .5})
+	require.NoError(t, err)
+	require.Equal(t, []deeply.Ranked{{Index: 0, Score: 1}, {Index: 3, Score: 1}, {Index: 1, Score: .6}}, res)
+
+	res, err = deeply.RankAll(context.Background(), "hello", candidates, deeply.RankAllOptions{MinScore: .5, TopK: 1})
+	require.NoError(t, err)
+	require.Equal(t, []deeply.Ranked{{Index: 0, Score: 1}}, res)
+}
+
+func TestRankAll_Empty(t *testing.T) {
+	res, err := deeply.RankAll(context.Background(), "hello", nil, deeply.RankAllOptions{})
+	require.NoError(t, err)
+	require.Empty(t, res)
+}
+
+func TestRankAll_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res, err := deeply.RankAll(ctx, "user1", stubs(1000), deeply.RankAllOptions{Workers: 4})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Nil(t, res)
+}
+
+func TestRankAll_Concurrent(t *testing.T) {
+	ranker := deeply.NewRanker(deeply.WithStringFold(deeply.FoldCase), deeply.WithWeight("$.name", 2))
+	candidates := stubs(300)
+	expected := rankAll(ranker, "USER1", candidates, 5, 0)
+
+	// The ranker is shared by the calls and their workers.
+	for range 4 {
+		t.Run("", func(t *testing.T) {
+			t.Parallel()
+
+			res, err := ranker.RankAll(context.Background(), "USER1", candidates, deeply.RankAllOptions{Workers: 4, TopK: 5})
+			require.NoError(t, err)
+			require.Equal(t, expected, res)
+		})
+	}
+}
+
+func TestDefaultWorkers(t *testing.T) {
+	require.Equal(t, runtime.GOMAXPROCS(0), deeply.DefaultWorkers())
+}
+
+func BenchmarkRankAll(b *testing.B) {
+	actual := map[string]any{"name": "user12", "age": 5.0, "tags": []any{"a", "2"}}
+	candidates := stubs(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		_, _ = deeply.RankAll(context.Background(), actual, candidates, deeply.RankAllOptions{TopK: 10})
+	}
+}
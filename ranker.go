@@ -3,6 +3,8 @@ package deeply
 import (
 	"reflect"
 	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cast"
 )
@@ -10,11 +12,41 @@ import (
 // Ranker is a function type used to rank matches between two values.
 type ranker func(expect, actual any) float64
 
+// Ranker calculates match scores like RankMatch, with options such as the
+// weights of the fields. The zero value scores like RankMatch.
+type Ranker struct {
+	weights map[string]float64 // Weights of the values keyed by their paths.
+
+	extraKeys    Policy // Policy for the keys of the actual maps only.
+	missingKeys  Policy // Policy for the keys of the expected maps only.
+	typeMismatch Policy // Policy for the values of the same key of different types.
+	nulls        Policy // Policy for the values of the same key nil on one side only.
+
+	tolerance tolerance  // Differences under which numbers are equal.
+	fold      StringFold // Transformations applied to strings before their distance.
+}
+
+// RankOption configures a Ranker.
+type RankOption func(*Ranker)
+
+// NewRanker returns a Ranker configured by the options.
+func NewRanker(opts ...RankOption) *Ranker {
+	r := &Ranker{}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
 // RankMatch calculates a match score between expected and actual values.
 //
 // This function uses recursive matching for maps and slices and assesses
 // the match for other types. The final score is the cumulative result of
-// matches for maps, slices, and other values.
+// matches for maps, slices, and other values. Operator nodes are scored
+// by their operators and values of a type with a comparator by the
+// comparator, see RegisterTypeComparator.
 //
 // Parameters:
 //   - expected: The expected value.
@@ -23,28 +55,98 @@ type ranker func(expect, actual any) float64
 // Returns:
 //   - A float64 representing the cumulative match score.
 func RankMatch(expected, actual any) float64 {
+	return (&Ranker{}).RankMatch(expected, actual)
+}
+
+// RankMatch calculates a match score between expected and actual values
+// like the RankMatch function, using the options of the ranker.
+func (r *Ranker) RankMatch(expected, actual any) float64 {
+	return r.rankMatch(actual, "$", expected, actual)
+}
+
+// rankMatch calculates the match score of the values at the path of the
+// actual document root, read by $expr.
+func (r *Ranker) rankMatch(root any, path string, expected, actual any) float64 {
 	// Special case handling for empty maps.
 	if value, ok := expected.(map[string]any); ok && len(value) == 0 {
 		return 0.1 //nolint:mnd
 	}
 
+	// Operator nodes are scored by their operators.
+	if node, ok := asOperatorNode(expected); ok {
+		return node.rank(actual, r.at(root, path), root)
+	}
+
+	// Values of types with a comparator are scored by the comparator.
+	if score, ok := rankByType(expected, actual); ok {
+		return score
+	}
+
 	// Calculate the match score for non-collection types.
-	score := rank(expected, actual)
+	score := r.rank(expected, actual, 0)
 
 	// Include scores from slice comparisons.
-	score += slicesRankMatch(expected, actual, RankMatch)
+	score += r.slicesRankMatch(root, path, expected, actual)
 
 	// Include scores from map comparisons.
-	score += mapRankMatch(expected, actual, RankMatch)
+	score += r.mapRankMatch(root, path, expected, actual)
 
 	// Return the total match score.
 	return score
 }
 
-// rank is a function that ranks the matches between two strings.
+// at returns the ranker function scoring the values at the path of the root.
+func (r *Ranker) at(root any, path string) ranker {
+	return func(expect, actual any) float64 {
+		return r.rankMatch(root, path, expect, actual)
+	}
+}
+
+// RankMatchAtLeast calculates the match score like RankMatch, giving up as
+// soon as the score is known to be lower than minScore. It returns false if
+// the score is lower than minScore, the score is then meaningless.
 //
-// It compares two strings and returns a float64 representing the match score.
-// The function first checks if the actual value is a boolean and returns 0 if it is.
+// Strings are compared with a Levenshtein distance bounded by minScore, so
+// loops looking for the best candidate can pass the best score so far and
+// skip the hopeless candidates cheaply, see BestMatch.
+func (r *Ranker) RankMatchAtLeast(expected, actual any, minScore float64) (float64, bool) {
+	score := r.rankMatchAtLeast(actual, "$", expected, actual, minScore)
+
+	return score, score >= minScore
+}
+
+// BestMatch returns the index of the candidate expectation scoring the
+// highest against the actual value and its score, the first one if several
+// do. It returns -1 if there are no candidates.
+func (r *Ranker) BestMatch(actual any, candidates []any) (int, float64) {
+	best, bestScore := -1, 0.0
+
+	for i, candidate := range candidates {
+		if score, ok := r.RankMatchAtLeast(candidate, actual, bestScore); ok && (best < 0 || score > bestScore) {
+			best, bestScore = i, score
+		}
+	}
+
+	return best, bestScore
+}
+
+// rankMatchAtLeast calculates the match score of the values at the path.
+// Scores lower than minScore may be reported as 0.
+func (r *Ranker) rankMatchAtLeast(root any, path string, expected, actual any, minScore float64) float64 {
+	// Expected strings are scored by rank alone, which can give up early.
+	if _, ok := expected.(string); ok && !hasTypeComparator(expected, actual) {
+		return r.rank(expected, actual, minScore)
+	}
+
+	return r.rankMatch(root, path, expected, actual)
+}
+
+// rank is a function that ranks the matches between two values.
+//
+// It compares two values and returns a float64 representing the match score.
+// Nil matches nil only and booleans match equal booleans only.
+// Times are scored by their closeness, see timeCloseness, and numbers by
+// their relative difference within the tolerance of the ranker, see numberScore.
 // Then it converts the expected and actual values to strings. If the values are not
 // strings or if there is an error converting them to strings, the function checks
 // if the values are deeply equal and returns the corresponding match score.
@@ -53,30 +155,55 @@ func RankMatch(expected, actual any) float64 {
 // and finds the first match in the actual string. If a match is found, the function
 // calculates the match score based on the length of the match. If no match is found,
 // the function calculates the match score based on the Levenshtein distance
-// between the two strings.
+// between the two strings after the folding of the ranker, giving up as soon
+// as the score is known to be lower than minScore.
 //
 // Parameters:
-// - expect: The expected string.
-// - actual: The actual string.
+// - expect: The expected value.
+// - actual: The actual value.
+// - minScore: The score under which the strings may be scored 0.
 //
 // Returns:
-// - The match score between the expected and actual strings.
-func rank(expect, actual any) float64 {
-	// Check if the actual value is a boolean and return 0 if it is.
-	if _, ok := actual.(bool); ok {
-		return 0
+// - The match score between the expected and actual values.
+func (r *Ranker) rank(expect, actual any, minScore float64) float64 {
+	// Nil matches nil only.
+	if expect == nil || actual == nil {
+		return matchScore(expect == nil && actual == nil)
+	}
+
+	// Booleans match equal booleans only.
+	if b, ok := actual.(bool); ok {
+		return matchScore(expect == b)
+	}
+
+	// Score times by their closeness instead of the distance between strings.
+	if score, ok := rankTime(expect, actual); ok {
+		return score
+	}
+
+	// Score numbers by their closeness, numeric strings included.
+	if isNumber(expect) {
+		if a, ok := toNumber(actual); ok {
+			return r.numberScore(toFloat(expect), a)
+		}
 	}
 
 	// Convert the expected and actual values to strings.
+	expectedStr, ok := expect.(string)
+
 	var (
-		expectedStr, expectedStringOk = expect.(string)
-		actualStr, actualStringErr    = cast.ToStringE(actual)
+		actualStr string
+		err       error
 	)
 
+	if ok {
+		actualStr, err = cast.ToStringE(actual)
+	}
+
 	// If the values are not strings or if there is an error converting them to strings,
 	// check if the values are deeply equal and return the corresponding match score.
-	if !expectedStringOk || actualStringErr != nil {
-		if reflect.DeepEqual(expect, actual) {
+	if !ok || err != nil {
+		if deepEqual(expect, actual) {
 			return 1 // Full match.
 		}
 
@@ -104,27 +231,60 @@ func rank(expect, actual any) float64 {
 
 	// If no match is found, calculate the match score based on the Levenshtein
 	// distance between the two strings.
-	return distance(expectedStr, actualStr)
+	return r.stringScore(expectedStr, actualStr, minScore)
 }
 
 // mapRankMatch calculates the match score between two maps.
 //
 // It iterates over the keys of the left map and finds the corresponding key in
 // the right map. If a match is found, it calculates the match score between
-// the values of the keys and adds it to the total score. It marks the keys
-// that have been matched to avoid duplicate matches. The function returns the
-// total score divided by the maximum number of keys in the two maps.
+// the values of the keys and adds it to the total score, multiplied by the
+// weight of the value. Then it does the same for the keys of the right map,
+// so the keys present in both maps are scored once from each side. The
+// function returns the total score divided by the maximum total weight of the
+// keys in the two maps.
+//
+// The keys are iterated in order, so that the scores are summed in the same
+// order and the same maps always score the same. Maps of the type
+// map[string]any, the maps decoded from JSON, are iterated without reflection.
 //
 // Parameters:
+//   - path: The path of the maps.
 //   - expect: The expected map.
 //   - actual: The actual map.
-//   - compare: The ranker function used to compare values.
 //
 // Returns:
 //   - The match score between the expected and actual maps.
 //
 //nolint:cyclop
-func mapRankMatch(expect, actual any, compare ranker) float64 {
+func (r *Ranker) mapRankMatch(root any, path string, expect, actual any) float64 {
+	var score mapScore
+
+	if left, ok := expect.(map[string]any); ok {
+		right, ok := actual.(map[string]any)
+		if !ok {
+			return 0
+		}
+
+		var buf [16]string // Holds the keys of small maps without allocation.
+
+		for _, k := range appendSortedKeys(buf[:0], left) {
+			rv, ok := right[k]
+			if !r.addLeft(&score, root, r.child(path, k), left[k], rv, ok) {
+				return 0
+			}
+		}
+
+		for _, k := range appendSortedKeys(buf[:0], right) {
+			l, ok := left[k]
+			if !r.addRight(&score, root, r.child(path, k), l, right[k], ok) {
+				return 0
+			}
+		}
+
+		return score.result()
+	}
+
 	// Check if the types of the expected and actual values are the same.
 	// If they are not, return 0.
 	if reflect.TypeOf(expect) != reflect.TypeOf(actual) {
@@ -147,70 +307,151 @@ func mapRankMatch(expect, actual any, compare ranker) float64 {
 	left := reflect.ValueOf(expect)
 	right := reflect.ValueOf(actual)
 
-	// Initialize the total score.
-	var res float64
-
-	// Calculate the maximum number of keys in the two maps.
-	total := max(left.Len(), right.Len())
-
-	// Create a map to keep track of the keys that have been matched.
-	marked := make(map[reflect.Value]bool, total)
-
 	// Iterate over the keys of the left map.
-	for _, k := range left.MapKeys() {
-		// If the corresponding key exists in the right map, calculate the match
-		// score between the values and add it to the total score.
-		// Mark the key as matched.
-		if right.MapIndex(k).IsValid() {
-			res += compare(left.MapIndex(k).Interface(), right.MapIndex(k).Interface())
-			marked[right.MapIndex(k)] = true
+	for _, k := range rankKeys(left) {
+		rv := right.MapIndex(k)
+		if !r.addLeft(&score, root, r.child(path, k.Interface()), left.MapIndex(k).Interface(), valueOf(rv), rv.IsValid()) {
+			return 0
 		}
 	}
 
 	// Iterate over the keys of the right map.
-	// If a key has not been marked as matched, calculate the match score between
-	// the corresponding values in the left and right maps and add it to the total
-	// score.
-	for _, k := range right.MapKeys() {
-		if _, ok := marked[k]; ok {
-			continue
+	for _, k := range rankKeys(right) {
+		lv := left.MapIndex(k)
+		if !r.addRight(&score, root, r.child(path, k.Interface()), valueOf(lv), right.MapIndex(k).Interface(), lv.IsValid()) {
+			return 0
 		}
+	}
 
-		if left.MapIndex(k).IsValid() {
-			res += compare(left.MapIndex(k).Interface(), right.MapIndex(k).Interface())
-		}
+	return score.result()
+}
+
+// appendSortedKeys appends the sorted keys of the map to the slice.
+func appendSortedKeys(keys []string, m map[string]any) []string {
+	for k := range m {
+		keys = append(keys, k)
 	}
 
-	// If the total score is 0 and the maximum number of keys is 0, return 1.
-	if res == 0 && total == 0 {
+	slices.Sort(keys)
+
+	return keys
+}
+
+// rankKeys returns the keys of a map in order: strings are sorted as such,
+// the other keys by their string representation like sortedKeys.
+func rankKeys(m reflect.Value) []reflect.Value {
+	if m.Type().Key().Kind() != reflect.String {
+		return sortedKeys(m)
+	}
+
+	keys := m.MapKeys()
+
+	slices.SortFunc(keys, func(a, b reflect.Value) int {
+		return strings.Compare(a.String(), b.String())
+	})
+
+	return keys
+}
+
+// mapScore accumulates the match score of two maps and the total weights of
+// their keys.
+type mapScore struct {
+	res, leftTotal, rightTotal float64
+}
+
+// result returns the total score divided by the maximum total weight of the
+// keys in the two maps, or 1 if both are 0.
+func (s *mapScore) result() float64 {
+	total := max(s.leftTotal, s.rightTotal)
+
+	if s.res == 0 && total == 0 {
 		return 1
 	}
 
-	// Return the total score divided by the maximum number of keys.
-	return res / float64(total)
+	return s.res / total
+}
+
+// valueOf returns the interface of the value, or nil if it is not valid.
+func valueOf(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+
+	return v.Interface()
+}
+
+// addLeft adds the score of a key of the left map to the map score. The
+// right value is present if ok. It returns false if a policy rules the maps
+// out.
+func (r *Ranker) addLeft(s *mapScore, root any, path string, left, right any, ok bool) bool {
+	weight := r.weight(path, left)
+
+	// Count the keys missing from the right map according to the policy.
+	if !ok {
+		return countOneSided(r.missingKeys, weight, &s.leftTotal, &s.rightTotal)
+	}
+
+	policy := r.pairPolicy(left, right)
+
+	switch policy {
+	case PolicyIgnore:
+		return true
+	case PolicyFatal:
+		return false
+	case PolicyPenalize:
+		s.leftTotal += weight
+	default:
+		s.leftTotal += weight
+		s.res += weight * r.rankMatch(root, path, left, right)
+	}
+
+	return true
+}
+
+// addRight adds the score of a key of the right map to the map score. The
+// left value is present if ok. It returns false if a policy rules the maps
+// out.
+func (r *Ranker) addRight(s *mapScore, root any, path string, left, right any, ok bool) bool {
+	// Count the keys missing from the left map according to the policy.
+	if !ok {
+		return countOneSided(r.extraKeys, r.weight(path, nil), &s.rightTotal, &s.leftTotal)
+	}
+
+	policy := r.pairPolicy(left, right)
+	if policy == PolicyIgnore {
+		return true
+	}
+
+	weight := r.weight(path, left)
+	s.rightTotal += weight
+
+	if policy != PolicyPenalize {
+		s.res += weight * r.rankMatch(root, path, left, right)
+	}
+
+	return true
 }
 
 // slicesRankMatch is a function that calculates the match score between two
-// slices or maps. It takes the expected and actual values and a ranker
-// function that compares two values and returns a match score between 0 and 1.
+// slices or maps. It takes the root of the document, the path of the values
+// and the expected and actual values, and compares the elements with the ranker.
 //
-// The ranker function is called for each pair of values in the slices or maps,
-// and the match scores are accumulated. The function returns the accumulated
-// match score divided by the maximum number of values in the slices or maps.
+// The elements are compared in pairs, and the match scores multiplied by the
+// weights of the expected elements are accumulated. The function returns the
+// accumulated match score divided by the maximum total weight of the values
+// in the slices.
 //
 // If the types of the expected and actual values are not equal, the function
 // returns 0. If either the expected or actual value is nil, the function
 // returns 1. If the types of the expected and actual values are not slice or
 // map, the function returns 0.
 //
-// The ranker function is called for each pair of values in the slices or maps,
-// and the match scores are accumulated. The function returns the accumulated
-// match score divided by the maximum number of values in the slices or maps.
+// Every value of the right slice is paired with at most one value of the
+// left slice: equal values are paired first, then each remaining value is
+// paired with its best scoring counterpart.
 //
-// The function uses a marked algorithm to avoid redundant comparisons.
-//
-//nolint:cyclop
-func slicesRankMatch(expect, actual any, compare ranker) float64 {
+//nolint:cyclop,funlen
+func (r *Ranker) slicesRankMatch(root any, path string, expect, actual any) float64 {
 	// Check if the types of the expected and actual values are equal.
 	if reflect.TypeOf(expect) != reflect.TypeOf(actual) {
 		return 0
@@ -221,165 +462,82 @@ func slicesRankMatch(expect, actual any, compare ranker) float64 {
 		return 1
 	}
 
-	// Convert the expected and actual values to reflect.Value.
-	a := reflect.ValueOf(expect)
-	b := reflect.ValueOf(actual)
-
 	// If the types of the expected and actual values are not slice, return 0.
-	if a.Kind() != reflect.Slice || b.Kind() != reflect.Slice {
+	if reflect.TypeOf(expect).Kind() != reflect.Slice {
 		return 0
 	}
 
-	var res float64 // Initialize the total score.
+	// Get the elements of the slices, without reflection for decoded JSON arrays.
+	a, _ := elements(expect)
+	b, _ := elements(actual)
 
-	marked := make(map[int]struct{}, b.Len()) // Create a map to keep track of the keys that have been marked as matched.
-
-	// Iterate over the values of the left slice.
-	for i := range a.Len() {
-		// Iterate over the values of the right slice.
-		for j := range b.Len() {
-			// Skip the value if it has already been marked as matched.
-			if _, ok := marked[j]; ok {
-				continue
-			}
-
-			// Calculate the match score between the values of the indices and
-			// add it to the total score if the result is not 0.
-			if result := compare(a.Index(i).Interface(), b.Index(j).Interface()); result != 0 {
-				res += result
-				marked[j] = struct{}{}
-			}
-		}
-	}
+	var res float64 // Initialize the total score.
 
-	total := max(a.Len(), b.Len()) // Calculate the maximum number of values in the two slices.
+	marked := make([]bool, len(b)) // Keep track of the values of the right slice that have been matched.
+	paired := make([]bool, len(a)) // Keep track of the values of the left slice that have been matched.
 
-	// If the total score is 0 and the maximum number of values is 0, return 1.
-	if res == 0 && total == 0 {
-		return 1
-	}
+	// The paths and the weights of the values of the left slice.
+	paths := make([]string, len(a))
+	weights := make([]float64, len(a))
 
-	// Return the total score divided by the maximum number of values.
-	return res / float64(total)
-}
+	var leftTotal, rightTotal float64
 
-// distance calculates the Levenshtein distance between two strings.
-// It returns a float64 representing the distance normalized by the length of the
-// longer string.
-//
-// The Levenshtein distance is a measure of the number of single-character edits
-// needed to transform one string into another, such as insertion, deletion, or
-// substitution.
-//
-// Parameters:
-// - s: The first string.
-// - t: The second string.
-func distance(s, t string) float64 {
-	// Fast path for identical strings
-	if s == t {
-		return 1.0
+	for i := range a {
+		paths[i] = r.child(path, i)
+		weights[i] = r.weight(paths[i], a[i])
+		leftTotal += weights[i]
 	}
 
-	// Fast path for empty strings
-	lenS, lenT := len(s), len(t)
-	if lenS == 0 || lenT == 0 {
-		return 0.0
+	for j := range b {
+		rightTotal += r.weight(r.child(path, j), nil)
 	}
 
-	// ASCII fast path optimization (common case)
-	if isASCII(s) && isASCII(t) {
-		return distanceASCII(s, t)
-	}
+	// Pair the equal values first, so that a slice scores the most against itself.
+	for i := range a {
+		for j := range b {
+			if !marked[j] && deepEqual(a[i], b[j]) {
+				res += weights[i] * r.rankMatch(root, paths[i], a[i], b[j])
+				marked[j], paired[i] = true, true
 
-	// Unicode path for non-ASCII strings
-	r1, r2 := []rune(s), []rune(t)
-	len1, len2 := len(r1), len(r2)
-
-	column := make([]int, len1+1)
-	for y := range column {
-		column[y] = y
-	}
-
-	for x := 1; x <= len2; x++ {
-		r2char := r2[x-1]
-		column[0] = x
-		lastDiag := x - 1
-
-		for y := 1; y <= len1; y++ {
-			oldDiag := column[y]
-
-			cost := 0
-			if r1[y-1] != r2char {
-				cost = 1
+				break
 			}
-
-			column[y] = min(
-				column[y]+1, // deletion
-				min(column[y-1]+1, // insertion
-					lastDiag+cost), // substitution
-			)
-
-			lastDiag = oldDiag
 		}
 	}
 
-	maxLength := max(len1, len2)
-
-	return (float64(maxLength) - float64(column[len1])) / float64(maxLength)
-}
-
-// ASCII-optimized version with stack-allocated buffer.
-func distanceASCII(s, t string) float64 {
-	lenS, lenT := len(s), len(t)
-
-	// Use stack allocation for small strings (common case)
-	const maxStackLen = 64
-
-	var (
-		columnStack [maxStackLen]int
-		column      []int
-	)
-
-	if lenS+1 <= maxStackLen {
-		column = columnStack[:lenS+1]
-	} else {
-		column = make([]int, lenS+1)
-	}
-
-	// Initialize column using copy for small sizes
-	if lenS+1 <= maxStackLen {
-		copy(column, columnStack[:])
-	} else {
-		for y := range column {
-			column[y] = y
+	// Pair each remaining value of the left slice with the value of the right
+	// slice it scores best against, and add the score to the total score.
+	for i := range a {
+		if paired[i] {
+			continue
 		}
-	}
-
-	for x := 1; x <= lenT; x++ {
-		tChar := t[x-1]
-		column[0] = x
-		lastDiag := x - 1
 
-		for y := 1; y <= lenS; y++ {
-			oldDiag := column[y]
+		best, bestIndex := 0.0, -1
 
-			cost := 0
-			if s[y-1] != tChar {
-				cost = 1
+		for j := range b {
+			if marked[j] {
+				continue
 			}
 
-			column[y] = min(
-				column[y]+1,
-				min(column[y-1]+1, lastDiag+cost),
-			)
+			if result := r.rankMatchAtLeast(root, paths[i], a[i], b[j], best); result > best {
+				best, bestIndex = result, j
+			}
+		}
 
-			lastDiag = oldDiag
+		if bestIndex >= 0 {
+			res += weights[i] * best
+			marked[bestIndex] = true
 		}
 	}
 
-	maxLength := max(lenS, lenT)
+	total := max(leftTotal, rightTotal) // Calculate the maximum total weight of the values in the two slices.
+
+	// If the total score is 0 and the maximum total weight is 0, return 1.
+	if res == 0 && total == 0 {
+		return 1
+	}
 
-	return (float64(maxLength) - float64(column[lenS])) / float64(maxLength)
+	// Return the total score divided by the maximum total weight.
+	return res / total
 }
 
 // isASCII checks if a string contains only ASCII characters.
@@ -80,3 +80,27 @@ func BenchmarkDistance_OneEmpty(b *testing.B) {
 		distance(s, "")
 	}
 }
+
+func BenchmarkDistance_BoundedLargeASCII(b *testing.B) {
+	s1 := "Lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor incididunt ut labore et dolore magna aliqua"
+	s2 := "Ut enim ad minim veniam quis nostrud exercitation ullamco laboris nisi ut aliquip ex ea commodo consequat duis aute irure"
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		distanceAtLeast(s1, s2, .9) //nolint:mnd
+	}
+}
+
+func BenchmarkDistance_SmallGraphemes(b *testing.B) {
+	s1 := "kø̃tten" // Norwegian/Danish characters
+	s2 := "sø̃tting"
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		graphemeDistanceAtLeast(s1, s2, 0)
+	}
+}
@@ -2,6 +2,7 @@ package deeply_test
 
 import (
 	"cmp"
+	"math"
 	"slices"
 	"testing"
 
@@ -125,6 +126,25 @@ func TestRankMatch_Boundary(t *testing.T) {
 	require.Greater(t, deeply.RankMatch(map[string]any{}, map[string]any{}), 0.)
 }
 
+func TestRankMatch_Slices_Pairing(t *testing.T) {
+	// Each actual element counts for one expected element only.
+	require.InDelta(t, 1./3, deeply.RankMatch([]any{"abc"}, []any{"abc", "abd", "abe"}), 1e-9)
+
+	// The equal elements are paired first, whatever their order.
+	require.InDelta(t, 1., deeply.RankMatch([]any{"abc", "abd"}, []any{"abd", "abc"}), 1e-9)
+}
+
+func TestRankMatch_Distance(t *testing.T) {
+	// ASCII strings score like the other strings.
+	require.Zero(t, deeply.RankMatch("abc", "x"))
+	require.Zero(t, deeply.RankMatch("äbc", "x"))
+	require.InDelta(t, .75, deeply.RankMatch("abcd", "xbcd"), 1e-9)
+
+	// Different invalid bytes are different characters.
+	require.InDelta(t, .5, deeply.RankMatch("a\xff", "a\xfe"), 1e-9)
+	require.Zero(t, deeply.RankMatch("\xff\xfe", "\xfe\xff"))
+}
+
 func TestRankMatch_RegularDigits(t *testing.T) {
 	require.Greater(t, deeply.RankMatch("[0-9]", 9), 0.)
 	require.Greater(t, deeply.RankMatch("^100[1-2]{2}\\d{0,3}$", 10012), .1)
@@ -134,3 +154,37 @@ func TestRankMatch_RegularDigits(t *testing.T) {
 		map[any]any{"vint64": 10012},
 	), 0.)
 }
+
+func TestRankMatch_Numbers(t *testing.T) {
+	require.Equal(t, []any{100, 101, 120, 900, -100}, ranker(100, []any{900, -100, 120, 101, 100}))
+	require.Equal(t, []any{100.0, "101", 900.0}, ranker(100, []any{900.0, "101", 100.0}), "numeric strings are numbers")
+
+	require.Greater(t,
+		deeply.RankMatch(map[string]any{"price": 100.0}, map[string]any{"price": 101.0}),
+		deeply.RankMatch(map[string]any{"price": 100.0}, map[string]any{"price": 900.0}))
+
+	require.InDelta(t, 1., deeply.RankMatch(1, 1.0), 1e-9)
+	require.InDelta(t, .99, deeply.RankMatch(100, 99), 1e-9)
+	require.Zero(t, deeply.RankMatch(1, -1))
+	require.Zero(t, deeply.RankMatch(math.NaN(), math.NaN()))
+	require.Zero(t, deeply.RankMatch(math.Inf(1), 1e300))
+	require.InDelta(t, 1., deeply.RankMatch(math.Inf(-1), math.Inf(-1)), 1e-9)
+}
+
+func TestRankMatch_Booleans(t *testing.T) {
+	require.InDelta(t, 1., deeply.RankMatch(true, true), 1e-9)
+	require.Zero(t, deeply.RankMatch(true, false))
+	require.Zero(t, deeply.RankMatch("true", true))
+	require.Equal(t, []any{true, nil, false, "true"}, ranker(true, []any{nil, false, "true", true}))
+}
+
+func TestRankMatch_Nulls(t *testing.T) {
+	require.Zero(t, deeply.RankMatch("", nil), "nil is not an empty string")
+	require.Zero(t, deeply.RankMatch(nil, ""))
+	require.Zero(t, deeply.RankMatch(0, nil))
+	require.Greater(t, deeply.RankMatch(nil, nil), 0.)
+
+	require.Greater(t,
+		deeply.RankMatch(map[string]any{"a": ""}, map[string]any{"a": ""}),
+		deeply.RankMatch(map[string]any{"a": ""}, map[string]any{"a": nil}))
+}
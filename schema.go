@@ -0,0 +1,364 @@
+package deeply
+
+import (
+	"log"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/spf13/cast"
+)
+
+// schemaResult is the number of constraints of a JSON Schema checked against
+// a value and the number of them satisfied by the value.
+type schemaResult struct {
+	satisfied, total int
+}
+
+// add adds the result of a single constraint.
+func (r *schemaResult) add(ok bool) {
+	r.total++
+
+	if ok {
+		r.satisfied++
+	}
+}
+
+// merge adds the results of a subschema.
+func (r *schemaResult) merge(other schemaResult) {
+	r.satisfied += other.satisfied
+	r.total += other.total
+}
+
+// valid checks if all the constraints are satisfied.
+func (r schemaResult) valid() bool {
+	return r.satisfied == r.total
+}
+
+// score returns the fraction of satisfied constraints.
+func (r schemaResult) score() float64 {
+	if r.total == 0 {
+		return 1
+	}
+
+	return float64(r.satisfied) / float64(r.total)
+}
+
+// matchSchema checks if the actual value conforms to the JSON Schema of $jsonSchema.
+func matchSchema(node map[string]any, actual any, _ cmp) bool {
+	return checkSchema(node["$jsonSchema"], actual).valid()
+}
+
+// rankSchema scores the actual value by the fraction of the constraints of
+// the JSON Schema of $jsonSchema it satisfies.
+func rankSchema(node map[string]any, actual any, _ ranker) float64 {
+	return checkSchema(node["$jsonSchema"], actual).score()
+}
+
+// checkSchema checks a value against a JSON Schema.
+//
+// The supported subset of the draft 2020-12 consists of boolean schemas and
+// the type, enum, const, properties, required, additionalProperties, items,
+// pattern, minLength, maxLength, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, minItems, maxItems, minProperties, maxProperties, not,
+// oneOf, anyOf and allOf keywords. Other keywords are ignored.
+//
+//nolint:cyclop,funlen
+func checkSchema(schema, value any) schemaResult {
+	var res schemaResult
+
+	switch s := schema.(type) {
+	case bool:
+		res.add(s)
+
+		return res
+	case map[string]any:
+		if v, ok := s["type"]; ok {
+			res.add(checkSchemaType(v, value))
+		}
+
+		if v, ok := s["const"]; ok {
+			res.add(jsonEqual(v, value))
+		}
+
+		if v, ok := s["enum"].([]any); ok {
+			res.add(slices.ContainsFunc(v, func(item any) bool { return jsonEqual(item, value) }))
+		}
+
+		res.merge(checkSchemaString(s, value))
+		res.merge(checkSchemaNumber(s, value))
+		res.merge(checkSchemaArray(s, value))
+		res.merge(checkSchemaObject(s, value))
+
+		if v, ok := s["not"]; ok {
+			res.add(!checkSchema(v, value).valid())
+		}
+
+		if v, ok := s["allOf"].([]any); ok {
+			for _, sub := range v {
+				res.merge(checkSchema(sub, value))
+			}
+		}
+
+		if v, ok := s["anyOf"].([]any); ok {
+			res.add(countValidSchemas(v, value) > 0)
+		}
+
+		if v, ok := s["oneOf"].([]any); ok {
+			res.add(countValidSchemas(v, value) == 1)
+		}
+
+		return res
+	default:
+		log.Printf("Error on checking JSON Schema %v: not an object or a boolean\n", schema)
+		res.add(false)
+
+		return res
+	}
+}
+
+// countValidSchemas counts the schemas the value conforms to.
+func countValidSchemas(schemas []any, value any) int {
+	count := 0
+
+	for _, schema := range schemas {
+		if checkSchema(schema, value).valid() {
+			count++
+		}
+	}
+
+	return count
+}
+
+// checkSchemaType checks if the value is of one of the JSON types of the type keyword.
+func checkSchemaType(types, value any) bool {
+	switch t := types.(type) {
+	case string:
+		return jsonType(value) == t || t == "number" && jsonType(value) == "integer"
+	case []any:
+		return slices.ContainsFunc(t, func(item any) bool { return checkSchemaType(item, value) })
+	default:
+		return false
+	}
+}
+
+// checkSchemaString checks the string keywords of the schema.
+// The keywords are ignored for values other than strings.
+func checkSchemaString(schema map[string]any, value any) schemaResult {
+	var res schemaResult
+
+	str, ok := value.(string)
+	if !ok {
+		return res
+	}
+
+	if v, ok := schema["minLength"]; ok {
+		res.add(float64(utf8.RuneCountInString(str)) >= cast.ToFloat64(v))
+	}
+
+	if v, ok := schema["maxLength"]; ok {
+		res.add(float64(utf8.RuneCountInString(str)) <= cast.ToFloat64(v))
+	}
+
+	if v, ok := schema["pattern"].(string); ok {
+		match, err := regexp.MatchString(v, str)
+		if err != nil {
+			log.Printf("Error on matching regex %s with %s error:%v\n", v, str, err)
+		}
+
+		res.add(match)
+	}
+
+	return res
+}
+
+// checkSchemaNumber checks the numeric keywords of the schema.
+// The keywords are ignored for values other than numbers.
+func checkSchemaNumber(schema map[string]any, value any) schemaResult {
+	var res schemaResult
+
+	t := jsonType(value)
+	if t != "number" && t != "integer" {
+		return res
+	}
+
+	num := toFloat(value)
+
+	if v, ok := schema["minimum"]; ok {
+		res.add(num >= cast.ToFloat64(v))
+	}
+
+	if v, ok := schema["maximum"]; ok {
+		res.add(num <= cast.ToFloat64(v))
+	}
+
+	if v, ok := schema["exclusiveMinimum"]; ok {
+		res.add(num > cast.ToFloat64(v))
+	}
+
+	if v, ok := schema["exclusiveMaximum"]; ok {
+		res.add(num < cast.ToFloat64(v))
+	}
+
+	return res
+}
+
+// checkSchemaArray checks the array keywords of the schema.
+// The keywords are ignored for values other than arrays.
+func checkSchemaArray(schema map[string]any, value any) schemaResult {
+	var res schemaResult
+
+	if jsonType(value) != "array" {
+		return res
+	}
+
+	items := reflect.ValueOf(value)
+
+	if v, ok := schema["minItems"]; ok {
+		res.add(float64(items.Len()) >= cast.ToFloat64(v))
+	}
+
+	if v, ok := schema["maxItems"]; ok {
+		res.add(float64(items.Len()) <= cast.ToFloat64(v))
+	}
+
+	if v, ok := schema["items"]; ok {
+		for i := range items.Len() {
+			res.merge(checkSchema(v, items.Index(i).Interface()))
+		}
+	}
+
+	return res
+}
+
+// checkSchemaObject checks the object keywords of the schema.
+// The keywords are ignored for values other than objects.
+//
+//nolint:cyclop
+func checkSchemaObject(schema map[string]any, value any) schemaResult {
+	var res schemaResult
+
+	if jsonType(value) != "object" {
+		return res
+	}
+
+	object := reflect.ValueOf(value)
+
+	if v, ok := schema["minProperties"]; ok {
+		res.add(float64(object.Len()) >= cast.ToFloat64(v))
+	}
+
+	if v, ok := schema["maxProperties"]; ok {
+		res.add(float64(object.Len()) <= cast.ToFloat64(v))
+	}
+
+	if v, ok := schema["required"].([]any); ok {
+		for _, name := range v {
+			res.add(mapIndexString(object, cast.ToString(name)).IsValid())
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+
+	for name, sub := range properties {
+		if property := mapIndexString(object, name); property.IsValid() {
+			res.merge(checkSchema(sub, property.Interface()))
+		}
+	}
+
+	if v, ok := schema["additionalProperties"]; ok {
+		for _, key := range object.MapKeys() {
+			if _, ok := properties[key.String()]; !ok {
+				res.merge(checkSchema(v, object.MapIndex(key).Interface()))
+			}
+		}
+	}
+
+	return res
+}
+
+// jsonType returns the JSON type of a value: "null", "boolean", "integer",
+// "number", "string", "array" or "object". It returns an empty string for
+// values which have no JSON representation.
+//
+//nolint:cyclop
+func jsonType(value any) string {
+	if value == nil {
+		return "null"
+	}
+
+	v := reflect.ValueOf(value)
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		if f := v.Float(); f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			return "object"
+		}
+	default:
+	}
+
+	return ""
+}
+
+// jsonEqual checks if two values are equal as JSON values: numbers are
+// compared by their value regardless of their Go type.
+func jsonEqual(a, b any) bool {
+	ta, tb := jsonType(a), jsonType(b)
+
+	switch {
+	case (ta == "number" || ta == "integer") && (tb == "number" || tb == "integer"):
+		return toFloat(a) == toFloat(b)
+	case ta == "array" && tb == "array":
+		x, y := reflect.ValueOf(a), reflect.ValueOf(b)
+		if x.Len() != y.Len() {
+			return false
+		}
+
+		for i := range x.Len() {
+			if !jsonEqual(x.Index(i).Interface(), y.Index(i).Interface()) {
+				return false
+			}
+		}
+
+		return true
+	case ta == "object" && tb == "object":
+		x, y := reflect.ValueOf(a), reflect.ValueOf(b)
+		if x.Len() != y.Len() {
+			return false
+		}
+
+		for _, key := range x.MapKeys() {
+			other := mapIndexString(y, key.String())
+			if !other.IsValid() || !jsonEqual(x.MapIndex(key).Interface(), other.Interface()) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+// mapIndexString returns the value of the map with a string key, converting
+// the key to the key type of the map.
+func mapIndexString(m reflect.Value, key string) reflect.Value {
+	return m.MapIndex(reflect.ValueOf(key).Convert(m.Type().Key()))
+}
@@ -0,0 +1,114 @@
+package deeply_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+func jsonSchema(t *testing.T, schema string) map[string]any {
+	t.Helper()
+
+	var v any
+
+	require.NoError(t, json.Unmarshal([]byte(schema), &v))
+
+	return map[string]any{"$jsonSchema": v}
+}
+
+func TestSchema_Keywords(t *testing.T) {
+	cases := []struct {
+		schema string
+		valid  []any
+		broken []any
+	}{
+		{`true`, []any{1, "a", nil}, nil},
+		{`false`, nil, []any{1, "a", nil}},
+		{`{"type": "string"}`, []any{"a"}, []any{1, nil, true}},
+		{`{"type": "integer"}`, []any{1, 2.0, int64(3)}, []any{1.5, "1"}},
+		{`{"type": "number"}`, []any{1, 1.5}, []any{"1.5"}},
+		{`{"type": ["null", "boolean"]}`, []any{nil, false}, []any{0}},
+		{`{"type": "array"}`, []any{[]any{}, []string{"a"}}, []any{map[string]any{}}},
+		{`{"type": "object"}`, []any{map[string]any{}}, []any{[]any{}, map[int]any{}}},
+		{`{"const": 1}`, []any{1, 1.0}, []any{2, "1"}},
+		{`{"const": {"a": [1, "b"]}}`, []any{map[string]any{"a": []any{1, "b"}}}, []any{map[string]any{"a": []any{1}}}},
+		{`{"enum": ["red", "green", 3]}`, []any{"red", 3.0}, []any{"blue", 4}},
+		{`{"pattern": "^\\d+$", "minLength": 2, "maxLength": 3}`, []any{"12", "123", 1}, []any{"1", "1234", "ab"}},
+		{`{"minLength": 2}`, []any{"ñö"}, []any{"ñ"}},
+		{`{"minimum": 1, "maximum": 3}`, []any{1, 3, 2.5, "0"}, []any{0, 3.5}},
+		{`{"exclusiveMinimum": 1, "exclusiveMaximum": 3}`, []any{2}, []any{1, 3}},
+		{`{"items": {"type": "integer"}, "minItems": 1, "maxItems": 2}`, []any{[]any{1}, []int{1, 2}}, []any{[]any{}, []any{1, "a"}, []any{1, 2, 3}}},
+		{`{"required": ["a"], "minProperties": 1, "maxProperties": 2}`, []any{map[string]any{"a": nil}}, []any{map[string]any{"b": 1}, map[string]any{"a": 1, "b": 1, "c": 1}}},
+		{`{"properties": {"a": {"type": "string"}}, "additionalProperties": false}`, []any{map[string]any{}, map[string]any{"a": "x"}}, []any{map[string]any{"a": 1}, map[string]any{"b": "x"}}},
+		{`{"not": {"type": "string"}}`, []any{1}, []any{"a"}},
+		{`{"allOf": [{"type": "integer"}, {"minimum": 2}]}`, []any{2}, []any{1, 2.5}},
+		{`{"anyOf": [{"type": "string"}, {"minimum": 2}]}`, []any{"a", 3}, []any{1}},
+		{`{"oneOf": [{"type": "integer"}, {"minimum": 2}]}`, []any{1, 2.5, "a"}, []any{3}},
+		{`{"title": "unknown keywords are ignored", "format": "email"}`, []any{"a"}, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.schema, func(t *testing.T) {
+			schema := jsonSchema(t, c.schema)
+
+			for _, v := range c.valid {
+				require.True(t, deeply.Matches(schema, v), v)
+				require.InDelta(t, 1., deeply.RankMatch(schema, v), 1e-9, v)
+			}
+
+			for _, v := range c.broken {
+				require.False(t, deeply.Matches(schema, v), v)
+				require.Less(t, deeply.RankMatch(schema, v), 1., v)
+			}
+		})
+	}
+}
+
+func TestSchema_Nested(t *testing.T) {
+	expect := map[string]any{
+		"service": "^greeter$",
+		"payload": jsonSchema(t, `{
+			"type": "object",
+			"required": ["name", "tags"],
+			"properties": {
+				"name": {"type": "string", "minLength": 1},
+				"tags": {"type": "array", "items": {"enum": ["a", "b"]}}
+			}
+		}`),
+	}
+
+	require.True(t, deeply.Matches(expect, map[string]any{
+		"service": "greeter",
+		"payload": map[string]any{"name": "bob", "tags": []any{"a", "b", "a"}},
+	}))
+	require.False(t, deeply.Matches(expect, map[string]any{
+		"service": "greeter",
+		"payload": map[string]any{"name": "bob", "tags": []any{"a", "c"}},
+	}))
+	require.True(t, deeply.Contains(expect["payload"], map[string]any{"name": "bob", "tags": []any{}, "extra": 1}))
+
+	require.False(t, deeply.Matches(map[string]any{"$jsonSchema": "string"}, "a"))
+}
+
+func TestSchema_RankMatch(t *testing.T) {
+	schema := jsonSchema(t, `{
+		"type": "object",
+		"required": ["id", "name", "email"],
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"name": {"type": "string"},
+			"email": {"type": "string", "pattern": "@"}
+		}
+	}`)
+
+	full := map[string]any{"id": 1, "name": "bob", "email": "bob@example.com"}
+	badEmail := map[string]any{"id": 1, "name": "bob", "email": "bob"}
+	missing := map[string]any{"id": 0, "name": "bob"}
+	wrong := []any{"bob"}
+
+	require.Equal(t, []any{full, badEmail, missing, wrong}, ranker(schema, []any{missing, wrong, full, badEmail}))
+	require.InDelta(t, 8./9., deeply.RankMatch(schema, badEmail), 1e-9)
+}
@@ -0,0 +1,304 @@
+package deeply
+
+import (
+	"strconv"
+	"strings"
+)
+
+// semver is a semantic version as defined by https://semver.org.
+// The build metadata is dropped as it doesn't affect precedence.
+type semver struct {
+	major, minor, patch uint64
+	pre                 []string
+}
+
+// semverComparator compares a version with the version of the comparator
+// using the operator, one of "=", "!=", "<", "<=", ">" and ">=".
+type semverComparator struct {
+	op      string
+	version semver
+}
+
+// semverConstraint is a disjunction of conjunctions of comparators,
+// e.g. ">=1.2 <2 || >=3".
+type semverConstraint [][]semverComparator
+
+// parseSemver parses a semantic version.
+func parseSemver(value string) (semver, bool) {
+	v, _, ok := parseSemverParts(value, false)
+
+	return v, ok
+}
+
+// parseSemverParts parses a semantic version and returns the number of parsed
+// numeric parts. If partial is true, the minor and patch numbers may be omitted.
+func parseSemverParts(value string, partial bool) (semver, int, bool) {
+	var v semver
+
+	value, _, _ = strings.Cut(value, "+")
+	value, pre, hasPre := strings.Cut(value, "-")
+
+	if hasPre {
+		v.pre = strings.Split(pre, ".")
+		for _, id := range v.pre {
+			if id == "" || !isSemverIdentifier(id) {
+				return semver{}, 0, false
+			}
+		}
+	}
+
+	parts := strings.Split(value, ".")
+	if len(parts) > 3 || len(parts) < 3 && (!partial || hasPre) { //nolint:mnd
+		return semver{}, 0, false
+	}
+
+	nums := [3]*uint64{&v.major, &v.minor, &v.patch}
+
+	for i, part := range parts {
+		if part == "" || len(part) > 1 && part[0] == '0' {
+			return semver{}, 0, false
+		}
+
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return semver{}, 0, false
+		}
+
+		*nums[i] = n
+	}
+
+	return v, len(parts), true
+}
+
+// isSemverIdentifier checks if the pre-release identifier contains only
+// alphanumerics and hyphens.
+func isSemverIdentifier(id string) bool {
+	for i := range len(id) {
+		c := id[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compare compares two versions by precedence.
+// It returns -1, 0 or 1 if v is lower, equal or higher than w.
+func (v semver) compare(w semver) int {
+	if c := compareInts(v.major, w.major); c != 0 {
+		return c
+	}
+
+	if c := compareInts(v.minor, w.minor); c != 0 {
+		return c
+	}
+
+	if c := compareInts(v.patch, w.patch); c != 0 {
+		return c
+	}
+
+	// A version without pre-release identifiers has a higher precedence.
+	switch {
+	case len(v.pre) == 0 && len(w.pre) == 0:
+		return 0
+	case len(v.pre) == 0:
+		return 1
+	case len(w.pre) == 0:
+		return -1
+	}
+
+	for i := range min(len(v.pre), len(w.pre)) {
+		if c := comparePrerelease(v.pre[i], w.pre[i]); c != 0 {
+			return c
+		}
+	}
+
+	return compareInts(len(v.pre), len(w.pre))
+}
+
+// compareInts compares two integers.
+// It returns -1, 0 or 1 if a is lower, equal or higher than b.
+func compareInts[T uint64 | int](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// comparePrerelease compares two pre-release identifiers. Numeric identifiers
+// are compared numerically and have a lower precedence than alphanumeric ones.
+func comparePrerelease(a, b string) int {
+	x, errA := strconv.ParseUint(a, 10, 64)
+	y, errB := strconv.ParseUint(b, 10, 64)
+
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(x, y)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// semverOperators are the characters of the operators of the comparators.
+const semverOperators = "<>=!^~"
+
+// parseSemverConstraint parses a constraint such as ">=1.2 <2 || ^3.1".
+// Comparators may use the operators "=", "!=", "<", "<=", ">", ">=", the
+// caret ("^1.2": compatible with 1.2) and the tilde ("~1.2": patch updates of 1.2).
+// A bare partial version, e.g. "1.2", matches any version with that prefix.
+// Spaces may separate an operator from its version, e.g. ">= 1.2".
+func parseSemverConstraint(value string) (semverConstraint, bool) {
+	var constraint semverConstraint
+
+	for alternative := range strings.SplitSeq(value, "||") {
+		var (
+			comparators []semverComparator
+			op          string // An operator separated from its version, e.g. ">=" in ">= 1.2".
+		)
+
+		for _, field := range strings.FieldsFunc(alternative, func(r rune) bool { return r == ' ' || r == ',' }) {
+			if strings.TrimLeft(field, semverOperators) == "" {
+				op += field
+
+				continue
+			}
+
+			parsed, ok := parseSemverComparator(op + field)
+			if !ok {
+				return nil, false
+			}
+
+			comparators = append(comparators, parsed...)
+			op = ""
+		}
+
+		if len(comparators) == 0 || op != "" {
+			return nil, false
+		}
+
+		constraint = append(constraint, comparators)
+	}
+
+	return constraint, true
+}
+
+// parseSemverComparator parses a single comparator, expanding the caret,
+// the tilde and partial versions into a range of plain comparators.
+//
+//nolint:cyclop
+func parseSemverComparator(value string) ([]semverComparator, bool) {
+	op := value[:len(value)-len(strings.TrimLeft(value, semverOperators))]
+
+	v, parts, ok := parseSemverParts(strings.TrimPrefix(value[len(op):], "v"), true)
+	if !ok {
+		return nil, false
+	}
+
+	// next returns the lowest version above all versions sharing the first n parts of v.
+	next := func(n int) semver {
+		switch n {
+		case 1:
+			return semver{major: v.major + 1, pre: []string{"0"}}
+		case 2: //nolint:mnd
+			return semver{major: v.major, minor: v.minor + 1, pre: []string{"0"}}
+		default:
+			return semver{major: v.major, minor: v.minor, patch: v.patch + 1, pre: []string{"0"}}
+		}
+	}
+
+	switch op {
+	case "", "=":
+		if parts == 3 { //nolint:mnd
+			return []semverComparator{{op: "=", version: v}}, true
+		}
+
+		return []semverComparator{{op: ">=", version: v}, {op: "<", version: next(parts)}}, true
+	case "!=", ">=":
+		return []semverComparator{{op: op, version: v}}, true
+	case "<":
+		// A partial upper bound excludes the pre-releases of the bound, e.g. <2 excludes 2.0.0-alpha.
+		if parts < 3 { //nolint:mnd
+			v.pre = []string{"0"}
+		}
+
+		return []semverComparator{{op: op, version: v}}, true
+	case ">":
+		if parts < 3 { //nolint:mnd
+			return []semverComparator{{op: ">=", version: next(parts)}}, true
+		}
+
+		return []semverComparator{{op: op, version: v}}, true
+	case "<=":
+		if parts < 3 { //nolint:mnd
+			return []semverComparator{{op: "<", version: next(parts)}}, true
+		}
+
+		return []semverComparator{{op: op, version: v}}, true
+	case "~":
+		return []semverComparator{{op: ">=", version: v}, {op: "<", version: next(min(parts, 2))}}, true //nolint:mnd
+	case "^":
+		// The first non-zero part can't change, e.g. ^0.2.3 allows 0.2.x only.
+		n := 1
+
+		switch {
+		case v.major == 0 && v.minor == 0 && parts == 3: //nolint:mnd
+			n = 3
+		case v.major == 0 && parts >= 2: //nolint:mnd
+			n = 2
+		}
+
+		return []semverComparator{{op: ">=", version: v}, {op: "<", version: next(n)}}, true
+	default:
+		return nil, false
+	}
+}
+
+// check checks if the version satisfies the comparator.
+func (c semverComparator) check(v semver) bool {
+	res := v.compare(c.version)
+
+	switch c.op {
+	case "=":
+		return res == 0
+	case "!=":
+		return res != 0
+	case "<":
+		return res < 0
+	case "<=":
+		return res <= 0
+	case ">":
+		return res > 0
+	default:
+		return res >= 0
+	}
+}
+
+// check checks if the version satisfies any of the alternatives of the constraint.
+func (c semverConstraint) check(v semver) bool {
+	for _, alternative := range c {
+		ok := true
+
+		for _, comparator := range alternative {
+			if !comparator.check(v) {
+				ok = false
+
+				break
+			}
+		}
+
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,178 @@
+package deeply
+
+import (
+	"log"
+)
+
+// sequenceOperands returns the expected elements of a sequence operator and
+// the elements of the actual slice.
+// It returns false if either of the values is not a slice.
+func sequenceOperands(node map[string]any, name string, actual any) ([]any, []any, bool) {
+	expect, ok := elements(node[name])
+	if !ok {
+		log.Printf("Error on parsing %s operand %v: not an array\n", name, node[name])
+
+		return nil, nil, false
+	}
+
+	items, ok := elements(actual)
+	if !ok {
+		return nil, nil, false
+	}
+
+	return expect, items, true
+}
+
+// matchSubsequence checks if the elements of $subsequence appear in the
+// actual slice in the same order, possibly with other elements between them.
+func matchSubsequence(node map[string]any, actual any, compare cmp) bool {
+	expect, items, ok := sequenceOperands(node, "$subsequence", actual)
+	if !ok {
+		return false
+	}
+
+	// Matching every expected element with the earliest possible actual element
+	// leaves the most room for the following ones, so a greedy search is enough.
+	i := 0
+
+	for j := 0; i < len(expect) && j < len(items); j++ {
+		if compare(expect[i], items[j]) {
+			i++
+		}
+	}
+
+	return i == len(expect)
+}
+
+// rankSubsequence scores the actual slice by the longest common subsequence
+// with $subsequence, weighted by the match scores of the elements and
+// normalized by the number of expected elements.
+func rankSubsequence(node map[string]any, actual any, compare ranker) float64 {
+	expect, items, ok := sequenceOperands(node, "$subsequence", actual)
+	if !ok {
+		return 0
+	}
+
+	if len(expect) == 0 {
+		return 1
+	}
+
+	return lcs(expect, items, compare) / float64(len(expect))
+}
+
+// lcs calculates the length of the longest common subsequence of two slices,
+// where each pair of elements contributes its match score instead of 1.
+func lcs(expect, actual []any, compare ranker) float64 {
+	prev := make([]float64, len(actual)+1)
+	curr := make([]float64, len(actual)+1)
+
+	for i := range expect {
+		for j := range actual {
+			curr[j+1] = max(prev[j+1], curr[j], prev[j]+clampScore(compare(expect[i], actual[j])))
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(actual)]
+}
+
+// matchSubslice checks if the elements of $subslice appear in the actual
+// slice contiguously and in the same order.
+func matchSubslice(node map[string]any, actual any, compare cmp) bool {
+	expect, items, ok := sequenceOperands(node, "$subslice", actual)
+	if !ok {
+		return false
+	}
+
+	for start := 0; start+len(expect) <= len(items); start++ {
+		if matchAt(expect, items, start, compare) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rankSubslice scores the actual slice by the position where it matches
+// $subslice best, partial overlaps included.
+func rankSubslice(node map[string]any, actual any, compare ranker) float64 {
+	expect, items, ok := sequenceOperands(node, "$subslice", actual)
+	if !ok {
+		return 0
+	}
+
+	var res float64
+
+	// The expected elements may hang over either end of the actual slice.
+	for start := 1 - len(expect); start < max(len(items), 1); start++ {
+		res = max(res, rankAt(expect, items, start, compare))
+	}
+
+	return res
+}
+
+// matchPrefix checks if the actual slice starts with the elements of $prefix.
+func matchPrefix(node map[string]any, actual any, compare cmp) bool {
+	expect, items, ok := sequenceOperands(node, "$prefix", actual)
+
+	return ok && len(expect) <= len(items) && matchAt(expect, items, 0, compare)
+}
+
+// rankPrefix scores the actual slice by the elements at the start of it.
+func rankPrefix(node map[string]any, actual any, compare ranker) float64 {
+	expect, items, ok := sequenceOperands(node, "$prefix", actual)
+	if !ok {
+		return 0
+	}
+
+	return rankAt(expect, items, 0, compare)
+}
+
+// matchSuffix checks if the actual slice ends with the elements of $suffix.
+func matchSuffix(node map[string]any, actual any, compare cmp) bool {
+	expect, items, ok := sequenceOperands(node, "$suffix", actual)
+
+	return ok && len(expect) <= len(items) && matchAt(expect, items, len(items)-len(expect), compare)
+}
+
+// rankSuffix scores the actual slice by the elements at the end of it.
+func rankSuffix(node map[string]any, actual any, compare ranker) float64 {
+	expect, items, ok := sequenceOperands(node, "$suffix", actual)
+	if !ok {
+		return 0
+	}
+
+	return rankAt(expect, items, len(items)-len(expect), compare)
+}
+
+// matchAt checks if the expected elements match the actual elements from the
+// start index. The actual slice must be long enough.
+func matchAt(expect, actual []any, start int, compare cmp) bool {
+	for i := range expect {
+		if !compare(expect[i], actual[start+i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// rankAt calculates the average score of the expected elements against the
+// actual elements from the start index, which may be out of the bounds of the
+// actual slice. Missing actual elements score 0.
+func rankAt(expect, actual []any, start int, compare ranker) float64 {
+	if len(expect) == 0 {
+		return 1
+	}
+
+	var res float64
+
+	for i := range expect {
+		if j := start + i; j >= 0 && j < len(actual) {
+			res += clampScore(compare(expect[i], actual[j]))
+		}
+	}
+
+	return res / float64(len(expect))
+}
@@ -0,0 +1,89 @@
+package deeply_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+func events(names ...string) []any {
+	res := make([]any, 0, len(names))
+	for _, name := range names {
+		res = append(res, map[string]any{"type": name, "at": len(res)})
+	}
+
+	return res
+}
+
+func TestSequences_Subsequence(t *testing.T) {
+	expect := map[string]any{"$subsequence": []any{
+		map[string]any{"type": "created"},
+		map[string]any{"type": "^paid|refunded$"},
+		map[string]any{"type": "shipped"},
+	}}
+
+	require.True(t, deeply.Matches(expect, events("created", "viewed", "paid", "viewed", "shipped")))
+	require.True(t, deeply.Matches(expect, events("created", "paid", "shipped")))
+	require.False(t, deeply.Matches(expect, events("created", "shipped", "paid")))
+	require.False(t, deeply.Matches(expect, events("created", "paid")))
+	require.False(t, deeply.Contains(expect, events("created", "paid", "shipped")), "Contains doesn't use regular expressions")
+	require.True(t, deeply.Contains(expect, events("created", "^paid|refunded$", "shipped")))
+
+	require.True(t, deeply.Matches(map[string]any{"$subsequence": []any{}}, []any{1}))
+	require.True(t, deeply.Matches(map[string]any{"$subsequence": []int{1, 3}}, []int{1, 2, 3}))
+	require.False(t, deeply.Matches(map[string]any{"$subsequence": []int{1, 3}}, "1 2 3"))
+	require.False(t, deeply.Matches(map[string]any{"$subsequence": 1}, []int{1, 2, 3}))
+}
+
+func TestSequences_Subslice(t *testing.T) {
+	expect := map[string]any{"$subslice": []any{2, 3}}
+
+	require.True(t, deeply.Matches(expect, []any{1, 2, 3, 4}))
+	require.True(t, deeply.Matches(expect, []any{2, 3}))
+	require.False(t, deeply.Matches(expect, []any{2, 1, 3}))
+	require.False(t, deeply.Matches(expect, []any{3}))
+	require.True(t, deeply.Matches(map[string]any{"$subslice": []any{}}, []any{}))
+}
+
+func TestSequences_PrefixSuffix(t *testing.T) {
+	prefix := map[string]any{"$prefix": []any{"^GET", "^200$"}}
+
+	require.True(t, deeply.Matches(prefix, []string{"GET /", "200", "12ms"}))
+	require.False(t, deeply.Matches(prefix, []string{"POST /", "200", "12ms"}))
+	require.False(t, deeply.Matches(prefix, []string{"GET /"}))
+
+	suffix := map[string]any{"$suffix": []any{"done"}}
+
+	require.True(t, deeply.Contains(suffix, []any{"start", "done"}))
+	require.False(t, deeply.Contains(suffix, []any{"done", "start"}))
+	require.False(t, deeply.Contains(suffix, []any{}))
+
+	both := map[string]any{"$prefix": []any{"start"}, "$suffix": []any{"done"}}
+
+	require.True(t, deeply.Equals(both, []any{"start", "step", "done"}))
+	require.False(t, deeply.Equals(both, []any{"start", "step"}))
+}
+
+func TestSequences_RankMatch(t *testing.T) {
+	expect := map[string]any{"$subsequence": []any{"a", "b", "c"}}
+
+	require.InDelta(t, 1., deeply.RankMatch(expect, []string{"a", "x", "b", "y", "c"}), 1e-9)
+	require.InDelta(t, 2./3., deeply.RankMatch(expect, []string{"a", "c", "b"}), 1e-9)
+	require.Equal(t,
+		[]any{[]string{"a", "b", "c"}, []string{"c", "a", "b"}, []string{"c", "b", "a"}, []string{"x"}},
+		ranker(expect, []any{[]string{"x"}, []string{"c", "b", "a"}, []string{"a", "b", "c"}, []string{"c", "a", "b"}}))
+
+	subslice := map[string]any{"$subslice": []any{1, 2, 3}}
+
+	require.InDelta(t, 1., deeply.RankMatch(subslice, []int{0, 1, 2, 3}), 1e-9)
+	require.InDelta(t, 2./3., deeply.RankMatch(subslice, []int{0, 1, 2, 0, 3}), 1e-9)
+	require.InDelta(t, 1./3., deeply.RankMatch(subslice, []int{3}), 1e-9)
+
+	// The mismatching numbers are scored by their closeness: 2 to 3 and 1 to 3.
+	require.InDelta(t, (1+2./3.)/2, deeply.RankMatch(map[string]any{"$prefix": []any{1, 2}}, []int{1, 3, 2}), 1e-9)
+	require.InDelta(t, (1./3.+1)/2, deeply.RankMatch(map[string]any{"$suffix": []any{1, 2}}, []int{1, 3, 2}), 1e-9)
+	require.InDelta(t, .5, deeply.RankMatch(map[string]any{"$suffix": []any{1, 2}}, []int{2}), 1e-9)
+	require.Zero(t, deeply.RankMatch(map[string]any{"$suffix": []any{1, 2}}, 2))
+}
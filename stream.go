@@ -0,0 +1,481 @@
+package deeply
+
+import (
+	"fmt"
+	"slices"
+)
+
+// StreamOrder is how the messages of a stream are matched against the
+// expected messages, see StreamMatcher.
+type StreamOrder int
+
+const (
+	// StreamExact requires the messages to match the expected messages one
+	// by one, no more and no less.
+	StreamExact StreamOrder = iota
+	// StreamPrefix requires the first messages to match the expected
+	// messages one by one, any messages may follow.
+	StreamPrefix
+	// StreamInOrder requires the expected messages to match messages in the
+	// same order, possibly with other messages between them.
+	StreamInOrder
+	// StreamAnyOrder requires every expected message to match a distinct
+	// message in any order, within a window of consecutive messages.
+	StreamAnyOrder
+)
+
+// streamOrderNames holds the names of the orders, as returned by StreamOrder.String.
+//
+//nolint:gochecknoglobals
+var streamOrderNames = map[StreamOrder]string{
+	StreamExact:    "exact",
+	StreamPrefix:   "prefix",
+	StreamInOrder:  "in-order",
+	StreamAnyOrder: "any-order",
+}
+
+// ParseStreamOrder returns the order with the name, e.g. "exact" or "any-order".
+func ParseStreamOrder(name string) (StreamOrder, error) {
+	for order, orderName := range streamOrderNames {
+		if orderName == name {
+			return order, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown stream order %q", name) //nolint:err113
+}
+
+// String returns the name of the order.
+func (o StreamOrder) String() string {
+	if name, ok := streamOrderNames[o]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("StreamOrder(%d)", int(o))
+}
+
+// StreamState is the state of a stream matched by a StreamMatcher.
+type StreamState int
+
+const (
+	// StreamPending means that the stream doesn't match yet, but the
+	// following messages may make it match.
+	StreamPending StreamState = iota
+	// StreamComplete means that the stream matches if it ends now, but the
+	// following messages may make it mismatch.
+	StreamComplete
+	// StreamMatched means that the stream matches whatever messages follow.
+	StreamMatched
+	// StreamMismatched means that the stream can't match whatever messages follow.
+	StreamMismatched
+)
+
+// Done checks if the state is final, so that the following messages don't
+// need to be matched.
+func (s StreamState) Done() bool {
+	return s == StreamMatched || s == StreamMismatched
+}
+
+// StreamOptions configures a StreamMatcher or a StreamRanker.
+type StreamOptions struct {
+	// Mode is the matching function of the messages, ModeMatches by default.
+	// Rankers score the messages with RankMatch whatever the mode.
+	Mode Mode
+	// Window is the number of consecutive messages within which StreamAnyOrder
+	// looks for the expected messages, the whole stream if it is not positive.
+	Window int
+}
+
+// StreamMatcher matches the messages of a stream, such as a client or
+// bidirectional gRPC stream, against an ordered list of expected messages.
+// The messages are pushed one at a time as they come, so that the server
+// can decide as soon as the outcome is known. Each message is matched as a
+// document of its own, see Mode.Match.
+//
+// A StreamMatcher is not safe for concurrent use.
+type StreamMatcher struct {
+	expect []any
+	order  StreamOrder
+	mode   Mode
+	window int
+
+	state StreamState
+	count int // Number of messages pushed.
+	next  int // Index of the next expected message, for the ordered streams.
+
+	// The messages of StreamAnyOrder matching some expected message, and the
+	// messages matched with the expected messages, -1 for none.
+	rows    []streamRow[bool]
+	matched []int
+}
+
+// streamRow holds the results of the comparisons of a message with the
+// expected messages.
+type streamRow[T any] struct {
+	index  int // The index of the message in the stream.
+	values []T // The results keyed by the index of the expected message.
+}
+
+// NewStreamMatcher returns a matcher of the streams of messages matching the
+// expected messages in the order.
+func NewStreamMatcher(expect []any, order StreamOrder, opts StreamOptions) *StreamMatcher {
+	m := &StreamMatcher{expect: expect, order: order, mode: opts.Mode, window: opts.Window}
+
+	switch {
+	case len(expect) == 0 && order == StreamExact:
+		m.state = StreamComplete
+	case len(expect) == 0:
+		m.state = StreamMatched
+	case order == StreamAnyOrder && m.window > 0 && m.window < len(expect):
+		m.state = StreamMismatched
+	}
+
+	if order == StreamAnyOrder {
+		m.matched = make([]int, len(expect))
+		for i := range m.matched {
+			m.matched[i] = -1
+		}
+	}
+
+	return m
+}
+
+// Push matches the next message of the stream and returns the state of the
+// stream. Once the state is final, the messages are not matched anymore.
+func (m *StreamMatcher) Push(msg any) StreamState {
+	if m.state.Done() {
+		m.count++
+
+		return m.state
+	}
+
+	switch m.order {
+	case StreamPrefix, StreamExact:
+		m.pushOrdered(msg)
+	case StreamInOrder:
+		if m.mode.Match(m.expect[m.next], msg) {
+			m.next++
+		}
+
+		if m.next == len(m.expect) {
+			m.state = StreamMatched
+		}
+	case StreamAnyOrder:
+		m.pushAnyOrder(msg)
+	default:
+		m.state = StreamMismatched
+	}
+
+	m.count++
+
+	return m.state
+}
+
+// State returns the state of the stream.
+func (m *StreamMatcher) State() StreamState {
+	return m.state
+}
+
+// Len returns the number of messages pushed.
+func (m *StreamMatcher) Len() int {
+	return m.count
+}
+
+// End checks if the stream matches once it has ended.
+func (m *StreamMatcher) End() bool {
+	return m.state == StreamComplete || m.state == StreamMatched
+}
+
+// pushOrdered matches the message with the next expected message of
+// StreamExact and StreamPrefix.
+func (m *StreamMatcher) pushOrdered(msg any) {
+	if m.next == len(m.expect) || !m.mode.Match(m.expect[m.next], msg) {
+		m.state = StreamMismatched
+
+		return
+	}
+
+	m.next++
+
+	switch {
+	case m.next < len(m.expect):
+	case m.order == StreamPrefix:
+		m.state = StreamMatched
+	default:
+		m.state = StreamComplete
+	}
+}
+
+// pushAnyOrder adds the message to the messages of StreamAnyOrder and looks
+// for a distinct matching message for each expected message, like a maximum
+// bipartite matching. The matching of the whole stream grows with the
+// messages; the matching of a window is looked for again as it slides.
+func (m *StreamMatcher) pushAnyOrder(msg any) {
+	row := streamRow[bool]{index: m.count, values: make([]bool, len(m.expect))}
+	matches := false
+
+	for i, expect := range m.expect {
+		row.values[i] = m.mode.Match(expect, msg)
+		matches = matches || row.values[i]
+	}
+
+	if m.window > 0 {
+		m.rows = slices.DeleteFunc(m.rows, func(row streamRow[bool]) bool {
+			return row.index <= m.count-m.window
+		})
+
+		for i := range m.matched {
+			m.matched[i] = -1
+		}
+	}
+
+	if !matches {
+		return
+	}
+
+	m.rows = append(m.rows, row)
+
+	complete := true
+
+	for i := range m.expect {
+		if m.matched[i] < 0 && !m.augment(i, make([]bool, len(m.rows))) {
+			complete = false
+		}
+	}
+
+	if complete {
+		m.state = StreamMatched
+	}
+}
+
+// augment looks for a message for the expected message, taking the message
+// of another expected message which can be matched with another message.
+func (m *StreamMatcher) augment(expect int, visited []bool) bool {
+	for j, row := range m.rows {
+		if !row.values[expect] || visited[j] {
+			continue
+		}
+
+		visited[j] = true
+
+		owner := slices.Index(m.matched, j)
+		if owner < 0 || m.augment(owner, visited) {
+			m.matched[expect] = j
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// StreamRanker scores the messages of a stream against an ordered list of
+// expected messages as they come, see Ranker.Stream.
+//
+// A StreamRanker is not safe for concurrent use.
+type StreamRanker struct {
+	ranker *Ranker
+	expect []any
+	order  StreamOrder
+	window int
+
+	// The scores of the expected messages against themselves, by which the
+	// scores of the messages are divided.
+	norms []float64
+
+	count int
+	total float64 // The sum of the scores of StreamExact and StreamPrefix.
+
+	// The weighted longest common subsequence of StreamInOrder, keyed by the
+	// number of expected messages.
+	lcs []float64
+
+	// The messages of StreamAnyOrder which may be part of the best
+	// assignment, and the best score of StreamAnyOrder so far.
+	rows []streamRow[float64]
+	best float64
+}
+
+// NewStreamRanker returns a ranker of the streams of messages like the
+// Stream method of a Ranker without options.
+func NewStreamRanker(expect []any, order StreamOrder, opts StreamOptions) *StreamRanker {
+	return (&Ranker{}).Stream(expect, order, opts)
+}
+
+// Stream returns a ranker of the streams of messages against the expected
+// messages in the order. The messages are scored with RankMatch relative to
+// the score of the expected message against itself, since maps score above
+// 1, and their scores aggregated between 0 and 1:
+//   - StreamExact averages the scores of the messages against the expected
+//     messages at the same index, over the most messages of both lists;
+//   - StreamPrefix averages the scores of the first messages against the
+//     expected messages at the same index;
+//   - StreamInOrder scores the longest common subsequence of the messages and
+//     the expected messages, each pair contributing its score;
+//   - StreamAnyOrder pairs each expected message with a distinct message,
+//     the highest scores first, within the best window so far.
+func (r *Ranker) Stream(expect []any, order StreamOrder, opts StreamOptions) *StreamRanker {
+	s := &StreamRanker{ranker: r, expect: expect, order: order, window: opts.Window, lcs: make([]float64, len(expect)+1)}
+
+	s.norms = make([]float64, len(expect))
+	for i := range expect {
+		s.norms[i] = max(r.RankMatch(expect[i], expect[i]), 1)
+	}
+
+	return s
+}
+
+// Push scores the next message of the stream and returns the score of the
+// stream so far.
+func (s *StreamRanker) Push(msg any) float64 {
+	switch s.order {
+	case StreamExact, StreamPrefix:
+		if s.count < len(s.expect) {
+			s.total += s.rank(s.count, msg)
+		}
+	case StreamInOrder:
+		// The row of the next message is computed in place: diagonal holds
+		// the previous value of the entry before the current one.
+		diagonal := s.lcs[0]
+
+		for i := range s.expect {
+			score := s.rank(i, msg)
+			diagonal, s.lcs[i+1] = s.lcs[i+1], max(s.lcs[i+1], s.lcs[i], diagonal+score)
+		}
+	case StreamAnyOrder:
+		s.pushAnyOrder(msg)
+	}
+
+	s.count++
+
+	return s.Score()
+}
+
+// Score returns the score of the stream so far.
+func (s *StreamRanker) Score() float64 {
+	if len(s.expect) == 0 {
+		if s.order == StreamExact && s.count > 0 {
+			return 0
+		}
+
+		return 1
+	}
+
+	switch s.order {
+	case StreamExact:
+		return s.total / float64(max(len(s.expect), s.count))
+	case StreamPrefix:
+		return s.total / float64(len(s.expect))
+	case StreamInOrder:
+		return s.lcs[len(s.expect)] / float64(len(s.expect))
+	case StreamAnyOrder:
+		return s.best
+	default:
+		return 0
+	}
+}
+
+// Len returns the number of messages pushed.
+func (s *StreamRanker) Len() int {
+	return s.count
+}
+
+// rank scores the message against the expected message at the index.
+func (s *StreamRanker) rank(expect int, msg any) float64 {
+	return clampScore(s.ranker.RankMatch(s.expect[expect], msg) / s.norms[expect])
+}
+
+// pushAnyOrder adds the message to the messages of StreamAnyOrder and scores
+// the best assignment of the messages of the window.
+//
+// Without a window, only the best len(expect) messages of each expected
+// message are kept: the others can't be part of the assignment, each
+// expected message taking its best message which is still free.
+func (s *StreamRanker) pushAnyOrder(msg any) {
+	row := streamRow[float64]{index: s.count, values: make([]float64, len(s.expect))}
+	for i := range s.expect {
+		row.values[i] = s.rank(i, msg)
+	}
+
+	s.rows = append(s.rows, row)
+
+	if s.window > 0 {
+		s.rows = slices.DeleteFunc(s.rows, func(row streamRow[float64]) bool {
+			return row.index <= s.count-s.window
+		})
+	} else if len(s.rows) > 2*len(s.expect)*len(s.expect) {
+		s.prune()
+	}
+
+	s.best = max(s.best, s.assign()/float64(len(s.expect)))
+}
+
+// assign pairs the expected messages with distinct messages, the pair
+// scoring the highest first, and returns the sum of the scores of the pairs.
+func (s *StreamRanker) assign() float64 {
+	var (
+		res      float64
+		paired   = make([]bool, len(s.expect))
+		assigned = make([]bool, len(s.rows))
+	)
+
+	for range min(len(s.expect), len(s.rows)) {
+		best, bestExpect, bestRow := -1.0, -1, -1
+
+		for i := range s.expect {
+			if paired[i] {
+				continue
+			}
+
+			for j, row := range s.rows {
+				if !assigned[j] && row.values[i] > best {
+					best, bestExpect, bestRow = row.values[i], i, j
+				}
+			}
+		}
+
+		res += best
+		paired[bestExpect], assigned[bestRow] = true, true
+	}
+
+	return res
+}
+
+// prune keeps the messages among the best len(expect) messages of some
+// expected message, the earliest first on equal scores.
+func (s *StreamRanker) prune() {
+	keep := make([]bool, len(s.rows))
+	order := make([]int, len(s.rows))
+
+	for i := range s.expect {
+		for j := range order {
+			order[j] = j
+		}
+
+		slices.SortStableFunc(order, func(a, b int) int {
+			switch x, y := s.rows[a].values[i], s.rows[b].values[i]; {
+			case x > y:
+				return -1
+			case x < y:
+				return 1
+			default:
+				return 0
+			}
+		})
+
+		for _, j := range order[:len(s.expect)] {
+			keep[j] = true
+		}
+	}
+
+	res := s.rows[:0]
+
+	for j, row := range s.rows {
+		if keep[j] {
+			res = append(res, row)
+		}
+	}
+
+	clear(s.rows[len(res):])
+	s.rows = res
+}
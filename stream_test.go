@@ -0,0 +1,254 @@
+package deeply_test
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+// push pushes the messages and returns the state after each of them.
+func push(m *deeply.StreamMatcher, msgs ...any) []deeply.StreamState {
+	res := make([]deeply.StreamState, 0, len(msgs))
+	for _, msg := range msgs {
+		res = append(res, m.Push(msg))
+	}
+
+	return res
+}
+
+func TestStreamOrder_Parse(t *testing.T) {
+	for _, order := range []deeply.StreamOrder{deeply.StreamExact, deeply.StreamPrefix, deeply.StreamInOrder, deeply.StreamAnyOrder} {
+		parsed, err := deeply.ParseStreamOrder(order.String())
+
+		require.NoError(t, err)
+		require.Equal(t, order, parsed)
+	}
+
+	_, err := deeply.ParseStreamOrder("random")
+
+	require.Error(t, err)
+	require.Equal(t, "StreamOrder(42)", deeply.StreamOrder(42).String())
+}
+
+func TestStreamMatcher_Exact(t *testing.T) {
+	expect := []any{map[string]any{"id": "^a"}, map[string]any{"id": "b"}}
+
+	m := deeply.NewStreamMatcher(expect, deeply.StreamExact, deeply.StreamOptions{})
+	require.Equal(t, []deeply.StreamState{deeply.StreamPending, deeply.StreamComplete},
+		push(m, map[string]any{"id": "abc"}, map[string]any{"id": "b", "extra": 1}))
+	require.True(t, m.End())
+	require.Equal(t, deeply.StreamMismatched, m.Push(map[string]any{"id": "c"}))
+	require.False(t, m.End())
+	require.Equal(t, 3, m.Len())
+
+	m = deeply.NewStreamMatcher(expect, deeply.StreamExact, deeply.StreamOptions{Mode: deeply.ModeEquals})
+	require.Equal(t, deeply.StreamMismatched, m.Push(map[string]any{"id": "abc"}), "Equals doesn't use regular expressions")
+	require.Equal(t, deeply.StreamMismatched, m.Push(map[string]any{"id": "^a"}), "the mismatch is final")
+
+	m = deeply.NewStreamMatcher(expect, deeply.StreamExact, deeply.StreamOptions{})
+	m.Push(map[string]any{"id": "abc"})
+	require.Equal(t, deeply.StreamPending, m.State())
+	require.False(t, m.End(), "the stream ended early")
+
+	m = deeply.NewStreamMatcher(nil, deeply.StreamExact, deeply.StreamOptions{})
+	require.True(t, m.End())
+	require.Equal(t, deeply.StreamMismatched, m.Push(1))
+}
+
+func TestStreamMatcher_Prefix(t *testing.T) {
+	m := deeply.NewStreamMatcher([]any{1, 2}, deeply.StreamPrefix, deeply.StreamOptions{})
+	require.Equal(t, []deeply.StreamState{deeply.StreamPending, deeply.StreamMatched, deeply.StreamMatched}, push(m, 1, 2, 4))
+	require.True(t, m.State().Done())
+
+	m = deeply.NewStreamMatcher([]any{1, 2}, deeply.StreamPrefix, deeply.StreamOptions{})
+	require.Equal(t, []deeply.StreamState{deeply.StreamMismatched, deeply.StreamMismatched}, push(m, 2, 1))
+
+	require.Equal(t, deeply.StreamMatched, deeply.NewStreamMatcher(nil, deeply.StreamPrefix, deeply.StreamOptions{}).State())
+}
+
+func TestStreamMatcher_InOrder(t *testing.T) {
+	expect := []any{map[string]any{"type": "created"}, map[string]any{"type": "shipped"}}
+
+	m := deeply.NewStreamMatcher(expect, deeply.StreamInOrder, deeply.StreamOptions{Mode: deeply.ModeContains})
+	require.Equal(t, []deeply.StreamState{deeply.StreamPending, deeply.StreamPending, deeply.StreamPending, deeply.StreamMatched},
+		push(m, events("shipped", "created", "paid", "shipped")...))
+	require.True(t, m.End())
+
+	m = deeply.NewStreamMatcher(expect, deeply.StreamInOrder, deeply.StreamOptions{Mode: deeply.ModeContains})
+	push(m, events("shipped", "created", "paid")...)
+	require.False(t, m.End())
+}
+
+func TestStreamMatcher_AnyOrder(t *testing.T) {
+	// The first message matches both expected messages, the matching must
+	// move it to the second one once the third message comes.
+	expect := []any{"^a", "^ab"}
+
+	m := deeply.NewStreamMatcher(expect, deeply.StreamAnyOrder, deeply.StreamOptions{})
+	require.Equal(t, []deeply.StreamState{deeply.StreamPending, deeply.StreamPending, deeply.StreamMatched}, push(m, "abc", "x", "ax"))
+
+	m = deeply.NewStreamMatcher(expect, deeply.StreamAnyOrder, deeply.StreamOptions{})
+	require.Equal(t, []deeply.StreamState{deeply.StreamPending, deeply.StreamPending}, push(m, "ax", "ay"))
+	require.False(t, m.End())
+
+	// A window of 2 messages: "abc" and "ax" are too far apart.
+	m = deeply.NewStreamMatcher(expect, deeply.StreamAnyOrder, deeply.StreamOptions{Window: 2})
+	require.Equal(t, []deeply.StreamState{deeply.StreamPending, deeply.StreamPending, deeply.StreamPending, deeply.StreamMatched},
+		push(m, "abc", "x", "ax", "abd"))
+
+	require.Equal(t, deeply.StreamMismatched,
+		deeply.NewStreamMatcher(expect, deeply.StreamAnyOrder, deeply.StreamOptions{Window: 1}).State())
+}
+
+// anyOrder checks if each expected message matches a distinct message of a
+// window by trying every assignment.
+func anyOrder(expect []int, msgs []int, window int, match func(e, m int) bool) bool {
+	for start := range max(len(msgs)-window+1, 1) {
+		used := make([]bool, len(msgs))
+
+		var assign func(i int) bool
+
+		assign = func(i int) bool {
+			if i == len(expect) {
+				return true
+			}
+
+			for j := start; j < min(start+window, len(msgs)); j++ {
+				if !used[j] && match(expect[i], msgs[j]) {
+					used[j] = true
+					if assign(i + 1) {
+						return true
+					}
+
+					used[j] = false
+				}
+			}
+
+			return false
+		}
+
+		if assign(0) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestStreamMatcher_AnyOrderRandom(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+
+	// An expected number n matches the messages divisible by n.
+	divides := func(e, m int) bool { return m%e == 0 }
+
+	for range 500 {
+		expect := make([]int, 1+rnd.IntN(4))
+		for i := range expect {
+			expect[i] = 1 + rnd.IntN(6)
+		}
+
+		msgs := make([]int, rnd.IntN(10))
+		for i := range msgs {
+			msgs[i] = 1 + rnd.IntN(12)
+		}
+
+		window := rnd.IntN(8)
+
+		expectations := make([]any, len(expect))
+		for i, e := range expect {
+			expectations[i] = map[string]any{"$expr": "@ % " + string(rune('0'+e)) + " == 0"}
+		}
+
+		m := deeply.NewStreamMatcher(expectations, deeply.StreamAnyOrder, deeply.StreamOptions{Window: window})
+		for _, msg := range msgs {
+			m.Push(float64(msg))
+		}
+
+		if window == 0 {
+			window = len(msgs)
+		}
+
+		require.Equal(t, anyOrder(expect, msgs, window, divides), m.End(), "%v %v %d", expect, msgs, window)
+	}
+}
+
+func TestStreamRanker(t *testing.T) {
+	expect := []any{"a", "b"}
+
+	exact := deeply.NewStreamRanker(expect, deeply.StreamExact, deeply.StreamOptions{})
+	require.InDelta(t, .5, exact.Push("a"), 1e-9)
+	require.InDelta(t, 1., exact.Push("b"), 1e-9)
+	require.InDelta(t, 2./3, exact.Push("c"), 1e-9)
+	require.Equal(t, 3, exact.Len())
+
+	prefix := deeply.NewStreamRanker(expect, deeply.StreamPrefix, deeply.StreamOptions{})
+	require.InDelta(t, .5, prefix.Push("a"), 1e-9)
+	require.InDelta(t, .5, prefix.Push("x"), 1e-9)
+	require.InDelta(t, .5, prefix.Push("b"), 1e-9)
+
+	inOrder := deeply.NewStreamRanker(expect, deeply.StreamInOrder, deeply.StreamOptions{})
+	require.InDelta(t, .5, inOrder.Push("b"), 1e-9)
+	require.InDelta(t, .5, inOrder.Push("a"), 1e-9, "b came before a")
+	require.InDelta(t, 1., inOrder.Push("b"), 1e-9)
+
+	anyOrder := deeply.NewStreamRanker(expect, deeply.StreamAnyOrder, deeply.StreamOptions{})
+	require.InDelta(t, .5, anyOrder.Push("b"), 1e-9)
+	require.InDelta(t, .5, anyOrder.Push("b"), 1e-9)
+	require.InDelta(t, 1., anyOrder.Push("a"), 1e-9)
+
+	require.InDelta(t, 1., deeply.NewStreamRanker(nil, deeply.StreamInOrder, deeply.StreamOptions{}).Score(), 1e-9)
+	require.Zero(t, deeply.NewStreamRanker(nil, deeply.StreamExact, deeply.StreamOptions{}).Push("a"))
+}
+
+func TestStreamRanker_Partial(t *testing.T) {
+	expect := []any{map[string]any{"user": "alice", "amount": 10.0}}
+
+	r := deeply.NewRanker(deeply.WithWeight("$.amount", 0))
+	require.InDelta(t,
+		r.Stream(expect, deeply.StreamExact, deeply.StreamOptions{}).Push(map[string]any{"user": "alice", "amount": 50.0}),
+		r.Stream(expect, deeply.StreamExact, deeply.StreamOptions{}).Push(map[string]any{"user": "alice", "amount": 99.0}),
+		1e-9, "the weights of the ranker apply")
+
+	// The messages score relative to the expected messages against themselves.
+	s := deeply.NewStreamRanker(expect, deeply.StreamExact, deeply.StreamOptions{})
+	near := s.Push(map[string]any{"user": "alice", "amount": 11.0})
+
+	s = deeply.NewStreamRanker(expect, deeply.StreamExact, deeply.StreamOptions{})
+	far := s.Push(map[string]any{"user": "bob", "amount": 10.0})
+
+	require.Less(t, near, 1.)
+	require.Greater(t, near, far)
+	require.Positive(t, far)
+}
+
+func TestStreamRanker_AnyOrderPrune(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(3, 4))
+
+	expect := []any{10.0, 20.0, 30.0}
+
+	// The ranker without a window only keeps the messages which can be part
+	// of the assignment, a window as long as the stream keeps them all.
+	pruned := deeply.NewStreamRanker(expect, deeply.StreamAnyOrder, deeply.StreamOptions{})
+	all := deeply.NewStreamRanker(expect, deeply.StreamAnyOrder, deeply.StreamOptions{Window: 1000})
+
+	for range 200 {
+		msg := float64(rnd.IntN(40))
+		require.InDelta(t, all.Push(msg), pruned.Push(msg), 1e-12)
+	}
+}
+
+func TestStreamRanker_AnyOrderWindow(t *testing.T) {
+	s := deeply.NewStreamRanker([]any{"a", "b"}, deeply.StreamAnyOrder, deeply.StreamOptions{Window: 2})
+
+	for _, msg := range []string{"a", "x", "b", "x"} {
+		s.Push(msg)
+	}
+
+	require.InDelta(t, .5, s.Score(), 1e-9, "a and b are too far apart")
+	require.InDelta(t, .5, s.Push("a"), 1e-9)
+	require.InDelta(t, 1., s.Push("b"), 1e-9)
+	require.InDelta(t, 1., s.Push("x"), 1e-9, "the best window so far")
+}
@@ -0,0 +1,172 @@
+package deeply
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// StringFold is a set of transformations applied by a Ranker to strings
+// before it calculates the distance between them, so that strings which
+// look the same score as equal. Any of them normalizes the strings to the
+// Unicode canonical composition (NFC) first, so that "é" precomposed and
+// "e" followed by a combining acute accent are equal.
+//
+// The folding applies to the distance and the equality of strings, not to
+// the expected strings used as regular expressions.
+type StringFold int
+
+const (
+	// FoldGraphemes compares grapheme clusters instead of runes, so that a
+	// letter with combining marks or an emoji sequence counts as a single
+	// character of the distance.
+	FoldGraphemes StringFold = 1 << iota
+	// FoldCase compares strings by their Unicode case folding, e.g. "STRASSE" equals "straße".
+	FoldCase
+	// FoldAccents strips the diacritical marks, e.g. "Müller" equals "Muller".
+	FoldAccents
+)
+
+// zwj is the zero width joiner, which joins emojis into a single cluster.
+const zwj = '\u200d'
+
+// clusterBase is the first rune assigned to the grapheme clusters of
+// several runes, past the last code point of Unicode.
+const clusterBase = unicode.MaxRune + 1
+
+// WithStringFold sets the transformations applied to strings before the
+// ranker compares them, e.g. FoldGraphemes|FoldCase|FoldAccents for
+// internationalized names.
+func WithStringFold(fold StringFold) RankOption {
+	return func(r *Ranker) {
+		r.fold = fold
+	}
+}
+
+// apply transforms the string according to the folding.
+func (f StringFold) apply(s string) string {
+	if f&FoldAccents != 0 {
+		// Decompose the letters to strip their marks, then compose them back.
+		stripped, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), s)
+		if err == nil {
+			s = stripped
+		}
+	} else {
+		s = norm.NFC.String(s)
+	}
+
+	if f&FoldCase != 0 {
+		s = cases.Fold().String(s)
+	}
+
+	return s
+}
+
+// stringScore calculates the normalized distance between two strings after
+// the folding of the ranker. It returns 0 if the result is lower than minScore.
+func (r *Ranker) stringScore(expect, actual string, minScore float64) float64 {
+	if r.fold == 0 {
+		return distanceAtLeast(expect, actual, minScore)
+	}
+
+	expect, actual = r.fold.apply(expect), r.fold.apply(actual)
+
+	if r.fold&FoldGraphemes != 0 {
+		return graphemeDistanceAtLeast(expect, actual, minScore)
+	}
+
+	return distanceAtLeast(expect, actual, minScore)
+}
+
+// graphemeDistanceAtLeast calculates the normalized Levenshtein distance
+// between the grapheme clusters of two strings like distanceAtLeast.
+func graphemeDistanceAtLeast(s, t string, minScore float64) float64 {
+	// ASCII strings and invalid UTF-8 strings have no clusters of several runes.
+	if s == t || s == "" || t == "" || isASCII(s) && isASCII(t) || !utf8.ValidString(s) || !utf8.ValidString(t) {
+		return distanceAtLeast(s, t, minScore)
+	}
+
+	ids := make(map[string]rune)
+
+	return normalizedDistance(clusters(s, ids), clusters(t, ids), minScore)
+}
+
+// clusters splits a string into grapheme clusters and returns a rune for
+// each of them: the rune itself for single rune clusters, a rune past the
+// last code point of Unicode for the others, the same for equal clusters.
+func clusters(s string, ids map[string]rune) []rune {
+	res := make([]rune, 0, len(s))
+
+	for start := 0; start < len(s); {
+		end := clusterEnd(s, start)
+
+		if r, size := utf8.DecodeRuneInString(s[start:]); start+size == end {
+			res = append(res, r)
+		} else {
+			id, ok := ids[s[start:end]]
+			if !ok {
+				id = clusterBase + rune(len(ids))
+				ids[s[start:end]] = id
+			}
+
+			res = append(res, id)
+		}
+
+		start = end
+	}
+
+	return res
+}
+
+// clusterEnd returns the end of the grapheme cluster starting at the index.
+//
+// The clusters approximate the extended grapheme clusters of Unicode: a rune
+// followed by its combining marks and emoji modifiers, runes joined by the
+// zero width joiner, and pairs of regional indicators (flags).
+func clusterEnd(s string, start int) int {
+	r, size := utf8.DecodeRuneInString(s[start:])
+	end := start + size
+
+	// A flag is a pair of regional indicators.
+	if isRegionalIndicator(r) {
+		if next, size := utf8.DecodeRuneInString(s[end:]); isRegionalIndicator(next) {
+			end += size
+		}
+	}
+
+	for end < len(s) {
+		next, size := utf8.DecodeRuneInString(s[end:])
+
+		switch {
+		case next == zwj:
+			end += size
+
+			// The joiner glues the next rune to the cluster.
+			if end < len(s) {
+				_, size = utf8.DecodeRuneInString(s[end:])
+				end += size
+			}
+		case unicode.Is(unicode.M, next) || isEmojiModifier(next):
+			end += size
+		default:
+			return end
+		}
+	}
+
+	return end
+}
+
+// isRegionalIndicator checks if the rune is a regional indicator symbol,
+// two of which make a flag.
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// isEmojiModifier checks if the rune is an emoji skin tone modifier.
+func isEmojiModifier(r rune) bool {
+	return r >= 0x1F3FB && r <= 0x1F3FF
+}
@@ -0,0 +1,69 @@
+package deeply_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+func TestText_Normalization(t *testing.T) {
+	precomposed, decomposed := "Ren\u00e9e", "Rene\u0301e"
+
+	require.Less(t, deeply.RankMatch(precomposed, decomposed), 1.)
+
+	for _, fold := range []deeply.StringFold{deeply.FoldGraphemes, deeply.FoldCase, deeply.FoldAccents} {
+		r := deeply.NewRanker(deeply.WithStringFold(fold))
+		require.InDelta(t, 1., r.RankMatch(precomposed, decomposed), 1e-9, fold)
+	}
+}
+
+func TestText_Graphemes(t *testing.T) {
+	r := deeply.NewRanker(deeply.WithStringFold(deeply.FoldGraphemes))
+
+	// A letter with several combining marks is a single character.
+	require.InDelta(t, 5./6., r.RankMatch("k\u00f8\u0303tten", "kotten"), 1e-9)
+	require.InDelta(t, 5./7., deeply.RankMatch("k\u00f8\u0303tten", "kotten"), 1e-9)
+
+	// So are emoji sequences and flags.
+	require.InDelta(t, .5, r.RankMatch(
+		"\U0001F469\u200d\U0001F469\u200d\U0001F467a",
+		"\U0001F468\u200d\U0001F469\u200d\U0001F467a",
+	), 1e-9)
+	require.InDelta(t, .5, r.RankMatch("\U0001F44D\U0001F3FDa", "\U0001F44D\U0001F3FFa"), 1e-9)
+	require.InDelta(t, .5, r.RankMatch("\U0001F1E9\U0001F1EAa", "\U0001F1EB\U0001F1F7a"), 1e-9)
+	require.InDelta(t, 2./3., r.RankMatch("\U0001F1E9\U0001F1EAab", "\U0001F1E9\U0001F1EAac"), 1e-9)
+
+	require.InDelta(t, deeply.RankMatch("kitten", "sitting"), r.RankMatch("kitten", "sitting"), 1e-9)
+}
+
+func TestText_Case(t *testing.T) {
+	r := deeply.NewRanker(deeply.WithStringFold(deeply.FoldCase))
+
+	require.InDelta(t, 1., r.RankMatch("STRASSE", "straße"), 1e-9)
+	require.InDelta(t, 1., r.RankMatch("\u0130stanbul", "i\u0307stanbul"), 1e-9)
+	require.Less(t, r.RankMatch("Müller", "Muller"), 1.)
+	require.Less(t, deeply.RankMatch("Alice", "ALICE"), 1.)
+}
+
+func TestText_Accents(t *testing.T) {
+	r := deeply.NewRanker(deeply.WithStringFold(deeply.FoldAccents))
+
+	require.InDelta(t, 1., r.RankMatch("Müller", "Muller"), 1e-9)
+	require.InDelta(t, 1., r.RankMatch("Zoë Saldaña", "Zoe Saldana"), 1e-9)
+	require.Less(t, r.RankMatch("Müller", "MULLER"), 1.)
+
+	all := deeply.NewRanker(deeply.WithStringFold(deeply.FoldGraphemes | deeply.FoldCase | deeply.FoldAccents))
+	require.InDelta(t, 1., all.RankMatch("Müller", "MULLER"), 1e-9)
+	require.InDelta(t, 1., all.RankMatch("JOS\u00c9", "Jose\u0301"), 1e-9)
+}
+
+func TestText_Fields(t *testing.T) {
+	r := deeply.NewRanker(deeply.WithStringFold(deeply.FoldCase | deeply.FoldAccents))
+	stub := map[string]any{"name": "François Müller"}
+
+	require.Greater(t,
+		r.RankMatch(stub, map[string]any{"name": "FRANCOIS MULLER"}),
+		r.RankMatch(stub, map[string]any{"name": "Francesca Miller"}))
+}
@@ -0,0 +1,281 @@
+package deeply
+
+import (
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// timeScale is the time difference at which the closeness score of two
+// instants drops to one half.
+const timeScale = time.Hour
+
+// timeLayouts are the layouts used to parse time strings, in order of preference.
+//
+//nolint:gochecknoglobals
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+//nolint:gochecknoglobals
+var (
+	clockMu sync.RWMutex
+	clock   = time.Now
+)
+
+// SetClock replaces the function used by the time operators to get the
+// current time and returns a function restoring the previous one.
+// It is intended to make expectations such as {"$within": "5m"} deterministic in tests.
+func SetClock(now func() time.Time) (restore func()) {
+	clockMu.Lock()
+	defer clockMu.Unlock()
+
+	prev := clock
+	clock = now
+
+	return func() {
+		clockMu.Lock()
+		defer clockMu.Unlock()
+
+		clock = prev
+	}
+}
+
+// currentTime returns the current time according to the configured clock.
+func currentTime() time.Time {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+
+	return clock()
+}
+
+// toTime converts a value to a time.Time.
+// It accepts:
+//   - time.Time and *time.Time values.
+//   - Strings in the RFC3339 format, without a zone (UTC is assumed) or a date only.
+//   - Protobuf Timestamp-shaped maps: {"seconds": 1700000000, "nanos": 0}.
+func toTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, false
+		}
+
+		return *v, true
+	case string:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	case map[string]any:
+		return timestampToTime(v)
+	}
+
+	return time.Time{}, false
+}
+
+// timestampToTime converts a protobuf Timestamp-shaped map to a time.Time.
+// The map must contain the "seconds" key and may contain the "nanos" key only.
+func timestampToTime(value map[string]any) (time.Time, bool) {
+	for key := range value {
+		if key != "seconds" && key != "nanos" {
+			return time.Time{}, false
+		}
+	}
+
+	sec, err := cast.ToInt64E(value["seconds"])
+	if _, ok := value["seconds"]; !ok || err != nil {
+		return time.Time{}, false
+	}
+
+	nsec, err := cast.ToInt64E(value["nanos"])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(sec, nsec).UTC(), true
+}
+
+// timeCloseness calculates the match score of two instants.
+// It returns 1 for equal instants and decreases as the difference grows,
+// reaching 0.5 when the instants are timeScale apart.
+func timeCloseness(d time.Duration) float64 {
+	return 1 / (1 + math.Abs(float64(d))/float64(timeScale))
+}
+
+// rankTime calculates the match score of an expected time and an actual time.
+// It returns false if either of the values can't be converted to a time.
+func rankTime(expect, actual any) (float64, bool) {
+	e, ok := toTime(expect)
+	if !ok {
+		return 0, false
+	}
+
+	a, ok := toTime(actual)
+	if !ok {
+		return 0, false
+	}
+
+	return timeCloseness(a.Sub(e)), true
+}
+
+// operandTime converts an operand of the time operators to a time.Time like
+// toTime. The "now" string is resolved using the configured clock: it is a
+// keyword of the expectations, not a time of the actual values.
+func operandTime(value any) (time.Time, bool) {
+	if s, ok := value.(string); ok && s == "now" {
+		return currentTime(), true
+	}
+
+	return toTime(value)
+}
+
+// timeOperand converts the operand of a time operator to a time.Time.
+// It logs an error if the operand is not a time.
+func timeOperand(node map[string]any, name string) (time.Time, bool) {
+	t, ok := operandTime(node[name])
+	if !ok {
+		log.Printf("Error on parsing %s operand %v: not a time\n", name, node[name])
+	}
+
+	return t, ok
+}
+
+// matchBefore checks if the actual time is strictly before the operand of $before.
+func matchBefore(node map[string]any, actual any, _ cmp) bool {
+	return rankBefore(node, actual, nil) == 1
+}
+
+// rankBefore scores the actual time against the operand of $before.
+// Times after the bound score by their closeness to it.
+func rankBefore(node map[string]any, actual any, _ ranker) float64 {
+	bound, ok := timeOperand(node, "$before")
+	if !ok {
+		return 0
+	}
+
+	a, ok := toTime(actual)
+	if !ok {
+		return 0
+	}
+
+	if a.Before(bound) {
+		return 1
+	}
+
+	return timeCloseness(a.Sub(bound)) / 2 //nolint:mnd
+}
+
+// matchAfter checks if the actual time is strictly after the operand of $after.
+func matchAfter(node map[string]any, actual any, _ cmp) bool {
+	return rankAfter(node, actual, nil) == 1
+}
+
+// rankAfter scores the actual time against the operand of $after.
+// Times before the bound score by their closeness to it.
+func rankAfter(node map[string]any, actual any, _ ranker) float64 {
+	bound, ok := timeOperand(node, "$after")
+	if !ok {
+		return 0
+	}
+
+	a, ok := toTime(actual)
+	if !ok {
+		return 0
+	}
+
+	if a.After(bound) {
+		return 1
+	}
+
+	return timeCloseness(bound.Sub(a)) / 2 //nolint:mnd
+}
+
+// matchWithin checks if the actual time is within the duration of $within from now.
+func matchWithin(node map[string]any, actual any, _ cmp) bool {
+	return rankWithin(node, actual, nil) == 1
+}
+
+// rankWithin scores the actual time against the duration of $within.
+// The duration is either a string parsed by time.ParseDuration or a number of seconds.
+// Times outside the window score by their closeness to its edge.
+func rankWithin(node map[string]any, actual any, _ ranker) float64 {
+	var window time.Duration
+
+	switch v := node["$within"].(type) {
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Printf("Error on parsing $within duration %s error:%v\n", v, err)
+
+			return 0
+		}
+
+		window = d
+	default:
+		seconds, err := cast.ToFloat64E(v)
+		if err != nil {
+			log.Printf("Error on parsing $within duration %v error:%v\n", v, err)
+
+			return 0
+		}
+
+		window = time.Duration(seconds * float64(time.Second))
+	}
+
+	a, ok := toTime(actual)
+	if !ok {
+		return 0
+	}
+
+	d := currentTime().Sub(a)
+	if d < 0 {
+		d = -d
+	}
+
+	if d <= window.Abs() {
+		return 1
+	}
+
+	return timeCloseness(d-window.Abs()) / 2 //nolint:mnd
+}
+
+// matchSameDay checks if the actual time falls on the same calendar day as the
+// operand of $sameDay, in the location of the operand.
+func matchSameDay(node map[string]any, actual any, _ cmp) bool {
+	return rankSameDay(node, actual, nil) == 1
+}
+
+// rankSameDay scores the actual time against the day of $sameDay.
+// Times on other days score by their closeness to the day.
+func rankSameDay(node map[string]any, actual any, _ ranker) float64 {
+	day, ok := timeOperand(node, "$sameDay")
+	if !ok {
+		return 0
+	}
+
+	a, ok := toTime(actual)
+	if !ok {
+		return 0
+	}
+
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+
+	switch a = a.In(day.Location()); {
+	case a.Before(start):
+		return timeCloseness(start.Sub(a)) / 2 //nolint:mnd
+	case !a.Before(end):
+		return timeCloseness(a.Sub(end)) / 2 //nolint:mnd
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,104 @@
+package deeply_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+func TestTime_BeforeAfter(t *testing.T) {
+	before := map[string]any{"$before": "2024-01-01T00:00:00Z"}
+	after := map[string]any{"$after": "2024-01-01T00:00:00Z"}
+
+	require.True(t, deeply.Matches(before, "2023-12-31T23:59:59Z"))
+	require.False(t, deeply.Matches(before, "2024-01-01T00:00:00Z"))
+	require.False(t, deeply.Matches(before, "2024-01-01T03:00:00+02:00"))
+	require.True(t, deeply.Matches(before, "2024-01-01T01:00:00+02:00"))
+
+	require.True(t, deeply.Contains(after, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)))
+	require.False(t, deeply.Contains(after, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)))
+
+	require.True(t, deeply.Matches(after, map[string]any{"seconds": 1704067201, "nanos": 0}))
+	require.False(t, deeply.Matches(after, map[string]any{"seconds": 1704067200}))
+	require.False(t, deeply.Matches(after, map[string]any{"seconds": 1704067201, "extra": 1}))
+
+	require.False(t, deeply.Matches(before, "yesterday"))
+	require.False(t, deeply.Matches(before, 1))
+	require.False(t, deeply.Matches(map[string]any{"$before": "tomorrow"}, "2023-12-31T23:59:59Z"))
+}
+
+func TestTime_Range(t *testing.T) {
+	expect := map[string]any{
+		"created_at": map[string]any{
+			"$after":  "2024-01-01",
+			"$before": "2024-02-01",
+		},
+	}
+
+	require.True(t, deeply.Matches(expect, map[string]any{"created_at": "2024-01-15T12:00:00Z", "id": 1}))
+	require.False(t, deeply.Matches(expect, map[string]any{"created_at": "2024-02-15T12:00:00Z", "id": 1}))
+	require.True(t, deeply.Equals(expect, map[string]any{"created_at": "2024-01-15T12:00:00Z"}))
+	require.True(t, deeply.MatchesIgnoreArrayOrder(
+		[]any{expect, "b"},
+		[]any{"b", map[string]any{"created_at": "2024-01-15T12:00:00Z"}},
+	))
+}
+
+func TestTime_Within(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	defer deeply.SetClock(func() time.Time { return now })()
+
+	expect := map[string]any{"$within": "5m"}
+
+	require.True(t, deeply.Matches(expect, now))
+	require.True(t, deeply.Matches(expect, "2024-05-01T11:56:00Z"))
+	require.True(t, deeply.Matches(expect, "2024-05-01T12:04:00Z"))
+	require.False(t, deeply.Matches(expect, "2024-05-01T12:06:00Z"))
+	require.True(t, deeply.Matches(map[string]any{"$within": 60}, "2024-05-01T12:01:00Z"))
+	require.False(t, deeply.Matches(map[string]any{"$within": "five minutes"}, now))
+
+	require.True(t, deeply.Matches(map[string]any{"$before": "now"}, "2024-05-01T11:00:00Z"))
+	require.False(t, deeply.Matches(map[string]any{"$before": "now"}, "2024-05-01T13:00:00Z"))
+
+	// "now" is a keyword of the operands only, not a time of the requests.
+	require.False(t, deeply.Matches(expect, "now"))
+	require.False(t, deeply.Matches(map[string]any{"$sameDay": "2024-05-01"}, "now"))
+	require.False(t, deeply.Matches(map[string]any{"$after": "2024-01-01"}, "now"))
+	require.False(t, deeply.Matches(map[string]any{"$before": "2025-01-01"}, "now"))
+	require.Zero(t, deeply.RankMatch(expect, "now"))
+}
+
+func TestTime_SameDay(t *testing.T) {
+	expect := map[string]any{"$sameDay": "2024-03-10T00:00:00+03:00"}
+
+	require.True(t, deeply.Matches(expect, "2024-03-10T23:59:59+03:00"))
+	require.True(t, deeply.Matches(expect, "2024-03-09T21:00:00Z"))
+	require.False(t, deeply.Matches(expect, "2024-03-09T20:59:59Z"))
+	require.False(t, deeply.Matches(expect, "2024-03-11T00:00:00+03:00"))
+}
+
+func TestTime_RankMatch(t *testing.T) {
+	require.Equal(t,
+		[]any{"2024-01-01T00:00:01Z", "2024-01-01T01:00:00Z", "2024-01-03T00:00:00Z", "2023-01-01T00:00:00Z"},
+		ranker("2024-01-01T00:00:00Z", []any{
+			"2023-01-01T00:00:00Z",
+			"2024-01-03T00:00:00Z",
+			"2024-01-01T00:00:01Z",
+			"2024-01-01T01:00:00Z",
+		}))
+
+	require.InDelta(t, 1., deeply.RankMatch("2024-01-01T00:00:00Z", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)), 1e-9)
+	require.InDelta(t, .5, deeply.RankMatch("2024-01-01T00:00:00Z", "2024-01-01T01:00:00Z"), 1e-9)
+
+	before := map[string]any{"$before": "2024-01-01T00:00:00Z"}
+
+	require.InDelta(t, 1., deeply.RankMatch(before, "2023-01-01T00:00:00Z"), 1e-9)
+	require.Greater(t,
+		deeply.RankMatch(before, "2024-01-01T00:00:01Z"),
+		deeply.RankMatch(before, "2024-06-01T00:00:00Z"))
+	require.Less(t, deeply.RankMatch(before, "2024-01-01T00:00:01Z"), 1.)
+	require.Zero(t, deeply.RankMatch(before, "not a time"))
+}
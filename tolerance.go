@@ -0,0 +1,203 @@
+package deeply
+
+import (
+	"log"
+	"maps"
+	"math"
+	"slices"
+)
+
+// tolerance holds the differences under which two numbers are equal.
+type tolerance struct {
+	abs  float64 // Absolute difference.
+	rel  float64 // Difference relative to the larger number.
+	ulps uint64  // Number of representable float64 values between the numbers.
+}
+
+// equal checks if two numbers are equal within the tolerance.
+// NaN equals nothing, not even NaN, and infinities equal infinities of the
+// same sign only, whatever the tolerance.
+func (t tolerance) equal(a, b float64) bool {
+	switch {
+	case a == b:
+		return true
+	case math.IsNaN(a) || math.IsNaN(b) || math.IsInf(a, 0) || math.IsInf(b, 0):
+		return false
+	case math.Abs(a-b) <= max(t.abs, t.rel*max(math.Abs(a), math.Abs(b))):
+		return true
+	default:
+		return t.ulps > 0 && ulpDistance(a, b) <= t.ulps
+	}
+}
+
+// deepEqual checks if the values are deeply equal like deepEqual, numbers
+// being equal within the tolerance whatever their Go types. The elements of
+// slices and the values of maps of decoded JSON are compared recursively.
+func (t tolerance) deepEqual(expect, actual any) bool {
+	if isNumber(expect) && isNumber(actual) {
+		return t.equal(toFloat(expect), toFloat(actual))
+	}
+
+	if left, ok := expect.(map[string]any); ok {
+		right, ok := actual.(map[string]any)
+
+		return ok && (left == nil) == (right == nil) && maps.EqualFunc(left, right, t.deepEqual)
+	}
+
+	if left, ok := expect.([]any); ok {
+		right, ok := actual.([]any)
+
+		return ok && (left == nil) == (right == nil) && slices.EqualFunc(left, right, t.deepEqual)
+	}
+
+	return deepEqual(expect, actual)
+}
+
+// ulpDistance returns the number of representable float64 values between
+// two finite numbers, 0 and -0 being the same value.
+func ulpDistance(a, b float64) uint64 {
+	x, y := orderedBits(a), orderedBits(b)
+	if x < y {
+		x, y = y, x
+	}
+
+	return uint64(x) - uint64(y)
+}
+
+// orderedBits maps a float64 to an int64 preserving the order of the numbers,
+// so that adjacent numbers map to adjacent integers.
+func orderedBits(f float64) int64 {
+	bits := int64(math.Float64bits(f)) //nolint:gosec
+	if bits < 0 {
+		return math.MinInt64 - bits
+	}
+
+	return bits
+}
+
+// WithNumberTolerance sets the differences under which the ranker scores two
+// numbers as equal: the absolute difference, e.g. 0.01 for prices in
+// dollars, or the difference relative to the larger number, e.g. 1e-9 for
+// floating point rounding errors. Numbers farther apart are scored by their
+// relative difference, so that 100 is closer to 101 than to 900.
+func WithNumberTolerance(absolute, relative float64) RankOption {
+	return func(r *Ranker) {
+		r.tolerance.abs = max(absolute, 0)
+		r.tolerance.rel = max(relative, 0)
+	}
+}
+
+// WithULPTolerance sets the number of representable float64 values between
+// two numbers under which the ranker scores them as equal, e.g. 4 to absorb
+// the rounding errors of a few arithmetic operations whatever the magnitude
+// of the numbers.
+func WithULPTolerance(ulps uint64) RankOption {
+	return func(r *Ranker) {
+		r.tolerance.ulps = ulps
+	}
+}
+
+// WithMatchTolerance sets the differences under which the matcher checks
+// two numbers as equal, like WithNumberTolerance for rankers: the absolute
+// difference or the difference relative to the larger number.
+func WithMatchTolerance(absolute, relative float64) MatchOption {
+	return func(m *Matcher) {
+		m.tolerance.abs = max(absolute, 0)
+		m.tolerance.rel = max(relative, 0)
+	}
+}
+
+// WithMatchULPTolerance sets the number of representable float64 values
+// between two numbers under which the matcher checks them as equal, like
+// WithULPTolerance for rankers.
+func WithMatchULPTolerance(ulps uint64) MatchOption {
+	return func(m *Matcher) {
+		m.tolerance.ulps = ulps
+	}
+}
+
+// numberScore calculates the match score of two numbers: 1 if they are equal
+// within the tolerance of the ranker, their closeness otherwise.
+// NaN matches nothing and infinities match equal infinities only.
+func (r *Ranker) numberScore(expect, actual float64) float64 {
+	switch {
+	case r.tolerance.equal(expect, actual):
+		return 1
+	case math.IsNaN(expect) || math.IsNaN(actual) || math.IsInf(expect, 0) || math.IsInf(actual, 0):
+		return 0
+	default:
+		return numberCloseness(expect, actual)
+	}
+}
+
+// approxOperands returns the operand of $approx and the tolerance set by the
+// $tol (absolute), $rel (relative) and $ulp modifiers.
+// It returns false if the operand or a modifier is not a number.
+func approxOperands(node map[string]any) (float64, tolerance, bool) {
+	operand, ok := toNumber(node["$approx"])
+	if !ok {
+		log.Printf("Error on parsing $approx operand %v: not a number\n", node["$approx"])
+
+		return 0, tolerance{}, false
+	}
+
+	var res tolerance
+
+	for name, dst := range map[string]*float64{"$tol": &res.abs, "$rel": &res.rel} {
+		if value, ok := node[name]; ok {
+			if *dst, ok = toNumber(value); !ok || *dst < 0 {
+				log.Printf("Error on parsing %s modifier %v: not a non-negative number\n", name, value)
+
+				return 0, tolerance{}, false
+			}
+		}
+	}
+
+	if value, ok := node["$ulp"]; ok {
+		ulps, ok := toNumber(value)
+		if !ok || ulps < 0 || ulps != math.Trunc(ulps) {
+			log.Printf("Error on parsing $ulp modifier %v: not a non-negative integer\n", value)
+
+			return 0, tolerance{}, false
+		}
+
+		res.ulps = uint64(min(ulps, math.MaxInt64))
+	}
+
+	return operand, res, true
+}
+
+// matchApprox checks if the actual number equals $approx within the tolerance.
+// Without modifiers the numbers must be equal, whatever their Go types.
+func matchApprox(node map[string]any, actual any, _ cmp) bool {
+	operand, tol, ok := approxOperands(node)
+	if !ok {
+		return false
+	}
+
+	value, ok := toNumber(actual)
+
+	return ok && tol.equal(operand, value)
+}
+
+// rankApprox scores the actual number by its closeness to $approx, numbers
+// out of the tolerance score at most one half.
+func rankApprox(node map[string]any, actual any, _ ranker) float64 {
+	operand, tol, ok := approxOperands(node)
+	if !ok {
+		return 0
+	}
+
+	value, ok := toNumber(actual)
+
+	switch {
+	case !ok:
+		return 0
+	case tol.equal(operand, value):
+		return 1
+	case math.IsNaN(operand) || math.IsNaN(value) || math.IsInf(operand, 0) || math.IsInf(value, 0):
+		return 0
+	default:
+		return numberCloseness(operand, value) / 2 //nolint:mnd
+	}
+}
@@ -0,0 +1,147 @@
+package deeply_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+func TestTolerance_Absolute(t *testing.T) {
+	r := deeply.NewRanker(deeply.WithNumberTolerance(.01, 0))
+
+	require.InDelta(t, 1., r.RankMatch(9.99, 10.0), 1e-9)
+	require.InDelta(t, 1., r.RankMatch(10, 9.995), 1e-9)
+	require.Less(t, r.RankMatch(9.99, 10.01), 1.)
+	require.Less(t, deeply.RankMatch(9.99, 10.0), 1.)
+}
+
+func TestTolerance_Relative(t *testing.T) {
+	r := deeply.NewRanker(deeply.WithNumberTolerance(0, 1e-9))
+	x, y := 0.1, 0.2
+
+	require.InDelta(t, 1., r.RankMatch(0.3, x+y), 1e-9)
+	require.InDelta(t, 1., r.RankMatch(1e12, 1e12+1), 1e-9)
+	require.Less(t, r.RankMatch(1, 1+1e-6), 1.)
+	require.Less(t, deeply.RankMatch(0.3, x+y), 1.)
+}
+
+func TestTolerance_Fields(t *testing.T) {
+	r := deeply.NewRanker(deeply.WithNumberTolerance(.5, 0))
+	stub := map[string]any{"lat": 52.52, "lon": 13.40}
+
+	require.InDelta(t,
+		r.RankMatch(stub, map[string]any{"lat": 52.5, "lon": 13.45}),
+		r.RankMatch(stub, map[string]any{"lat": 52.6, "lon": 13.3}), 1e-9)
+	require.Greater(t,
+		r.RankMatch(stub, map[string]any{"lat": 52.6, "lon": 13.3}),
+		r.RankMatch(stub, map[string]any{"lat": 48.1, "lon": 11.6}))
+}
+
+func TestTolerance_ULP(t *testing.T) {
+	r := deeply.NewRanker(deeply.WithULPTolerance(4))
+
+	require.InDelta(t, 1., r.RankMatch(1e20, math.Nextafter(1e20, math.Inf(1))), 1e-9)
+	require.InDelta(t, 1., r.RankMatch(1e-20, math.Nextafter(1e-20, 0)), 1e-9)
+	require.InDelta(t, 1., r.RankMatch(0.0, math.Copysign(0, -1)), 1e-9)
+	require.InDelta(t, 1., r.RankMatch(math.SmallestNonzeroFloat64, -math.SmallestNonzeroFloat64), 1e-9)
+	require.Less(t, r.RankMatch(1.0, 1+1e-12), 1.)
+}
+
+func TestTolerance_Approx(t *testing.T) {
+	x, y := 0.1, 0.2
+
+	for _, match := range []func(expect, actual any) bool{deeply.Matches, deeply.Contains, deeply.Equals} {
+		require.True(t, match(map[string]any{"$approx": 0.3, "$tol": 1e-9}, x+y))
+		require.False(t, match(map[string]any{"$approx": 0.3}, x+y), "no tolerance by default")
+		require.True(t, match(map[string]any{"$approx": 3}, int64(3)), "numbers of any type")
+		require.True(t, match(map[string]any{"$approx": 3.14, "$tol": 0.01}, "3.145"), "numeric strings")
+		require.False(t, match(map[string]any{"$approx": 3.14, "$tol": 0.01}, 3.151))
+		require.True(t, match(map[string]any{"$approx": 100, "$rel": 0.01}, 100.9))
+		require.False(t, match(map[string]any{"$approx": 100, "$rel": 0.01}, 98.9))
+		require.True(t, match(map[string]any{"$approx": 0.3, "$ulp": 1}, x+y))
+		require.False(t, match(map[string]any{"$approx": 3.14, "$tol": 0.01}, "pi"))
+		require.False(t, match(map[string]any{"$approx": "pi"}, 3.14))
+		require.False(t, match(map[string]any{"$approx": 3.14, "$tol": -1}, 3.14))
+		require.False(t, match(map[string]any{"$approx": 3.14, "$ulp": 1.5}, 3.14))
+
+		require.True(t, match(
+			map[string]any{"lat": map[string]any{"$approx": 52.52, "$tol": 0.01}},
+			map[string]any{"lat": 52.521}))
+	}
+}
+
+func TestTolerance_ApproxArrays(t *testing.T) {
+	approx := map[string]any{"$approx": 3.14, "$tol": 0.01}
+
+	for _, match := range []func(expect, actual any) bool{
+		deeply.Matches, deeply.MatchesIgnoreArrayOrder,
+		deeply.Equals, deeply.EqualsIgnoreArrayOrder,
+	} {
+		require.True(t, match([]any{approx, 1.0}, []any{3.141, 1.0}))
+		require.True(t, match(map[string]any{"pts": []any{approx}}, map[string]any{"pts": []any{3.145}}))
+		require.False(t, match([]any{approx}, []any{3.151}))
+	}
+
+	require.False(t, deeply.Equals([]any{approx}, []any{3.141, 1.0}))
+	require.False(t, deeply.Equals([]any{approx, 1.0}, []any{1.0, 3.141}))
+	require.True(t, deeply.EqualsIgnoreArrayOrder([]any{approx, 1.0}, []any{1.0, 3.141}))
+}
+
+func TestTolerance_ApproxSpecialValues(t *testing.T) {
+	inf := map[string]any{"$approx": math.Inf(1), "$tol": math.Inf(1)}
+	require.True(t, deeply.Matches(inf, math.Inf(1)))
+	require.False(t, deeply.Matches(inf, math.Inf(-1)))
+	require.False(t, deeply.Matches(inf, math.MaxFloat64))
+
+	anything := map[string]any{"$approx": 0, "$tol": math.Inf(1)}
+	require.True(t, deeply.Matches(anything, math.MaxFloat64))
+	require.False(t, deeply.Matches(anything, math.Inf(1)))
+	require.False(t, deeply.Matches(anything, math.NaN()))
+
+	require.False(t, deeply.Matches(map[string]any{"$approx": math.NaN(), "$tol": 1}, math.NaN()), "NaN equals nothing")
+}
+
+func TestTolerance_ApproxRankMatch(t *testing.T) {
+	approx := map[string]any{"$approx": 100, "$tol": 1}
+
+	require.InDelta(t, 1., deeply.RankMatch(approx, 100.5), 1e-9)
+	require.InDelta(t, .49, deeply.RankMatch(approx, 98), 1e-9)
+	require.Greater(t, deeply.RankMatch(approx, 102), deeply.RankMatch(approx, 900))
+	require.Zero(t, deeply.RankMatch(approx, math.NaN()))
+	require.Zero(t, deeply.RankMatch(approx, "hundred"))
+
+	value, err := deeply.Generate(map[string]any{"price": approx}, deeply.ModeEquals)
+	require.NoError(t, err)
+	require.True(t, deeply.Equals(map[string]any{"price": approx}, value))
+}
+
+func TestTolerance_Matcher(t *testing.T) {
+	expect := map[string]any{"price": 0.3, "items": []any{map[string]any{"qty": 1.0}, 2.0}, "name": "^a"}
+	actual := map[string]any{"price": 0.1 + 0.2, "items": []any{map[string]any{"qty": 1.0000001}, 2}, "name": "^a"}
+
+	for _, mode := range []deeply.Mode{
+		deeply.ModeMatches, deeply.ModeMatchesIgnoreArrayOrder,
+		deeply.ModeContains, deeply.ModeContainsIgnoreArrayOrder,
+		deeply.ModeEquals, deeply.ModeEqualsIgnoreArrayOrder,
+	} {
+		require.False(t, mode.Match(expect, actual), mode)
+		require.True(t, deeply.NewMatcher(mode, deeply.WithMatchTolerance(0, 1e-6)).Match(expect, actual), mode)
+		require.False(t, deeply.NewMatcher(mode, deeply.WithMatchTolerance(0, 1e-9)).Match(expect, actual), mode)
+	}
+
+	require.True(t, deeply.NewMatcher(deeply.ModeEquals, deeply.WithMatchTolerance(0.01, 0)).Match(19.995, 20))
+	require.False(t, deeply.NewMatcher(deeply.ModeEquals, deeply.WithMatchTolerance(0.01, 0)).Match(19.98, 20))
+	require.True(t, deeply.NewMatcher(deeply.ModeEquals, deeply.WithMatchULPTolerance(4)).Match(0.3, 0.1+0.2))
+	require.False(t, deeply.NewMatcher(deeply.ModeEquals, deeply.WithMatchULPTolerance(4)).Match(math.NaN(), math.NaN()))
+	require.False(t, deeply.NewMatcher(deeply.ModeEquals, deeply.WithMatchTolerance(1, 0)).Match("1", 1), "strings are not numbers")
+
+	// The tolerance applies to the operands of the operators too.
+	require.True(t, deeply.NewMatcher(deeply.ModeMatches, deeply.WithMatchULPTolerance(4)).Match(
+		map[string]any{"$all": 0.3}, []any{0.1 + 0.2, 0.3}))
+
+	// The zero value matches like Matches.
+	require.True(t, (&deeply.Matcher{}).Match(map[string]any{"a": "^x"}, map[string]any{"a": "xyz"}))
+}
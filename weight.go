@@ -0,0 +1,90 @@
+package deeply
+
+import (
+	"log"
+	"regexp"
+)
+
+// WithWeight sets the weight of the values at the path, used by the ranker
+// when it aggregates the scores of map values and slice elements. A value
+// weighing 5 counts as much as five values of the default weight 1, a value
+// weighing 0 is ignored.
+//
+// Paths are written like the paths of Explain, e.g. "$.user_id" or
+// "$.items[0].sku"; "[*]" stands for any index, e.g. "$.items[*].sku".
+// Expectations may also set the weight of a value in place with the
+// "$weight" modifier, e.g. {"$weight": 5, "$value": "^alice$"}, which takes
+// precedence over the weight of its path.
+func WithWeight(path string, weight float64) RankOption {
+	return func(r *Ranker) {
+		if r.weights == nil {
+			r.weights = make(map[string]float64)
+		}
+
+		r.weights[path] = max(weight, 0)
+	}
+}
+
+// anyIndex matches the indexes of a path, replaced by "[*]" to find the
+// weights of the elements of any index.
+//
+//nolint:gochecknoglobals
+var anyIndex = regexp.MustCompile(`\[\d+\]`)
+
+// child returns the path of a map value or a slice element.
+// Paths are only built if the ranker has weights to look up.
+func (r *Ranker) child(path string, key any) string {
+	if len(r.weights) == 0 {
+		return path
+	}
+
+	return childPath(path, key)
+}
+
+// weight returns the weight of the expected value at the path: the weight
+// set by its "$weight" modifier, the weight of its path, or 1.
+func (r *Ranker) weight(path string, expect any) float64 {
+	if node, ok := expect.(map[string]any); ok {
+		if value, ok := node["$weight"]; ok && isOperatorNode(node) {
+			if w, ok := toNumber(value); ok && w >= 0 {
+				return w
+			}
+
+			log.Printf("Error on parsing $weight %v: not a non-negative number\n", value)
+		}
+	}
+
+	if len(r.weights) == 0 {
+		return 1
+	}
+
+	if w, ok := r.weights[path]; ok {
+		return w
+	}
+
+	if w, ok := r.weights[anyIndex.ReplaceAllString(path, "[*]")]; ok {
+		return w
+	}
+
+	return 1
+}
+
+// matchValue checks if the actual value matches $value, the expectation
+// wrapped by a node to carry modifiers such as $weight. The values are
+// compared by the comparator named by the $custom modifier if any.
+func matchValue(node map[string]any, actual any, compare cmp) bool {
+	if c, ok := customComparator(node); ok {
+		return c.match(node["$value"], actual)
+	}
+
+	return compare(node["$value"], actual)
+}
+
+// rankValue scores the actual value against $value.
+func rankValue(node map[string]any, actual any, compare ranker) float64 {
+	if c, ok := customComparator(node); ok {
+		return c.rank(node["$value"], actual)
+	}
+
+	return compare(node["$value"], actual)
+}
@@ -0,0 +1,95 @@
+package deeply_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gripmock/deeply"
+)
+
+func TestWeight_ZeroRanker(t *testing.T) {
+	expect := map[string]any{"a": "a", "b": []any{"x", "y"}, "c": map[string]any{"d": 1.0}}
+	actual := map[string]any{"a": "b", "b": []any{"y"}, "c": map[string]any{"d": 2.0}, "e": true}
+
+	require.InDelta(t, deeply.RankMatch(expect, actual), deeply.NewRanker().RankMatch(expect, actual), 1e-9)
+	require.InDelta(t, deeply.RankMatch(expect, actual), (&deeply.Ranker{}).RankMatch(expect, actual), 1e-9)
+}
+
+func TestWeight_Path(t *testing.T) {
+	stub := map[string]any{"user_id": "42", "trace_id": "abc"}
+	sameUser := map[string]any{"user_id": "42", "trace_id": "xyz"}
+	sameTrace := map[string]any{"user_id": "17", "trace_id": "abc"}
+
+	require.InDelta(t, deeply.RankMatch(stub, sameUser), deeply.RankMatch(stub, sameTrace), 1e-9)
+
+	r := deeply.NewRanker(deeply.WithWeight("$.user_id", 5))
+	require.Greater(t, r.RankMatch(stub, sameUser), r.RankMatch(stub, sameTrace))
+
+	r = deeply.NewRanker(deeply.WithWeight("$.trace_id", 0))
+	require.InDelta(t,
+		r.RankMatch(stub, map[string]any{"user_id": "42", "trace_id": "abd"}),
+		r.RankMatch(stub, sameUser), 1e-9)
+}
+
+func TestWeight_AnyIndex(t *testing.T) {
+	stub := map[string]any{"items": []any{
+		map[string]any{"sku": "a-1", "note": "gift"},
+		map[string]any{"sku": "b-2", "note": "gift"},
+	}}
+	sameSKUs := map[string]any{"items": []any{
+		map[string]any{"sku": "a-1", "note": "none"},
+		map[string]any{"sku": "b-2", "note": "none"},
+	}}
+	sameNotes := map[string]any{"items": []any{
+		map[string]any{"sku": "x-9", "note": "gift"},
+		map[string]any{"sku": "y-8", "note": "gift"},
+	}}
+
+	r := deeply.NewRanker(deeply.WithWeight("$.items[*].sku", 3))
+	require.Greater(t, r.RankMatch(stub, sameSKUs), r.RankMatch(stub, sameNotes))
+
+	r = deeply.NewRanker(deeply.WithWeight("$.items[*].note", 3))
+	require.Greater(t, r.RankMatch(stub, sameNotes), r.RankMatch(stub, sameSKUs))
+
+	r = deeply.NewRanker(
+		deeply.WithWeight("$.items[*].sku", 3),
+		deeply.WithWeight("$.items[0].sku", 0),
+		deeply.WithWeight("$.items[1].sku", 0),
+	)
+	require.Greater(t, r.RankMatch(stub, sameNotes), r.RankMatch(stub, sameSKUs), "exact paths take precedence")
+}
+
+func TestWeight_Annotation(t *testing.T) {
+	stub := map[string]any{
+		"user_id":  map[string]any{"$weight": 5, "$value": "42"},
+		"trace_id": "abc",
+	}
+	sameUser := map[string]any{"user_id": "42", "trace_id": "xyz"}
+	sameTrace := map[string]any{"user_id": "17", "trace_id": "abc"}
+
+	require.Greater(t, deeply.RankMatch(stub, sameUser), deeply.RankMatch(stub, sameTrace))
+
+	r := deeply.NewRanker(deeply.WithWeight("$.user_id", 0))
+	require.Greater(t, r.RankMatch(stub, sameUser), r.RankMatch(stub, sameTrace), "annotations take precedence")
+
+	weighted := map[string]any{"$weight": 2, "$gte": 10}
+	require.InDelta(t, 1., deeply.RankMatch(weighted, 12), 1e-9)
+	require.Greater(t,
+		deeply.RankMatch([]any{weighted, "a"}, []any{12, "b"}),
+		deeply.RankMatch([]any{weighted, "a"}, []any{1, "a"}))
+}
+
+func TestWeight_Value(t *testing.T) {
+	stub := map[string]any{"name": map[string]any{"$weight": 2, "$value": "^ali"}}
+
+	require.True(t, deeply.Matches(stub, map[string]any{"name": "alice"}))
+	require.False(t, deeply.Matches(stub, map[string]any{"name": "bob"}))
+	require.True(t, deeply.Contains(stub, map[string]any{"name": "^ali", "age": 42}))
+	require.False(t, deeply.Equals(stub, map[string]any{"name": "^ali", "age": 42}))
+	require.True(t, deeply.Equals(stub, map[string]any{"name": "^ali"}))
+
+	value, err := deeply.Generate(stub, deeply.ModeMatches)
+	require.NoError(t, err)
+	require.True(t, deeply.Matches(stub, value))
+}